@@ -1,10 +1,20 @@
 package main
 
 import (
+	"api/internal/application/exports"
+	"api/internal/application/notifications"
+	"api/internal/application/scheduling"
+	"api/internal/application/stream"
+	"api/internal/application/trash"
+	"api/internal/application/trends"
+	"api/internal/domain/entities"
+	domain "api/internal/domain/repositories"
 	"api/internal/infrastructure/config"
 	"api/internal/infrastructure/database"
+	"api/internal/infrastructure/database/repositories"
 	"api/internal/infrastructure/http/middlewares"
 	router "api/internal/infrastructure/http/router/mux/routes"
+	"api/internal/infrastructure/storage"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,22 +23,38 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func configurateRoutes(r *mux.Router, db *mongo.Database) *mux.Router {
+func configurateRoutes(r *mux.Router, db *mongo.Database, usersRepository domain.UsersRepository, blobStore domain.BlobStore, trendsCache *trends.Cache, hub *stream.Hub) *mux.Router {
 	routes := []router.Route{}
 
-	usersRoutes := router.ConfigUsersRoutes(db)
-	postsRoutes := router.ConfigPostsRoutes(db)
+	usersRoutes := router.ConfigUsersRoutes(db, hub)
+	postsRoutes := router.ConfigPostsRoutes(db, hub)
 	loginRoute := router.ConfigLoginRoutes(db)
+	mediaRoutes := router.ConfigMediaRoutes(db, blobStore)
+	tagsRoutes := router.ConfigTagsRoutes(db, hub)
+	trendsRoutes := router.ConfigTrendsRoutes(trendsCache)
+	notificationsRoutes := router.ConfigNotificationsRoutes(db)
+	streamRoutes := router.ConfigStreamRoutes(db, hub)
+	messagesRoutes := router.ConfigMessagesRoutes(db, hub)
+	exportsRoutes := router.ConfigExportsRoutes(db, blobStore)
+	bookmarksRoutes := router.ConfigBookmarksRoutes(db)
 
 	routes = append(routes, usersRoutes...)
 	routes = append(routes, postsRoutes...)
+	routes = append(routes, mediaRoutes...)
+	routes = append(routes, tagsRoutes...)
+	routes = append(routes, trendsRoutes...)
+	routes = append(routes, notificationsRoutes...)
+	routes = append(routes, streamRoutes...)
+	routes = append(routes, messagesRoutes...)
+	routes = append(routes, exportsRoutes...)
+	routes = append(routes, bookmarksRoutes...)
 	routes = append(routes, loginRoute)
 
 	for _, route := range routes {
 		if route.RequiresAuth {
 			r.HandleFunc(route.URI,
 				middlewares.Logger(
-					middlewares.Authenticate(route.Controller),
+					middlewares.Authenticate(usersRepository, route.Controller),
 				),
 			).Methods(route.Method)
 		} else {
@@ -41,15 +67,76 @@ func configurateRoutes(r *mux.Router, db *mongo.Database) *mux.Router {
 
 func main() {
 	config.Load()
+	entities.AddReservedNicks(config.ReservedNicks)
 
 	mongo, err := database.Connect()
 	if err != nil {
 		panic(fmt.Errorf("Could not connect on mongoDB: %s", err))
 	}
 
+	blobStore, err := storage.New()
+	if err != nil {
+		panic(fmt.Errorf("Could not configure the media storage: %s", err))
+	}
+
+	trendsCache := trends.NewCache()
+	trends.Start(repositories.NewTrendsRepository(mongo), trendsCache, config.TrendsRefreshInterval)
+
+	hub := stream.NewHub(config.StreamBufferSize, config.StreamHistorySize)
+
+	postsRepository := repositories.NewPostsRepository(mongo)
+	if migrated, err := postsRepository.MigrateLegacyLikes(); err != nil {
+		log.Printf("could not migrate the legacy likes: %s", err)
+	} else if migrated > 0 {
+		log.Printf("migrated the legacy likes of %d posts", migrated)
+	}
+	usersRepository := repositories.NewUsersRepository(mongo)
+	if migrated, err := usersRepository.MigrateNickKeys(); err != nil {
+		log.Printf("could not migrate the nick keys: %s", err)
+	} else if migrated > 0 {
+		log.Printf("set the nick key of %d users", migrated)
+	}
+	locksRepository := repositories.NewLocksRepository(mongo)
+	mediaRepository := repositories.NewMediaRepository(mongo)
+	exportsRepository := repositories.NewExportsRepository(mongo)
+	bookmarksRepository := repositories.NewBookmarksRepository(mongo)
+	notificationsRepository := repositories.NewNotificationsRepository(mongo)
+	notifier := notifications.NewNotifier(notificationsRepository, usersRepository, hub)
+	scheduling.NewScheduler(
+		postsRepository,
+		usersRepository,
+		locksRepository,
+		notifier,
+		hub,
+		config.SchedulerLease,
+	).Start(config.SchedulerInterval)
+
+	accountDeleter := trash.NewAccountDeleter(
+		repositories.NewAccountDeletionsRepository(mongo),
+		postsRepository,
+		usersRepository,
+		bookmarksRepository,
+		notificationsRepository,
+		repositories.NewMessagesRepository(mongo),
+		mediaRepository,
+		exportsRepository,
+		blobStore,
+	)
+	trash.NewPurger(postsRepository, usersRepository, locksRepository, accountDeleter, config.TrashRetention).Start(config.TrashPurgeInterval)
+
+	exports.NewExporter(
+		exportsRepository,
+		usersRepository,
+		postsRepository,
+		bookmarksRepository,
+		mediaRepository,
+		blobStore,
+		config.ExportExpiration,
+	).Start(config.ExportInterval)
+
 	r := mux.NewRouter()
 
-	configRoutes := configurateRoutes(r, mongo)
+	configRoutes := configurateRoutes(r, mongo, usersRepository, blobStore, trendsCache, hub)
 
 	var PORT = fmt.Sprintf(":%v", config.Port)
 
@@ -0,0 +1,352 @@
+package exports
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"time"
+)
+
+// pageSize is how many records are read at a time while gathering the data of an export
+const pageSize = 100
+
+// extensions names the files of the exported media after their content type
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ExportedPost is a post along with the versions it had before being edited
+type ExportedPost struct {
+	entities.Post
+	Revisions []entities.PostRevision `json:"revisions,omitempty"`
+}
+
+// ExportedMedia is an uploaded media along with the file it's saved at on the archive. Missing tells the file
+// was no longer on the storage, so the archive only has its metadata
+type ExportedMedia struct {
+	entities.Media
+	File    string `json:"file,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// archive is the data of a user gathered for an export
+type archive struct {
+	Profile     entities.User
+	Posts       []ExportedPost
+	Followers   []string
+	Following   []string
+	Blocked     []string
+	Muted       []string
+	Likes       []entities.PostLike
+	Reactions   []entities.PostReaction
+	Votes       []entities.PollVote
+	Bookmarks   []entities.Bookmark
+	Collections []entities.BookmarkCollection
+	Media       []ExportedMedia
+	ExportedAt  time.Time
+	// Files are the JSON documents on the archive
+	Files []string
+}
+
+// Sources are the repositories the data of an export is read from
+type Sources struct {
+	Users     repositories.UsersRepository
+	Posts     repositories.PostsRepository
+	Bookmarks repositories.BookmarksRepository
+	Media     repositories.MediaRepository
+	Blobs     repositories.BlobStore
+}
+
+// Build writes everything the user has on the API as a ZIP archive to output, with a JSON file for each kind of
+// data, the uploaded files and an HTML page to browse them. The uploaded files are written as they're read, so
+// only one of them is held in memory at a time
+func Build(sources Sources, nick string, output io.Writer) error {
+	user, err := sources.Users.GetUserByNick(nick)
+	if err != nil {
+		return err
+	}
+	user.Password = ""
+
+	data := archive{
+		Profile:    user,
+		Posts:      []ExportedPost{},
+		Followers:  orEmpty(user.Followers),
+		Following:  orEmpty(user.Following),
+		Blocked:    orEmpty(user.Blocked),
+		Muted:      orEmpty(user.Muted),
+		Likes:      []entities.PostLike{},
+		Reactions:  []entities.PostReaction{},
+		Votes:      []entities.PollVote{},
+		Bookmarks:  []entities.Bookmark{},
+		Media:      []ExportedMedia{},
+		ExportedAt: time.Now(),
+	}
+
+	if err = data.gatherPosts(sources.Posts, nick); err != nil {
+		return err
+	}
+	if err = data.gatherLikes(sources.Posts, nick); err != nil {
+		return err
+	}
+	if err = data.gatherReactions(sources.Posts, nick); err != nil {
+		return err
+	}
+	if err = data.gatherVotes(sources.Posts, nick); err != nil {
+		return err
+	}
+	if err = data.gatherBookmarks(sources.Bookmarks, nick); err != nil {
+		return err
+	}
+
+	writer := zip.NewWriter(output)
+
+	if err = data.writeMedia(writer, sources.Media, sources.Blobs, nick); err != nil {
+		return err
+	}
+	if err = data.writeDocuments(writer); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func (data *archive) gatherPosts(postRepository repositories.PostsRepository, nick string) error {
+	for page := 1; ; page++ {
+		posts, err := postRepository.GetAuthorPosts(nick, page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			exported := ExportedPost{Post: post}
+
+			for revisionsPage := 1; ; revisionsPage++ {
+				revisions, err := postRepository.GetRevisions(post.ID, revisionsPage, pageSize)
+				if err != nil {
+					return err
+				}
+
+				exported.Revisions = append(exported.Revisions, revisions...)
+				if len(revisions) < pageSize {
+					break
+				}
+			}
+
+			data.Posts = append(data.Posts, exported)
+		}
+
+		if len(posts) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (data *archive) gatherLikes(postRepository repositories.PostsRepository, nick string) error {
+	for page := 1; ; page++ {
+		likes, err := postRepository.GetLikesBy(nick, page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		data.Likes = append(data.Likes, likes...)
+		if len(likes) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (data *archive) gatherReactions(postRepository repositories.PostsRepository, nick string) error {
+	for page := 1; ; page++ {
+		reactions, err := postRepository.GetReactionsBy(nick, page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		data.Reactions = append(data.Reactions, reactions...)
+		if len(reactions) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (data *archive) gatherVotes(postRepository repositories.PostsRepository, nick string) error {
+	for page := 1; ; page++ {
+		votes, err := postRepository.GetVotesBy(nick, page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		data.Votes = append(data.Votes, votes...)
+		if len(votes) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (data *archive) gatherBookmarks(bookmarkRepository repositories.BookmarksRepository, nick string) error {
+	collections, err := bookmarkRepository.GetCollections(nick)
+	if err != nil {
+		return err
+	}
+	data.Collections = collections
+
+	for page := 1; ; page++ {
+		bookmarks, err := bookmarkRepository.GetBookmarks(nick, "", page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		data.Bookmarks = append(data.Bookmarks, bookmarks...)
+		if len(bookmarks) < pageSize {
+			return nil
+		}
+	}
+}
+
+// writeMedia adds the original of every uploaded file, the thumbnails can be made again from it. A file no
+// longer on the storage is listed as missing instead of failing the whole export
+func (data *archive) writeMedia(writer *zip.Writer, mediaRepository repositories.MediaRepository, blobStore repositories.BlobStore, nick string) error {
+	for page := 1; ; page++ {
+		media, err := mediaRepository.GetMediaByOwner(nick, page, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range media {
+			content, err := blobStore.Get(item.Key)
+			if errors.Is(err, repositories.ErrBlobNotFound) {
+				data.Media = append(data.Media, ExportedMedia{Media: item, Missing: true})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			name := "media/" + item.ID + extensions[item.ContentType]
+			if err = data.writeFile(writer, name, content); err != nil {
+				return err
+			}
+			data.Media = append(data.Media, ExportedMedia{Media: item, File: name})
+		}
+
+		if len(media) < pageSize {
+			return nil
+		}
+	}
+}
+
+// writeDocuments adds the JSON files and the HTML page listing them
+func (data *archive) writeDocuments(writer *zip.Writer) error {
+	documents := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"posts.json", data.Posts},
+		{"followers.json", data.Followers},
+		{"following.json", data.Following},
+		{"blocked.json", data.Blocked},
+		{"muted.json", data.Muted},
+		{"likes.json", data.Likes},
+		{"reactions.json", data.Reactions},
+		{"poll-votes.json", data.Votes},
+		{"bookmarks.json", data.Bookmarks},
+		{"bookmark-collections.json", data.Collections},
+		{"media.json", data.Media},
+	}
+
+	for _, document := range documents {
+		content, err := json.MarshalIndent(document.content, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err = data.writeFile(writer, document.name, content); err != nil {
+			return err
+		}
+		data.Files = append(data.Files, document.name)
+	}
+
+	index, err := data.createFile(writer, "index.html")
+	if err != nil {
+		return err
+	}
+
+	return indexTemplate.Execute(index, data)
+}
+
+func (data *archive) writeFile(writer *zip.Writer, name string, content []byte) error {
+	file, err := data.createFile(writer, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+	return err
+}
+
+func (data *archive) createFile(writer *zip.Writer, name string) (io.Writer, error) {
+	return writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: data.ExportedAt})
+}
+
+// orEmpty keeps missing lists as empty arrays on the JSON files
+func orEmpty(nicks []string) []string {
+	if nicks == nil {
+		return []string{}
+	}
+
+	return nicks
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Data of @{{.Profile.Nick}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+article { border-top: 1px solid #ddd; padding: 0.5rem 0; }
+small { color: #666; }
+img { max-width: 10rem; margin: 0.25rem; }
+</style>
+</head>
+<body>
+<h1>{{.Profile.Name}} (@{{.Profile.Nick}})</h1>
+<p><small>Exported on {{.ExportedAt.Format "2006-01-02 15:04 MST"}}</small></p>
+{{if .Profile.Bio}}<p>{{.Profile.Bio}}</p>{{end}}
+<ul>
+<li>{{len .Posts}} posts</li>
+<li>{{len .Followers}} followers, {{len .Following}} following</li>
+<li>{{len .Likes}} likes</li>
+<li>{{len .Reactions}} reactions</li>
+<li>{{len .Votes}} poll votes</li>
+<li>{{len .Bookmarks}} bookmarks in {{len .Collections}} collections</li>
+<li>{{len .Media}} uploaded files</li>
+</ul>
+
+<h2>Files</h2>
+<ul>
+{{range .Files}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul>
+
+<h2>Posts</h2>
+{{range .Posts}}<article>
+{{if .Title}}<h3>{{.Title}}</h3>{{end}}
+<p>{{.Content}}</p>
+<small>{{.CreatedAt.Format "2006-01-02 15:04"}}{{if .Status}} &middot; {{.Status}}{{end}}{{if .DeletedAt}} &middot; deleted{{end}}{{if .Revisions}} &middot; {{len .Revisions}} earlier versions{{end}}</small>
+</article>
+{{else}}<p>No posts.</p>
+{{end}}
+<h2>Media</h2>
+{{range .Media}}{{if .Missing}}<p><small>{{.ID}} is no longer available</small></p>{{else}}<a href="{{.File}}"><img src="{{.File}}" alt="{{.ID}}"></a>{{end}}
+{{else}}<p>No uploaded files.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,129 @@
+package exports
+
+import (
+	"api/internal/domain/repositories"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// lease is how long an instance holds an export it's building, before another one can take it over
+	lease = 10 * time.Minute
+	// expireBatchSize is how many expired archives are removed at a time
+	expireBatchSize = 100
+)
+
+// Exporter builds the requested data exports in the background and removes their archives once they expire.
+// Every export is claimed with a lease, so the API instances share the work without building one twice
+type Exporter struct {
+	exportRepository repositories.ExportsRepository
+	sources          Sources
+	expiration       time.Duration
+	owner            string
+}
+
+func NewExporter(exportRepository repositories.ExportsRepository, userRepository repositories.UsersRepository, postRepository repositories.PostsRepository, bookmarkRepository repositories.BookmarksRepository, mediaRepository repositories.MediaRepository, blobStore repositories.BlobStore, expiration time.Duration) *Exporter {
+	hostname, _ := os.Hostname()
+
+	return &Exporter{
+		exportRepository,
+		Sources{userRepository, postRepository, bookmarkRepository, mediaRepository, blobStore},
+		expiration,
+		fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+	}
+}
+
+// Run builds the pending exports and removes the archives expired by now
+func (exporter *Exporter) Run(now time.Time) error {
+	for {
+		export, found, err := exporter.exportRepository.ClaimExport(exporter.owner, lease)
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+
+		if err = exporter.export(export.ID, export.Nick); err != nil {
+			log.Printf("could not export the data of %s: %s", export.Nick, err)
+		}
+	}
+
+	for {
+		expired, err := exporter.exportRepository.GetExpiredExports(now, expireBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, export := range expired {
+			if err = exporter.sources.Blobs.Delete(export.Key); err != nil {
+				return err
+			}
+			if err = exporter.exportRepository.ExpireExport(export.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(expired) < expireBatchSize {
+			return nil
+		}
+	}
+}
+
+// export builds and stores the archive of a claimed export. A failure is recorded on the export, so the user
+// sees it when checking on it
+func (exporter *Exporter) export(exportID string, nick string) error {
+	// the archive is built on a temporary file, as it may not fit in memory
+	file, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		return exporter.fail(exportID, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err = Build(exporter.sources, nick, file); err != nil {
+		return exporter.fail(exportID, err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return exporter.fail(exportID, err)
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return exporter.fail(exportID, err)
+	}
+
+	key := "exports/" + exportID + ".zip"
+	if err = exporter.sources.Blobs.PutStream(key, "application/zip", file, size); err != nil {
+		return exporter.fail(exportID, err)
+	}
+
+	// when the lease passed and another instance took the export over, the archive it stores replaces this one
+	return exporter.exportRepository.CompleteExport(exportID, exporter.owner, key, int(size), time.Now().Add(exporter.expiration))
+}
+
+func (exporter *Exporter) fail(exportID string, err error) error {
+	if failErr := exporter.exportRepository.FailExport(exportID, exporter.owner, err.Error()); failErr != nil {
+		return failErr
+	}
+
+	return err
+}
+
+// Start runs the exporter on every interval
+func (exporter *Exporter) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := exporter.Run(time.Now()); err != nil {
+				log.Printf("could not run the data exports: %s", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,344 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const maxPixels = 40000000
+
+var ErrUnsupportedType = errors.New("Only JPEG, PNG and GIF images are supported")
+
+type ThumbnailSize struct {
+	Name    string
+	MaxSide int
+}
+
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxSide: 150},
+	{Name: "medium", MaxSide: 600},
+}
+
+type Image struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+}
+
+type ProcessedImage struct {
+	Original   Image
+	Thumbnails map[string]Image
+}
+
+// Process checks the real content type of the upload, re-encodes it so any
+// metadata (EXIF, GPS, comments) is dropped and generates the thumbnails
+func Process(data []byte) (ProcessedImage, error) {
+	contentType := http.DetectContentType(data)
+
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return ProcessedImage{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedImage{}, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return ProcessedImage{}, errors.New("The image dimensions are too large")
+	}
+	// every frame of an animation is decoded and kept in memory, so the limit counts all of them
+	if contentType == "image/gif" && gifFrames(data)*config.Width*config.Height > maxPixels {
+		return ProcessedImage{}, errors.New("The animation has too many frames")
+	}
+
+	var original Image
+	var decoded image.Image
+
+	switch contentType {
+	case "image/jpeg":
+		decoded, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return ProcessedImage{}, err
+		}
+		// the orientation lives on the EXIF block we are about to drop, so it is applied to the pixels
+		decoded = orient(decoded, jpegOrientation(data))
+		original, err = encode(decoded, contentType)
+	case "image/png":
+		decoded, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return ProcessedImage{}, err
+		}
+		original, err = encode(decoded, contentType)
+	case "image/gif":
+		var animation *gif.GIF
+		animation, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return ProcessedImage{}, err
+		}
+		// a frame may only cover part of the canvas, the thumbnails are made from the whole canvas
+		first := animation.Image[0]
+		canvas := image.NewRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+		draw.Draw(canvas, first.Bounds(), first, first.Bounds().Min, draw.Src)
+		decoded = canvas
+		original, err = encodeGIF(animation)
+	}
+	if err != nil {
+		return ProcessedImage{}, err
+	}
+
+	thumbnailType := "image/png"
+	if contentType == "image/jpeg" {
+		thumbnailType = contentType
+	}
+
+	thumbnails := map[string]Image{}
+	for _, size := range ThumbnailSizes {
+		thumbnail, err := encode(resize(decoded, size.MaxSide), thumbnailType)
+		if err != nil {
+			return ProcessedImage{}, err
+		}
+		thumbnails[size.Name] = thumbnail
+	}
+
+	return ProcessedImage{Original: original, Thumbnails: thumbnails}, nil
+}
+
+func encode(img image.Image, contentType string) (Image, error) {
+	var buffer bytes.Buffer
+	var err error
+
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType: contentType,
+		Data:        buffer.Bytes(),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}, nil
+}
+
+func encodeGIF(animation *gif.GIF) (Image, error) {
+	var buffer bytes.Buffer
+	if err := gif.EncodeAll(&buffer, animation); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType: "image/gif",
+		Data:        buffer.Bytes(),
+		Width:       animation.Config.Width,
+		Height:      animation.Config.Height,
+	}, nil
+}
+
+// gifFrames counts the image descriptors of a GIF walking its blocks without decoding them, a malformed
+// file stops the count and is rejected by the decoder later
+func gifFrames(data []byte) int {
+	if len(data) < 13 {
+		return 0
+	}
+
+	offset := 13
+	if data[10]&0x80 != 0 {
+		offset += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames := 0
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x21:
+			offset += 2
+		case 0x2C:
+			if offset+10 > len(data) {
+				return frames
+			}
+			frames++
+			packed := data[offset+9]
+			offset += 10
+			if packed&0x80 != 0 {
+				offset += 3 << (packed&0x07 + 1)
+			}
+			// LZW minimum code size
+			offset++
+		default:
+			return frames
+		}
+
+		// data sub-blocks, each one prefixed by its size and ended by an empty one
+		for offset < len(data) && data[offset] != 0 {
+			offset += int(data[offset]) + 1
+		}
+		offset++
+	}
+
+	return frames
+}
+
+// resize scales the image down to fit on maxSide averaging the source pixels
+func resize(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	newWidth, newHeight := width, height
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			newWidth, newHeight = maxSide, height*maxSide/width
+		} else {
+			newWidth, newHeight = width*maxSide/height, maxSide
+		}
+	}
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		startY, endY := y*height/newHeight, (y+1)*height/newHeight
+		if endY == startY {
+			endY++
+		}
+
+		for x := 0; x < newWidth; x++ {
+			startX, endX := x*width/newWidth, (x+1)*width/newWidth
+			if endX == startX {
+				endX++
+			}
+
+			var r, g, b, a, count uint64
+			for sy := startY; sy < endY; sy++ {
+				for sx := startX; sx < endX; sx++ {
+					pr, pg, pb, pa := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
+
+// orient applies an EXIF orientation (1 to 8) to the pixels
+func orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = width-1-x, y
+			case 3:
+				sx, sy = width-1-x, height-1-y
+			case 4:
+				sx, sy = x, height-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, height-1-x
+			case 7:
+				sx, sy = width-1-y, height-1-x
+			case 8:
+				sx, sy = width-1-y, x
+			}
+			dst.Set(x, y, src.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation reads the orientation tag from the EXIF segment, returns 1 when there is none
+func jpegOrientation(data []byte) int {
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[offset+4 : offset+2+length]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+
+		offset += 2 + length
+	}
+
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifdOffset := int(order.Uint32(tiff[4:8]))
+	if ifdOffset+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifdOffset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return 1
+}
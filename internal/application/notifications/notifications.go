@@ -0,0 +1,158 @@
+package notifications
+
+import (
+	"api/internal/application/stream"
+	"api/internal/application/visibility"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Notifier creates the notifications, respecting the preferences, blocks and mutes of the recipient
+type Notifier struct {
+	notificationRepository repositories.NotificationsRepository
+	userRepository         repositories.UsersRepository
+	hub                    *stream.Hub
+}
+
+func NewNotifier(notificationRepository repositories.NotificationsRepository, userRepository repositories.UsersRepository, hub *stream.Hub) *Notifier {
+	return &Notifier{
+		notificationRepository,
+		userRepository,
+		hub,
+	}
+}
+
+// Notify lets the recipient know the actor did something, postID is the post the notifications are grouped by.
+// Failing to notify doesn't fail the action that caused it, so errors are only logged
+func (notifier *Notifier) Notify(recipientNick string, notificationType string, actorNick string, postID string) {
+	if recipientNick == "" || recipientNick == actorNick {
+		return
+	}
+
+	recipient, err := notifier.userRepository.GetUserByNick(recipientNick)
+	if err != nil {
+		log.Printf("could not find %s to notify about a %s: %s", recipientNick, notificationType, err)
+		return
+	}
+
+	notifier.notify(recipient, notificationType, actorNick, postID)
+}
+
+// notifyReader notifies the recipient only when they can read the post that caused the notification
+func (notifier *Notifier) notifyReader(recipientNick string, notificationType string, authorNick string, post entities.Post, postID string) {
+	if recipientNick == "" || recipientNick == authorNick {
+		return
+	}
+
+	recipient, err := notifier.userRepository.GetUserByNick(recipientNick)
+	if err != nil {
+		log.Printf("could not find %s to notify about a %s: %s", recipientNick, notificationType, err)
+		return
+	}
+
+	if !visibility.CanRead(recipient, post) {
+		return
+	}
+
+	notifier.notify(recipient, notificationType, authorNick, postID)
+}
+
+func (notifier *Notifier) notify(recipient entities.User, notificationType string, actorNick string, postID string) {
+	if !Preferences(recipient)[notificationType] || visibility.Silenced(recipient, actorNick) {
+		return
+	}
+
+	notification := entities.Notification{
+		Recipient: recipient.Nick,
+		Type:      notificationType,
+		Actor:     actorNick,
+		PostID:    postID,
+	}
+
+	if err := notifier.notificationRepository.AddNotification(notification); err != nil {
+		log.Printf("could not notify %s about a %s from %s: %s", recipient.Nick, notificationType, actorNick, err)
+		return
+	}
+
+	// the connected clients get the single event, the grouped notification is seen on the next listing
+	notification.Actors = []string{actorNick}
+	notification.ActorCount = 1
+	notification.CreatedAt = time.Now()
+	notification.UpdatedAt = notification.CreatedAt
+	notification.Summary = Summarize(notification)
+	notifier.hub.PublishTo(recipient.Nick, stream.EventNotification, notification)
+}
+
+// NotifyPublished lets the authors of the replied and the quoted posts and the mentioned users know about a
+// post that was just published. parent and quoted are empty when the post doesn't reply or quote
+func (notifier *Notifier) NotifyPublished(authorNick string, post entities.Post, parent entities.Post, quoted entities.Post, users map[string]entities.User) {
+	if parent.ID != "" {
+		notifier.notifyReader(parent.AuthorNick, entities.NotificationReply, authorNick, post, parent.ID)
+	}
+	if quoted.ID != "" {
+		notifier.notifyReader(quoted.AuthorNick, entities.NotificationQuote, authorNick, post, quoted.ID)
+	}
+	notifier.NotifyMentions(authorNick, post, users, nil)
+}
+
+// NotifyMentions lets the mentioned users know about the post, the ones already mentioned before an update
+// and the ones that can't read the post aren't notified
+func (notifier *Notifier) NotifyMentions(authorNick string, post entities.Post, users map[string]entities.User, previous []entities.Mention) {
+	notified := map[string]bool{authorNick: true}
+	for _, mention := range previous {
+		notified[mention.Nick] = true
+	}
+
+	for _, user := range users {
+		if notified[user.Nick] || !visibility.CanRead(user, post) {
+			continue
+		}
+		notified[user.Nick] = true
+
+		notifier.Notify(user.Nick, entities.NotificationMention, authorNick, post.ID)
+	}
+}
+
+// Preferences tells which kinds of notification the user receives, all of them unless turned off
+func Preferences(user entities.User) entities.NotificationPreferences {
+	preferences := entities.NotificationPreferences{}
+	for _, notificationType := range entities.NotificationTypes {
+		preferences[notificationType] = true
+	}
+
+	for _, notificationType := range user.DisabledNotifications {
+		if _, found := preferences[notificationType]; found {
+			preferences[notificationType] = false
+		}
+	}
+
+	return preferences
+}
+
+var summaries = map[string]string{
+	entities.NotificationFollow:        "followed you",
+	entities.NotificationFollowRequest: "asked to follow you",
+	entities.NotificationLike:          "liked your post",
+	entities.NotificationReaction:      "reacted to your post",
+	entities.NotificationReply:         "replied to your post",
+	entities.NotificationMention:       "mentioned you",
+	entities.NotificationRepost:        "reposted your post",
+	entities.NotificationQuote:         "quoted your post",
+}
+
+// Summarize describes the grouped notification, like "alice and 12 others liked your post"
+func Summarize(notification entities.Notification) string {
+	action := summaries[notification.Type]
+
+	switch others := notification.ActorCount - 1; {
+	case others <= 0:
+		return fmt.Sprintf("%s %s", notification.Actor, action)
+	case others == 1:
+		return fmt.Sprintf("%s and 1 other %s", notification.Actor, action)
+	default:
+		return fmt.Sprintf("%s and %d others %s", notification.Actor, others, action)
+	}
+}
@@ -0,0 +1,104 @@
+package scheduling
+
+import (
+	"api/internal/application/notifications"
+	"api/internal/application/stream"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// lockName is the lease the instances compete for, only its holder looks for due posts
+	lockName = "scheduled-posts"
+	// batchSize is how many posts are published before the lease is extended
+	batchSize = 50
+)
+
+// Scheduler publishes the scheduled posts once their time comes. The lease keeps the instances from scanning
+// the schedule together, and each post is taken off it by a single update, so none is ever published twice
+type Scheduler struct {
+	postRepository repositories.PostsRepository
+	userRepository repositories.UsersRepository
+	lockRepository repositories.LocksRepository
+	notifier       *notifications.Notifier
+	hub            *stream.Hub
+	owner          string
+	lease          time.Duration
+}
+
+func NewScheduler(postRepository repositories.PostsRepository, userRepository repositories.UsersRepository, lockRepository repositories.LocksRepository, notifier *notifications.Notifier, hub *stream.Hub, lease time.Duration) *Scheduler {
+	hostname, _ := os.Hostname()
+
+	return &Scheduler{
+		postRepository,
+		userRepository,
+		lockRepository,
+		notifier,
+		hub,
+		fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		lease,
+	}
+}
+
+// PublishDue publishes the posts scheduled up to now, when this instance gets the lease
+func (scheduler *Scheduler) PublishDue(now time.Time) error {
+	for {
+		acquired, err := scheduler.lockRepository.Acquire(lockName, scheduler.owner, scheduler.lease)
+		if err != nil || !acquired {
+			return err
+		}
+
+		posts, err := scheduler.postRepository.PublishDuePosts(now, batchSize)
+		for _, post := range posts {
+			scheduler.announce(post)
+		}
+		if err != nil || len(posts) < batchSize {
+			if releaseErr := scheduler.lockRepository.Release(lockName, scheduler.owner); err == nil {
+				err = releaseErr
+			}
+			return err
+		}
+	}
+}
+
+// announce streams the post and notifies the authors of the posts it replies and quotes and the mentioned
+// users, as if it was created now
+func (scheduler *Scheduler) announce(post entities.Post) {
+	scheduler.hub.PublishPost(post, stream.EventPost, post)
+
+	users := map[string]entities.User{}
+	for _, mention := range post.Mentions {
+		if user, err := scheduler.userRepository.GetUserByNick(mention.Nick); err == nil {
+			users[user.Nick] = user
+		}
+	}
+
+	// the posts no longer found are left empty, so their authors aren't notified
+	var parent, quoted entities.Post
+	if post.ParentID != "" {
+		parent, _ = scheduler.postRepository.GetPostWithId(post.ParentID)
+	}
+	if post.QuoteOf != "" {
+		quoted, _ = scheduler.postRepository.GetPostWithId(post.QuoteOf)
+	}
+	scheduler.notifier.NotifyPublished(post.AuthorNick, post, parent, quoted, users)
+}
+
+// Start looks for due posts on every interval
+func (scheduler *Scheduler) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := scheduler.PublishDue(time.Now()); err != nil {
+				log.Printf("could not publish the scheduled posts: %s", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
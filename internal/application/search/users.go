@@ -0,0 +1,143 @@
+package search
+
+import (
+	"api/internal/domain/entities"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// RankUsers scores the candidates against the query and returns the ones that match, best first.
+// Nick matches weigh more than name matches, and accounts followed by the viewer or with many
+// followers are boosted
+func RankUsers(query string, candidates []entities.UserSummary) []entities.UserSummary {
+	queryKey := entities.NickKey(query)
+	if queryKey == "" {
+		return []entities.UserSummary{}
+	}
+
+	type scoredUser struct {
+		user  entities.UserSummary
+		score float64
+	}
+
+	scored := []scoredUser{}
+	for _, candidate := range candidates {
+		score := matchScore(query, queryKey, candidate)
+		if score == 0 {
+			continue
+		}
+
+		if candidate.FollowedByViewer {
+			score += 25
+		}
+		score += 5 * math.Log10(1+float64(candidate.FollowersCount))
+
+		scored = append(scored, scoredUser{candidate, score})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].user.Nick < scored[j].user.Nick
+	})
+
+	ranked := make([]entities.UserSummary, 0, len(scored))
+	for _, item := range scored {
+		ranked = append(ranked, item.user)
+	}
+
+	return ranked
+}
+
+func matchScore(query string, queryKey string, candidate entities.UserSummary) float64 {
+	nickKey := candidate.NickKey
+	if nickKey == "" {
+		nickKey = entities.NickKey(candidate.Nick)
+	}
+
+	if nickKey == queryKey {
+		return 100
+	}
+
+	if strings.HasPrefix(nickKey, queryKey) {
+		return 60 + 20*float64(len(queryKey))/float64(len(nickKey))
+	}
+
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	for _, word := range strings.Fields(strings.ToLower(candidate.Name)) {
+		if strings.HasPrefix(word, lowerQuery) {
+			return 40
+		}
+	}
+
+	// typo tolerance, compares the query with the beginning of the nick
+	tolerance := typoTolerance(queryKey)
+	if tolerance == 0 {
+		return 0
+	}
+
+	nickPrefix := nickKey
+	if queryLength := utf8.RuneCountInString(queryKey); utf8.RuneCountInString(nickKey) > queryLength {
+		nickPrefix = string([]rune(nickKey)[:queryLength])
+	}
+
+	distance := Distance(queryKey, nickPrefix)
+	if distance > tolerance {
+		return 0
+	}
+
+	return 30 - 10*float64(distance)
+}
+
+func typoTolerance(queryKey string) int {
+	switch length := utf8.RuneCountInString(queryKey); {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Distance is the optimal string alignment distance: insertions, deletions,
+// substitutions and transpositions of adjacent characters cost 1
+func Distance(first string, second string) int {
+	a, b := []rune(first), []rune(second)
+
+	matrix := make([][]int, len(a)+1)
+	for i := range matrix {
+		matrix[i] = make([]int, len(b)+1)
+		matrix[i][0] = i
+	}
+	for j := range matrix[0] {
+		matrix[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			matrix[i][j] = minInt(matrix[i-1][j]+1, minInt(matrix[i][j-1]+1, matrix[i-1][j-1]+cost))
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				matrix[i][j] = minInt(matrix[i][j], matrix[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return matrix[len(a)][len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
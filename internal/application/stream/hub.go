@@ -0,0 +1,216 @@
+package stream
+
+import (
+	"api/internal/domain/entities"
+	"sync"
+	"time"
+)
+
+// kinds of event sent to the clients
+const (
+	EventPost         = "post"
+	EventLikes        = "likes"
+	EventReactions    = "reactions"
+	EventNotification = "notification"
+	EventMessage      = "message"
+	// EventMessagesRead is the read receipt of a conversation
+	EventMessagesRead = "messages_read"
+	// EventReset tells the client some events were lost, so it has to reload instead of resuming
+	EventReset = "reset"
+)
+
+// Event is a change pushed to the connected users. It goes either to a single recipient or to the author and
+// everyone following them
+type Event struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	recipient string
+	author    string
+}
+
+// LikesChange is the data of a likes event
+type LikesChange struct {
+	PostID string `json:"postId"`
+	Likes  int    `json:"likes"`
+}
+
+// ReactionsChange is the data of a reactions event
+type ReactionsChange struct {
+	PostID    string         `json:"postId"`
+	Reactions map[string]int `json:"reactions"`
+}
+
+// Subscriber is a connection waiting for events. When the connection can't keep up and its buffer fills,
+// the hub drops it by closing Events, and the client resumes from the last event it got
+type Subscriber struct {
+	Events    chan Event
+	nick      string
+	following map[string]bool
+	muted     map[string]bool
+}
+
+func (subscriber *Subscriber) wants(event Event) bool {
+	if event.recipient != "" {
+		return event.recipient == subscriber.nick
+	}
+
+	if event.author == subscriber.nick {
+		return true
+	}
+
+	return subscriber.following[event.author] && !subscriber.muted[event.author]
+}
+
+// Hub is the in-process pub/sub of the events, keeping the latest ones so clients can resume after reconnecting
+type Hub struct {
+	mutex       sync.Mutex
+	lastID      uint64
+	history     []Event
+	historySize int
+	bufferSize  int
+	subscribers map[*Subscriber]bool
+}
+
+func NewHub(bufferSize int, historySize int) *Hub {
+	return &Hub{
+		// ids start from the clock, so they keep growing after a restart and old ids are seen as too old
+		lastID:      uint64(time.Now().UnixMilli()) * 1000,
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: map[*Subscriber]bool{},
+	}
+}
+
+// PublishTo sends the event only to the recipient
+func (hub *Hub) PublishTo(recipient string, eventType string, data interface{}) {
+	hub.publish(Event{Type: eventType, Data: data, recipient: recipient})
+}
+
+// PublishFrom sends the event to the author and their followers
+func (hub *Hub) PublishFrom(author string, eventType string, data interface{}) {
+	hub.publish(Event{Type: eventType, Data: data, author: author})
+}
+
+// PublishPost sends an event about the post to the ones that can read it. The followers get the posts readable
+// by anyone and the followers-only ones, while mentioned-only posts go to the mentioned users
+func (hub *Hub) PublishPost(post entities.Post, eventType string, data interface{}) {
+	if post.Visibility != entities.VisibilityMentioned {
+		hub.PublishFrom(post.AuthorNick, eventType, data)
+		return
+	}
+
+	hub.PublishTo(post.AuthorNick, eventType, data)
+	for _, mention := range post.Mentions {
+		if mention.Nick != post.AuthorNick {
+			hub.PublishTo(mention.Nick, eventType, data)
+		}
+	}
+}
+
+func (hub *Hub) publish(event Event) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.lastID++
+	event.ID = hub.lastID
+
+	hub.history = append(hub.history, event)
+	if len(hub.history) > hub.historySize {
+		hub.history = hub.history[len(hub.history)-hub.historySize:]
+	}
+
+	for subscriber := range hub.subscribers {
+		if !subscriber.wants(event) {
+			continue
+		}
+
+		select {
+		case subscriber.Events <- event:
+		default:
+			hub.drop(subscriber)
+		}
+	}
+}
+
+// Subscribe starts listening to the events of the user, returning the ones published after lastEventID that
+// are still kept. When some of them were already discarded the returned events start with a reset event
+func (hub *Hub) Subscribe(nick string, following []string, muted []string, lastEventID uint64) (*Subscriber, []Event) {
+	subscriber := &Subscriber{
+		Events:    make(chan Event, hub.bufferSize),
+		nick:      nick,
+		following: toSet(following),
+		muted:     toSet(muted),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	missed := []Event{}
+	if lastEventID > 0 && lastEventID < hub.lastID {
+		oldestKept := hub.lastID + 1
+		if len(hub.history) > 0 {
+			oldestKept = hub.history[0].ID
+		}
+
+		if lastEventID+1 < oldestKept {
+			missed = append(missed, Event{ID: hub.lastID, Type: EventReset})
+		} else {
+			for _, event := range hub.history {
+				if event.ID > lastEventID && subscriber.wants(event) {
+					missed = append(missed, event)
+				}
+			}
+		}
+	}
+
+	hub.subscribers[subscriber] = true
+	return subscriber, missed
+}
+
+// SetFollowing changes whether the connections of the user get the events of the author, so the ones open
+// before an unfollow or a block stop getting what the user can't read anymore
+func (hub *Hub) SetFollowing(nick string, author string, following bool) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	for subscriber := range hub.subscribers {
+		if subscriber.nick == nick {
+			subscriber.following[author] = following
+		}
+	}
+}
+
+// SetMuted changes whether the connections of the user leave out the events of the author
+func (hub *Hub) SetMuted(nick string, author string, muted bool) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	for subscriber := range hub.subscribers {
+		if subscriber.nick == nick {
+			subscriber.muted[author] = muted
+		}
+	}
+}
+
+func (hub *Hub) Unsubscribe(subscriber *Subscriber) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.drop(subscriber)
+}
+
+func (hub *Hub) drop(subscriber *Subscriber) {
+	if hub.subscribers[subscriber] {
+		delete(hub.subscribers, subscriber)
+		close(subscriber.Events)
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
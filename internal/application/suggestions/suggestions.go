@@ -0,0 +1,101 @@
+package suggestions
+
+import (
+	"api/internal/domain/entities"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rank orders the candidates by mutual connections, shared tech stack tags and recent activity
+func Rank(techStack []string, candidates []entities.UserSummary, now time.Time) []entities.UserSummary {
+	tags := map[string]bool{}
+	for _, tag := range techStack {
+		tags[tag] = true
+	}
+
+	scores := map[string]float64{}
+	for _, candidate := range candidates {
+		sharedTags := 0
+		for _, tag := range candidate.TechStack {
+			if tags[tag] {
+				sharedTags++
+			}
+		}
+
+		score := 10*float64(candidate.MutualCount) + 5*float64(sharedTags)
+
+		if !candidate.LastPostAt.IsZero() {
+			switch sinceLastPost := now.Sub(candidate.LastPostAt); {
+			case sinceLastPost <= 7*24*time.Hour:
+				score += 8
+			case sinceLastPost <= 30*24*time.Hour:
+				score += 4
+			}
+		}
+
+		score += 2 * math.Log10(1+float64(candidate.FollowersCount))
+		scores[candidate.Nick] = score
+	}
+
+	ranked := append([]entities.UserSummary{}, candidates...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if scores[ranked[i].Nick] != scores[ranked[j].Nick] {
+			return scores[ranked[i].Nick] > scores[ranked[j].Nick]
+		}
+		return ranked[i].Nick < ranked[j].Nick
+	})
+
+	return ranked
+}
+
+type cacheEntry struct {
+	suggestions []entities.UserSummary
+	expiresAt   time.Time
+}
+
+// Cache keeps the suggestions of each user until the refresh interval is over
+type Cache struct {
+	mutex           sync.Mutex
+	entries         map[string]cacheEntry
+	refreshInterval time.Duration
+}
+
+func NewCache(refreshInterval time.Duration) *Cache {
+	return &Cache{
+		entries:         map[string]cacheEntry{},
+		refreshInterval: refreshInterval,
+	}
+}
+
+func (cache *Cache) Get(userID string) ([]entities.UserSummary, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, found := cache.entries[userID]
+	if !found || time.Now().After(entry.expiresAt) {
+		delete(cache.entries, userID)
+		return nil, false
+	}
+
+	return entry.suggestions, true
+}
+
+func (cache *Cache) Set(userID string, suggestions []entities.UserSummary) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries[userID] = cacheEntry{
+		suggestions: suggestions,
+		expiresAt:   time.Now().Add(cache.refreshInterval),
+	}
+}
+
+// Invalidate drops the cached suggestions, used when the user follows, blocks or mutes someone
+func (cache *Cache) Invalidate(userID string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.entries, userID)
+}
@@ -0,0 +1,56 @@
+package threads
+
+import (
+	"api/internal/domain/entities"
+	"errors"
+)
+
+// maxAncestors stops the walk up the conversation when a parent is missing or the chain loops
+const maxAncestors = 1000
+
+// Build finds the post inside its conversation, returning the chain of posts it replies, starting from the
+// first one, and every reply below it as a tree
+func Build(postID string, conversation []entities.Post) (entities.Thread, error) {
+	postsByID := map[string]entities.Post{}
+	repliesByParent := map[string][]entities.Post{}
+	for _, post := range conversation {
+		postsByID[post.ID] = post
+		if post.ParentID != "" {
+			repliesByParent[post.ParentID] = append(repliesByParent[post.ParentID], post)
+		}
+	}
+
+	post, found := postsByID[postID]
+	if !found {
+		return entities.Thread{}, errors.New("This post isn't part of the conversation")
+	}
+
+	ancestors := []entities.Post{}
+	for parentID := post.ParentID; parentID != "" && len(ancestors) < maxAncestors; {
+		parent, found := postsByID[parentID]
+		if !found {
+			break
+		}
+		ancestors = append([]entities.Post{parent}, ancestors...)
+		parentID = parent.ParentID
+	}
+
+	return entities.Thread{
+		Ancestors: ancestors,
+		Post:      buildNode(post, repliesByParent, map[string]bool{}),
+	}, nil
+}
+
+func buildNode(post entities.Post, repliesByParent map[string][]entities.Post, visited map[string]bool) entities.ThreadNode {
+	visited[post.ID] = true
+
+	node := entities.ThreadNode{Post: post, Replies: []entities.ThreadNode{}}
+	for _, reply := range repliesByParent[post.ID] {
+		if visited[reply.ID] {
+			continue
+		}
+		node.Replies = append(node.Replies, buildNode(reply, repliesByParent, visited))
+	}
+
+	return node
+}
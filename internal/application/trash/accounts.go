@@ -0,0 +1,198 @@
+package trash
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"fmt"
+	"log"
+	"time"
+)
+
+// AccountDeleter removes for good the accounts whose trash retention passed, along with their posts, likes,
+// reactions, poll votes, bookmarks, follows, notifications, conversations, media, data exports and nick.
+// Every account goes through the steps of a durable deletion, so an interrupted one resumes from the step it
+// stopped at
+type AccountDeleter struct {
+	deletionRepository     repositories.AccountDeletionsRepository
+	postRepository         repositories.PostsRepository
+	userRepository         repositories.UsersRepository
+	bookmarkRepository     repositories.BookmarksRepository
+	notificationRepository repositories.NotificationsRepository
+	messageRepository      repositories.MessagesRepository
+	mediaRepository        repositories.MediaRepository
+	exportRepository       repositories.ExportsRepository
+	blobStore              repositories.BlobStore
+}
+
+func NewAccountDeleter(
+	deletionRepository repositories.AccountDeletionsRepository,
+	postRepository repositories.PostsRepository,
+	userRepository repositories.UsersRepository,
+	bookmarkRepository repositories.BookmarksRepository,
+	notificationRepository repositories.NotificationsRepository,
+	messageRepository repositories.MessagesRepository,
+	mediaRepository repositories.MediaRepository,
+	exportRepository repositories.ExportsRepository,
+	blobStore repositories.BlobStore,
+) *AccountDeleter {
+	return &AccountDeleter{
+		deletionRepository,
+		postRepository,
+		userRepository,
+		bookmarkRepository,
+		notificationRepository,
+		messageRepository,
+		mediaRepository,
+		exportRepository,
+		blobStore,
+	}
+}
+
+// Schedule starts the deletion of up to limit accounts deleted before the given time
+func (deleter *AccountDeleter) Schedule(before time.Time, limit int) (int, error) {
+	return deleter.deletionRepository.Schedule(before, limit)
+}
+
+// RunPending carries up to limit pending deletions on, it tells how many of them finished. A deletion that
+// fails is logged and tried again on the next run
+func (deleter *AccountDeleter) RunPending(limit int) (int, error) {
+	deletions, err := deleter.deletionRepository.GetPending(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, deletion := range deletions {
+		if err := deleter.Run(deletion); err != nil {
+			log.Printf("could not delete the account %s: %s", deletion.Nick, err)
+			continue
+		}
+		completed++
+	}
+
+	return completed, nil
+}
+
+// Run goes through the steps of the deletion, starting from the one it's at
+func (deleter *AccountDeleter) Run(deletion entities.AccountDeletion) error {
+	steps := entities.AccountDeletionSteps
+
+	start := 0
+	for i, step := range steps {
+		if step == deletion.Step {
+			start = i
+			break
+		}
+	}
+
+	for i := start; i < len(steps); i++ {
+		if err := deleter.runStep(deletion.Nick, steps[i]); err != nil {
+			if failErr := deleter.deletionRepository.Fail(deletion.Nick, err.Error()); failErr != nil {
+				log.Printf("could not record the failed deletion of %s: %s", deletion.Nick, failErr)
+			}
+			return fmt.Errorf("step %s: %s", steps[i], err)
+		}
+
+		if i+1 < len(steps) {
+			if err := deleter.deletionRepository.Advance(deletion.Nick, steps[i+1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return deleter.deletionRepository.Complete(deletion.Nick)
+}
+
+func (deleter *AccountDeleter) runStep(nick string, step string) error {
+	switch step {
+	case entities.DeletionStepPosts:
+		return drain(func() (int, error) { return deleter.postRepository.PurgeAuthorPosts(nick, batchSize) })
+	case entities.DeletionStepLikes:
+		return drain(func() (int, error) { return deleter.postRepository.RemoveLikesBy(nick, batchSize) })
+	case entities.DeletionStepReactions:
+		return drain(func() (int, error) { return deleter.postRepository.RemoveReactionsBy(nick, batchSize) })
+	case entities.DeletionStepVotes:
+		return drain(func() (int, error) { return deleter.postRepository.RemoveVotesBy(nick, batchSize) })
+	case entities.DeletionStepBookmarks:
+		return deleter.bookmarkRepository.DeleteUserBookmarks(nick)
+	case entities.DeletionStepFollows:
+		return deleter.userRepository.RemoveReferences(nick)
+	case entities.DeletionStepNotifications:
+		return deleter.notificationRepository.DeleteUserNotifications(nick)
+	case entities.DeletionStepConversations:
+		return deleter.messageRepository.RemoveParticipant(nick)
+	case entities.DeletionStepMedia:
+		return drain(func() (int, error) { return deleter.deleteMedia(nick) })
+	case entities.DeletionStepExports:
+		return deleter.deleteExports(nick)
+	case entities.DeletionStepSessions:
+		return deleter.userRepository.ReserveDeletedNick(nick)
+	case entities.DeletionStepAccount:
+		return deleter.userRepository.PurgeUser(nick)
+	}
+
+	return nil
+}
+
+// deleteMedia removes a batch of the media uploaded by the user. The files go before the metadata, so a
+// failure leaves the media to be found again
+func (deleter *AccountDeleter) deleteMedia(nick string) (int, error) {
+	media, err := deleter.mediaRepository.GetMediaByOwner(nick, 1, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range media {
+		keys := []string{item.Key}
+		for _, thumbnail := range item.Thumbnails {
+			keys = append(keys, thumbnail.Key)
+		}
+
+		for _, key := range keys {
+			if err := deleter.blobStore.Delete(key); err != nil {
+				return 0, err
+			}
+		}
+
+		if err := deleter.mediaRepository.DeleteMedia(item.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(media), nil
+}
+
+// deleteExports removes the data exports of the user along with their archives
+func (deleter *AccountDeleter) deleteExports(nick string) error {
+	exports, err := deleter.exportRepository.GetExports(nick)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.Key != "" {
+			if err := deleter.blobStore.Delete(export.Key); err != nil {
+				return err
+			}
+		}
+
+		if err := deleter.exportRepository.DeleteExport(export.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// drain calls remove until it removes less than a full batch
+func drain(remove func() (int, error)) error {
+	for {
+		removed, err := remove()
+		if err != nil {
+			return err
+		}
+		if removed < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package trash
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type deleterMocks struct {
+	deletions     *mocks.AccountDeletionsRepositoryMock
+	posts         *mocks.PostsRepositoryMock
+	users         *mocks.UsersRepositoryMock
+	bookmarks     *mocks.BookmarksRepositoryMock
+	notifications *mocks.NotificationsRepositoryMock
+	messages      *mocks.MessagesRepositoryMock
+	media         *mocks.MediaRepositoryMock
+	exports       *mocks.ExportsRepositoryMock
+}
+
+// newTestDeleter returns a deleter whose steps all succeed for the nick "1", but for the follows step that
+// returns followsError
+func newTestDeleter(followsError error) (*AccountDeleter, deleterMocks) {
+	m := deleterMocks{
+		mocks.NewAccountDeletionsRepositoryMock(),
+		mocks.NewPostsRepositoryMock(),
+		mocks.NewUsersRepositoryMock(),
+		mocks.NewBookmarksRepositoryMock(),
+		mocks.NewNotificationsRepositoryMock(),
+		mocks.NewMessagesRepositoryMock(),
+		mocks.NewMediaRepositoryMock(),
+		mocks.NewExportsRepositoryMock(),
+	}
+
+	m.deletions.On("Advance", "1", mock.Anything).Return(nil)
+	m.deletions.On("Fail", "1", mock.Anything).Return(nil)
+	m.deletions.On("Complete", "1").Return(nil)
+
+	// a full batch of likes is removed first, so the step goes for another one
+	m.posts.On("PurgeAuthorPosts", "1", batchSize).Return(0, nil)
+	m.posts.On("RemoveLikesBy", "1", batchSize).Return(batchSize, nil).Once()
+	m.posts.On("RemoveLikesBy", "1", batchSize).Return(3, nil).Once()
+	m.posts.On("RemoveReactionsBy", "1", batchSize).Return(0, nil)
+	m.posts.On("RemoveVotesBy", "1", batchSize).Return(0, nil)
+	m.bookmarks.On("DeleteUserBookmarks", "1").Return(nil)
+	m.users.On("RemoveReferences", "1").Return(followsError)
+	m.notifications.On("DeleteUserNotifications", "1").Return(nil)
+	m.messages.On("RemoveParticipant", "1").Return(nil)
+	m.media.On("GetMediaByOwner", "1", 1, batchSize).Return([]entities.Media{}, nil)
+	m.exports.On("GetExports", "1").Return([]entities.DataExport{}, nil)
+	m.users.On("ReserveDeletedNick", "1").Return(nil)
+	m.users.On("PurgeUser", "1").Return(nil)
+
+	deleter := NewAccountDeleter(m.deletions, m.posts, m.users, m.bookmarks, m.notifications, m.messages, m.media, m.exports, mocks.NewBlobStoreMock())
+	return deleter, m
+}
+
+// recordedSteps returns the calls made to the deletions repository, like "Advance likes" or "Complete"
+func recordedSteps(deletions *mocks.AccountDeletionsRepositoryMock) []string {
+	steps := []string{}
+	for _, call := range deletions.Calls {
+		switch call.Method {
+		case "Advance":
+			steps = append(steps, "Advance "+call.Arguments.String(1))
+		case "Fail", "Complete":
+			steps = append(steps, call.Method)
+		}
+	}
+
+	return steps
+}
+
+func TestRunAccountDeletion(t *testing.T) {
+	deleter, m := newTestDeleter(nil)
+
+	err := deleter.Run(entities.AccountDeletion{Nick: "1", Step: entities.DeletionStepPosts})
+	assert.Nil(t, err)
+
+	// every step is recorded in order, and the deletion completes only after the last one
+	expected := []string{}
+	for _, step := range entities.AccountDeletionSteps[1:] {
+		expected = append(expected, "Advance "+step)
+	}
+	expected = append(expected, "Complete")
+
+	assert.Equal(t, expected, recordedSteps(m.deletions))
+	m.posts.AssertNumberOfCalls(t, "RemoveLikesBy", 2)
+	m.users.AssertCalled(t, "PurgeUser", "1")
+}
+
+func TestResumeAccountDeletion(t *testing.T) {
+	deleter, m := newTestDeleter(nil)
+
+	err := deleter.Run(entities.AccountDeletion{Nick: "1", Step: entities.DeletionStepMedia})
+	assert.Nil(t, err)
+
+	// the steps done before the interruption don't run again
+	m.posts.AssertNotCalled(t, "PurgeAuthorPosts", "1", batchSize)
+	m.users.AssertNotCalled(t, "RemoveReferences", "1")
+	m.messages.AssertNotCalled(t, "RemoveParticipant", "1")
+	m.media.AssertCalled(t, "GetMediaByOwner", "1", 1, batchSize)
+
+	assert.Equal(t, []string{
+		"Advance " + entities.DeletionStepExports,
+		"Advance " + entities.DeletionStepSessions,
+		"Advance " + entities.DeletionStepAccount,
+		"Complete",
+	}, recordedSteps(m.deletions))
+}
+
+func TestFailedAccountDeletion(t *testing.T) {
+	deleter, m := newTestDeleter(assert.AnError)
+
+	err := deleter.Run(entities.AccountDeletion{Nick: "1", Step: entities.DeletionStepBookmarks})
+	assert.NotNil(t, err)
+
+	// the deletion stops at the failing step, to resume from it on the next run
+	assert.Equal(t, []string{"Advance " + entities.DeletionStepFollows, "Fail"}, recordedSteps(m.deletions))
+	m.deletions.AssertCalled(t, "Fail", "1", assert.AnError.Error())
+	m.notifications.AssertNotCalled(t, "DeleteUserNotifications", "1")
+	m.users.AssertNotCalled(t, "PurgeUser", "1")
+}
+
+func TestRunPendingAccountDeletions(t *testing.T) {
+	deleter, m := newTestDeleter(assert.AnError)
+	m.deletions.On("GetPending", 10).Return([]entities.AccountDeletion{
+		{Nick: "1", Step: entities.DeletionStepFollows},
+		{Nick: "1", Step: entities.DeletionStepSessions},
+	}, nil)
+
+	completed, err := deleter.RunPending(10)
+	assert.Nil(t, err)
+
+	// the failed deletion doesn't keep the next one from completing
+	assert.Equal(t, 1, completed)
+	m.deletions.AssertNumberOfCalls(t, "Fail", 1)
+	m.deletions.AssertNumberOfCalls(t, "Complete", 1)
+}
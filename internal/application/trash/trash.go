@@ -0,0 +1,94 @@
+package trash
+
+import (
+	"api/internal/domain/repositories"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	// lockName is the lease the instances compete for, only its holder purges the trash
+	lockName = "trash-purge"
+	// lease is how long the purge holds the lock, it's extended on every batch
+	lease = 10 * time.Minute
+	// batchSize is how many posts or accounts are handled before the lease is extended
+	batchSize = 100
+)
+
+// Purger removes for good the posts and accounts left in the trash longer than the retention. It also
+// finishes the nick changes interrupted before all the references to the old nick were rewritten
+type Purger struct {
+	postRepository repositories.PostsRepository
+	userRepository repositories.UsersRepository
+	lockRepository repositories.LocksRepository
+	accountDeleter *AccountDeleter
+	retention      time.Duration
+	owner          string
+}
+
+func NewPurger(postRepository repositories.PostsRepository, userRepository repositories.UsersRepository, lockRepository repositories.LocksRepository, accountDeleter *AccountDeleter, retention time.Duration) *Purger {
+	hostname, _ := os.Hostname()
+
+	return &Purger{
+		postRepository,
+		userRepository,
+		lockRepository,
+		accountDeleter,
+		retention,
+		fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+	}
+}
+
+// Purge removes what was deleted before the retention, when this instance gets the lease. The trashed posts go
+// first, then the expired accounts are scheduled for deletion and the pending deletions carried on, and last
+// the interrupted nick changes are resumed
+func (purger *Purger) Purge(now time.Time) (err error) {
+	before := now.Add(-purger.retention)
+
+	defer func() {
+		if releaseErr := purger.lockRepository.Release(lockName, purger.owner); err == nil {
+			err = releaseErr
+		}
+	}()
+
+	for _, purge := range []func() (int, error){
+		func() (int, error) { return purger.postRepository.PurgeTrashedPosts(before, batchSize) },
+		func() (int, error) { return purger.accountDeleter.Schedule(before, batchSize) },
+		func() (int, error) { return purger.accountDeleter.RunPending(batchSize) },
+		func() (int, error) { return purger.userRepository.ResumeNickChanges(batchSize) },
+	} {
+		for {
+			acquired, err := purger.lockRepository.Acquire(lockName, purger.owner, lease)
+			if err != nil || !acquired {
+				return err
+			}
+
+			purged, err := purge()
+			if err != nil {
+				return err
+			}
+			if purged < batchSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Start purges the trash on every interval
+func (purger *Purger) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := purger.Purge(time.Now()); err != nil {
+				log.Printf("could not purge the trash: %s", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,149 @@
+package trends
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTrends is how many tags are kept for each window
+	maxTrends = 20
+	// minPosts avoids a single viral post being enough for a tag to trend
+	minPosts = 2
+	// growthSmoothing is added to the activity of both periods, so a tag without a baseline needs some volume
+	// to trend and a tiny baseline doesn't make any use look like a spike
+	growthSmoothing = 10.0
+)
+
+// Windows are the periods trends can be asked for
+var Windows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// Rank scores the tags active during the window by how much their activity grew over the window before it, so
+// a tag spiking now ranks above one that is used a lot all the time
+func Rank(stats []entities.TagStats, window time.Duration, now time.Time) []entities.Trend {
+	since := now.Add(-window)
+	baselineSince := since.Add(-window)
+
+	trendsByTag := map[string]*entities.Trend{}
+	current := map[string]float64{}
+	baseline := map[string]float64{}
+	for _, stat := range stats {
+		if stat.Bucket.Before(baselineSince) || stat.Bucket.After(now) {
+			continue
+		}
+
+		activity := float64(stat.Posts)*3 + float64(stat.Replies)*2 + float64(stat.Reposts)*2 + float64(stat.Likes)
+		if stat.Bucket.Before(since) {
+			baseline[stat.Tag] += activity
+			continue
+		}
+
+		trend, found := trendsByTag[stat.Tag]
+		if !found {
+			trend = &entities.Trend{Tag: stat.Tag}
+			trendsByTag[stat.Tag] = trend
+		}
+
+		trend.Posts += stat.Posts
+		trend.Engagement += stat.Likes + stat.Replies + stat.Reposts
+		current[stat.Tag] += activity
+	}
+
+	ranked := []entities.Trend{}
+	for tag, trend := range trendsByTag {
+		if trend.Posts < minPosts {
+			continue
+		}
+
+		growth := (current[tag] + growthSmoothing) / (baseline[tag] + growthSmoothing)
+		trend.Score = math.Round(growth*100) / 100
+		ranked = append(ranked, *trend)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].Posts != ranked[j].Posts {
+			return ranked[i].Posts > ranked[j].Posts
+		}
+		return ranked[i].Tag < ranked[j].Tag
+	})
+
+	if len(ranked) > maxTrends {
+		ranked = ranked[:maxTrends]
+	}
+
+	return ranked
+}
+
+// Cache keeps the trends computed by the last refresh of each window
+type Cache struct {
+	mutex  sync.RWMutex
+	trends map[string][]entities.Trend
+}
+
+func NewCache() *Cache {
+	return &Cache{trends: map[string][]entities.Trend{}}
+}
+
+func (cache *Cache) Get(window string) ([]entities.Trend, bool) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	trends, found := cache.trends[window]
+	return trends, found
+}
+
+func (cache *Cache) Set(window string, trends []entities.Trend) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.trends[window] = trends
+}
+
+// Refresh ranks every window from the tag stats, along with the window before it, and stores the result on the
+// cache
+func Refresh(repository repositories.TrendsRepository, cache *Cache, now time.Time) error {
+	longestWindow := time.Duration(0)
+	for _, window := range Windows {
+		if window > longestWindow {
+			longestWindow = window
+		}
+	}
+
+	stats, err := repository.GetTagStats(now.Add(-2 * longestWindow))
+	if err != nil {
+		return err
+	}
+
+	for name, window := range Windows {
+		cache.Set(name, Rank(stats, window, now))
+	}
+
+	return nil
+}
+
+// Start refreshes the trends right away and then on every interval, in the background
+func Start(repository repositories.TrendsRepository, cache *Cache, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := Refresh(repository, cache, time.Now()); err != nil {
+				log.Printf("could not refresh the trends: %s", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package visibility
+
+import "api/internal/domain/entities"
+
+// CanSee tells if the viewer is allowed to see the posts of the author, private accounts are only
+// visible to their followers and blocks hide both users from each other
+func CanSee(viewer entities.User, author entities.User) bool {
+	if author.Nick == "" {
+		return false
+	}
+
+	if viewer.Nick != "" && viewer.Nick == author.Nick {
+		return true
+	}
+
+	if contains(author.Blocked, viewer.Nick) || contains(viewer.Blocked, author.Nick) {
+		return false
+	}
+
+	return !author.Private || contains(author.Followers, viewer.Nick)
+}
+
+// CanRead tells if the viewer is allowed to read the post given its visibility level. The author can always
+// read it, followers-only posts need the viewer to follow the author and mentioned-only posts need the viewer
+// to be mentioned. It doesn't check blocks and private accounts, CanSee does
+func CanRead(viewer entities.User, post entities.Post) bool {
+	if viewer.Nick != "" && viewer.Nick == post.AuthorNick {
+		return true
+	}
+
+	switch post.Visibility {
+	case entities.VisibilityFollowers:
+		return contains(viewer.Following, post.AuthorNick)
+	case entities.VisibilityMentioned:
+		for _, mention := range post.Mentions {
+			if viewer.Nick != "" && mention.Nick == viewer.Nick {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// CanMessage tells if the sender is allowed to send direct messages to the recipient, blocks stop them both
+// ways and users can accept messages only from the ones they follow
+func CanMessage(sender entities.User, recipient entities.User) bool {
+	if recipient.Nick == "" || sender.Nick == recipient.Nick {
+		return false
+	}
+
+	if contains(recipient.Blocked, sender.Nick) || contains(sender.Blocked, recipient.Nick) {
+		return false
+	}
+
+	return !recipient.DMFollowingOnly || contains(recipient.Following, sender.Nick)
+}
+
+// Blocked tells if the user blocked the other user
+func Blocked(user entities.User, otherNick string) bool {
+	return contains(user.Blocked, otherNick)
+}
+
+// Silenced tells if the user doesn't want to hear from the other user, because they blocked or muted them
+func Silenced(user entities.User, otherNick string) bool {
+	return contains(user.Blocked, otherNick) || contains(user.Muted, otherNick)
+}
+
+func contains(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package entities
+
+import "time"
+
+// steps of an account deletion, run in this order. Each one can run again without harm, so a deletion that
+// was interrupted resumes from the step it stopped at
+const (
+	DeletionStepPosts         = "posts"
+	DeletionStepLikes         = "likes"
+	DeletionStepReactions     = "reactions"
+	DeletionStepVotes         = "votes"
+	DeletionStepBookmarks     = "bookmarks"
+	DeletionStepFollows       = "follows"
+	DeletionStepNotifications = "notifications"
+	DeletionStepConversations = "conversations"
+	DeletionStepMedia         = "media"
+	DeletionStepExports       = "exports"
+	DeletionStepSessions      = "sessions"
+	DeletionStepAccount       = "account"
+)
+
+var AccountDeletionSteps = []string{
+	DeletionStepPosts,
+	DeletionStepLikes,
+	DeletionStepReactions,
+	DeletionStepVotes,
+	DeletionStepBookmarks,
+	DeletionStepFollows,
+	DeletionStepNotifications,
+	DeletionStepConversations,
+	DeletionStepMedia,
+	DeletionStepExports,
+	DeletionStepSessions,
+	DeletionStepAccount,
+}
+
+// AccountDeletion is the progress of removing for good an account whose trash retention passed
+type AccountDeletion struct {
+	Nick      string    `json:"nick" bson:"_id"`
+	Step      string    `json:"step" bson:"step"`
+	Attempts  int       `json:"attempts" bson:"attempts"`
+	LastError string    `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	StartedAt time.Time `json:"startedAt" bson:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
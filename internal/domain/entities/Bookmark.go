@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxBookmarkCollectionNameLength = 50
+
+// Bookmark is a post the user saved for later, only the user sees it. It can be kept on one of the user's
+// collections
+type Bookmark struct {
+	PostID       string    `json:"postId" bson:"postId"`
+	Nick         string    `json:"-" bson:"nick"`
+	CollectionID string    `json:"collectionId,omitempty" bson:"collectionId,omitempty"`
+	CreatedAt    time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// BookmarkCollection is a named group of bookmarks
+type BookmarkCollection struct {
+	ID        string    `json:"id,omitempty" bson:"_id,omitempty"`
+	Nick      string    `json:"-" bson:"nick"`
+	Name      string    `json:"name" bson:"name"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// BookmarkedPost is a bookmark along with the post it saved
+type BookmarkedPost struct {
+	Bookmark `bson:",inline"`
+	Post     Post `json:"post" bson:"post"`
+}
+
+func (collection *BookmarkCollection) Prepare() error {
+	collection.Name = strings.TrimSpace(collection.Name)
+
+	if collection.Name == "" {
+		return errors.New("The name is required and can't be empty")
+	}
+
+	if utf8.RuneCountInString(collection.Name) > maxBookmarkCollectionNameLength {
+		return errors.New("The collection name can't be longer than 50 characters")
+	}
+
+	return nil
+}
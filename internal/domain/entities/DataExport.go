@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// states of a data export, from being requested until its archive can't be downloaded anymore
+const (
+	ExportStatusPending = "pending"
+	ExportStatusRunning = "running"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+	ExportStatusExpired = "expired"
+)
+
+// DataExport is a copy of everything a user has on the API, built in the background as a ZIP archive
+type DataExport struct {
+	ID     string `json:"id" bson:"_id"`
+	Nick   string `json:"nick" bson:"nick"`
+	Status string `json:"status" bson:"status"`
+	Error  string `json:"error,omitempty" bson:"error,omitempty"`
+	Size   int    `json:"size,omitempty" bson:"size,omitempty"`
+	Key    string `json:"-" bson:"key,omitempty"`
+	// InProgress is set while the export is pending or running, a user has a single one of those at a time
+	InProgress bool `json:"-" bson:"inProgress,omitempty"`
+	// Owner is the instance building the export, until LeaseUntil passes
+	Owner       string     `json:"-" bson:"owner,omitempty"`
+	LeaseUntil  *time.Time `json:"-" bson:"leaseUntil,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+}
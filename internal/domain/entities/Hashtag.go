@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	maxHashtagLength   = 50
+	maxHashtagsPerPost = 10
+)
+
+// a hashtag starts on a # that isn't glued to a word, an url fragment or another hashtag
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_&/#])#([\p{L}\p{M}\p{N}_]+)`)
+
+type Hashtag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeHashtag is the value used to store and compare hashtags, "#Golang" and "golang" are the same tag
+func NormalizeHashtag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	return strings.ToLower(norm.NFKC.String(tag))
+}
+
+// ExtractHashtags returns the normalized hashtags found on the content, in the order they appear.
+// Tags made only of numbers, like "#1", aren't hashtags
+func ExtractHashtags(content string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(norm.NFKC.String(content), -1) {
+		tag := NormalizeHashtag(match[1])
+		if seen[tag] || utf8.RuneCountInString(tag) > maxHashtagLength || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+		if len(tags) == maxHashtagsPerPost {
+			break
+		}
+	}
+
+	return tags
+}
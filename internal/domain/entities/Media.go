@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+type Media struct {
+	ID          string      `json:"id,omitempty" bson:"_id"`
+	OwnerNick   string      `json:"ownerNick,omitempty" bson:"ownerNick"`
+	ContentType string      `json:"contentType,omitempty" bson:"contentType"`
+	Size        int         `json:"size" bson:"size"`
+	Width       int         `json:"width" bson:"width"`
+	Height      int         `json:"height" bson:"height"`
+	Key         string      `json:"-" bson:"key"`
+	Thumbnails  []Thumbnail `json:"thumbnails" bson:"thumbnails"`
+	CreatedAt   time.Time   `json:"createdAt,omitempty" bson:"createdAt"`
+}
+
+type Thumbnail struct {
+	Size        string `json:"size" bson:"size"`
+	ContentType string `json:"contentType" bson:"contentType"`
+	Width       int    `json:"width" bson:"width"`
+	Height      int    `json:"height" bson:"height"`
+	Key         string `json:"-" bson:"key"`
+}
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// maxMentionedUsers limits how many different users a post can mention
+const maxMentionedUsers = 10
+
+// a mention starts on an @ that isn't part of an email, an url or another mention
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_@/.])@([\p{L}\p{M}\p{N}_]+)`)
+
+// Mention links a part of the content to a user. Start and End are offsets in characters (not bytes) of the
+// content, End not included, covering the @ and the nick
+type Mention struct {
+	Nick  string `json:"nick" bson:"nick"`
+	Start int    `json:"start" bson:"start"`
+	End   int    `json:"end" bson:"end"`
+}
+
+// ExtractMentions returns every @nick found on the content, they still have to be resolved to real users
+func ExtractMentions(content string) []Mention {
+	mentions := []Mention{}
+	nicks := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		// match[2] and match[3] are the limits of the nick, the @ is right before it
+		nick := content[match[2]:match[3]]
+		length := utf8.RuneCountInString(nick)
+		if length < minNickLength || length > maxNickLength {
+			continue
+		}
+
+		if !nicks[nick] {
+			if len(nicks) == maxMentionedUsers {
+				continue
+			}
+			nicks[nick] = true
+		}
+
+		start := utf8.RuneCountInString(content[:match[2]-1])
+		mentions = append(mentions, Mention{
+			Nick:  nick,
+			Start: start,
+			End:   start + 1 + length,
+		})
+	}
+
+	return mentions
+}
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxMessageLength = 2000
+	// MaxConversationParticipants counts every member of a group, its creator included
+	MaxConversationParticipants = 10
+	maxConversationNameLength   = 50
+)
+
+// Conversation is a one-to-one or group chat. One-to-one conversations have a Key made of both nicks, so the
+// same pair always shares the same conversation
+type Conversation struct {
+	ID           string        `json:"id,omitempty" bson:"_id,omitempty"`
+	Key          string        `json:"-" bson:"key,omitempty"`
+	Group        bool          `json:"group" bson:"group"`
+	Name         string        `json:"name,omitempty" bson:"name,omitempty"`
+	CreatedBy    string        `json:"createdBy" bson:"createdBy"`
+	Participants []Participant `json:"participants" bson:"participants"`
+	LastMessage  *Message      `json:"lastMessage,omitempty" bson:"lastMessage,omitempty"`
+	// UnreadCount is the number of messages the user reading the conversation didn't read yet
+	UnreadCount int       `json:"unreadCount" bson:"-"`
+	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Participant keeps what each member read and deleted. A participant that deletes the conversation stops
+// seeing the messages sent before ClearedAt, and the conversation is Hidden until a new message arrives
+type Participant struct {
+	Nick       string    `json:"nick" bson:"nick"`
+	LastReadAt time.Time `json:"lastReadAt" bson:"lastReadAt"`
+	Unread     int       `json:"-" bson:"unread"`
+	ClearedAt  time.Time `json:"-" bson:"clearedAt"`
+	Hidden     bool      `json:"-" bson:"hidden"`
+}
+
+type Message struct {
+	ID             string `json:"id,omitempty" bson:"_id,omitempty"`
+	ConversationID string `json:"conversationId" bson:"conversationId"`
+	SenderNick     string `json:"senderNick" bson:"senderNick"`
+	Content        string `json:"content" bson:"content"`
+	// DeletedFor are the participants that deleted the message, it's still shown to the others
+	DeletedFor []string `json:"-" bson:"deletedFor,omitempty"`
+	// ReadBy are the other participants that already read the message, filled when the message is read
+	ReadBy    []string  `json:"readBy" bson:"-"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// DirectConversationKey identifies the one-to-one conversation between two users, whatever the order
+func DirectConversationKey(nick string, otherNick string) string {
+	if nick > otherNick {
+		nick, otherNick = otherNick, nick
+	}
+	return nick + ":" + otherNick
+}
+
+func (conversation *Conversation) Participant(nick string) (Participant, bool) {
+	for _, participant := range conversation.Participants {
+		if participant.Nick == nick {
+			return participant, true
+		}
+	}
+	return Participant{}, false
+}
+
+func (conversation *Conversation) Prepare() error {
+	conversation.Name = strings.TrimSpace(conversation.Name)
+
+	if len(conversation.Participants) < 2 {
+		return errors.New("A conversation needs at least another participant")
+	}
+
+	if len(conversation.Participants) > MaxConversationParticipants {
+		return errors.New("A conversation can't have more than 10 participants")
+	}
+
+	if !conversation.Group && conversation.Name != "" {
+		return errors.New("Only group conversations can have a name")
+	}
+
+	if utf8.RuneCountInString(conversation.Name) > maxConversationNameLength {
+		return errors.New("The conversation name can't be longer than 50 characters")
+	}
+
+	return nil
+}
+
+func (message *Message) Prepare() error {
+	message.Content = strings.TrimSpace(message.Content)
+
+	if message.Content == "" {
+		return errors.New("The content is required and can't be empty")
+	}
+
+	if utf8.RuneCountInString(message.Content) > maxMessageLength {
+		return errors.New("A message can't be longer than 2000 characters")
+	}
+
+	return nil
+}
+
+// ReadReceipt tells the other participants up to when a participant read the conversation
+type ReadReceipt struct {
+	ConversationID string    `json:"conversationId"`
+	Nick           string    `json:"nick"`
+	ReadAt         time.Time `json:"readAt"`
+}
@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	minNickLength = 3
+	maxNickLength = 30
+)
+
+// reservedNicks can't be registered by anyone, mostly route names and staff-like handles
+var reservedNicks = map[string]bool{}
+
+func init() {
+	AddReservedNicks([]string{
+		"about", "admin", "administrator", "api", "auth", "bookmarks", "devbook", "drafts", "explore",
+		"feed", "help", "home", "login", "logout", "me", "media", "messages", "moderator",
+		"notifications", "null", "posts", "register", "relationships", "root", "search", "security",
+		"settings", "signup", "staff", "stream", "support", "system", "tags", "trash", "trends",
+		"undefined", "users",
+	})
+}
+
+// AddReservedNicks extends the reserved list, used to load the RESERVED_NICKS configuration
+func AddReservedNicks(nicks []string) {
+	for _, nick := range nicks {
+		if key := NickKey(nick); key != "" {
+			reservedNicks[key] = true
+		}
+	}
+}
+
+// confusables maps characters from other scripts to the latin letter they look like
+var confusables = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'А': 'a', 'В': 'b', 'в': 'b', 'е': 'e', 'Е': 'e', 'К': 'k', 'к': 'k', 'М': 'm',
+	'Н': 'h', 'н': 'h', 'о': 'o', 'О': 'o', 'р': 'p', 'Р': 'p', 'с': 'c', 'С': 'c', 'Т': 't',
+	'у': 'y', 'У': 'y', 'х': 'x', 'Х': 'x', 'і': 'i', 'І': 'i', 'ј': 'j', 'Ј': 'j', 'ѕ': 's',
+	'Ѕ': 's', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l',
+	// greek
+	'α': 'a', 'Α': 'a', 'Β': 'b', 'ε': 'e', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h', 'ι': 'i', 'Ι': 'i',
+	'κ': 'k', 'Κ': 'k', 'Μ': 'm', 'ν': 'v', 'Ν': 'n', 'ο': 'o', 'Ο': 'o', 'ρ': 'p', 'Ρ': 'p',
+	'Τ': 't', 'υ': 'u', 'Υ': 'y', 'χ': 'x', 'Χ': 'x',
+	// latin look-alikes outside ascii
+	'ı': 'i', 'ɑ': 'a', 'ɡ': 'g', 'ʀ': 'r', 'ո': 'n', 'ս': 'u',
+}
+
+// NormalizeNick applies the unicode compatibility normalization, so "ｆｕｌｌwidth" becomes "fullwidth"
+func NormalizeNick(nick string) string {
+	return norm.NFKC.String(strings.TrimSpace(nick))
+}
+
+// NickKey is the value used to compare nicks, nicks with the same key can't coexist.
+// It ignores case and maps confusable characters to the latin letter they look like
+func NickKey(nick string) string {
+	var key strings.Builder
+	for _, char := range NormalizeNick(nick) {
+		if latin, found := confusables[char]; found {
+			char = latin
+		}
+		key.WriteRune(unicode.ToLower(char))
+	}
+
+	return key.String()
+}
+
+// ValidateNick checks the characters, size and reserved words of a nick
+func ValidateNick(nick string) error {
+	nick = NormalizeNick(nick)
+	if nick == "" {
+		return errors.New("The nick is required and can't be empty")
+	}
+
+	length := utf8.RuneCountInString(nick)
+	if length < minNickLength || length > maxNickLength {
+		return errors.New("The nick must have between 3 and 30 characters")
+	}
+
+	hasLatin, hasConfusable := false, false
+	for _, char := range nick {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_' {
+			return errors.New("The nick can only have letters, numbers and underscores")
+		}
+
+		if char < utf8.RuneSelf && unicode.IsLetter(char) {
+			hasLatin = true
+		}
+		if _, found := confusables[char]; found {
+			hasConfusable = true
+		}
+	}
+
+	// mixing latin with look-alike letters is how "admin" becomes "аdmin"
+	if hasLatin && hasConfusable {
+		return errors.New("The nick mixes characters that look alike from different alphabets")
+	}
+
+	if reservedNicks[NickKey(nick)] {
+		return errors.New("This nick is reserved")
+	}
+
+	return nil
+}
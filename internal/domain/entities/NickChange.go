@@ -0,0 +1,43 @@
+package entities
+
+import "time"
+
+// steps of a nick change, run in this order. The account takes the new nick first and the references to the
+// old one are rewritten after it. Each step can run again without harm, so a change that was interrupted
+// resumes from the step it stopped at
+const (
+	NickChangeStepAccount       = "account"
+	NickChangeStepHistory       = "history"
+	NickChangeStepRelationships = "relationships"
+	NickChangeStepPosts         = "posts"
+	NickChangeStepOwned         = "owned"
+	NickChangeStepMentions      = "mentions"
+	NickChangeStepNotifications = "notifications"
+	NickChangeStepConversations = "conversations"
+)
+
+var NickChangeSteps = []string{
+	NickChangeStepAccount,
+	NickChangeStepHistory,
+	NickChangeStepRelationships,
+	NickChangeStepPosts,
+	NickChangeStepOwned,
+	NickChangeStepMentions,
+	NickChangeStepNotifications,
+	NickChangeStepConversations,
+}
+
+type NickChange struct {
+	ID            string    `json:"-" bson:"_id,omitempty"`
+	OldNick       string    `json:"oldNick" bson:"oldNick"`
+	OldNickKey    string    `json:"-" bson:"oldNickKey"`
+	NewNick       string    `json:"newNick" bson:"newNick"`
+	CurrentNick   string    `json:"currentNick" bson:"currentNick"`
+	ChangedAt     time.Time `json:"changedAt" bson:"changedAt"`
+	RedirectUntil time.Time `json:"redirectUntil" bson:"redirectUntil"`
+	ReservedUntil time.Time `json:"reservedUntil" bson:"reservedUntil"`
+	// Step is the step the change is at while its references are rewritten, it's removed once all of them are
+	Step      string    `json:"-" bson:"step,omitempty"`
+	LastError string    `json:"-" bson:"lastError,omitempty"`
+	UpdatedAt time.Time `json:"-" bson:"updatedAt,omitempty"`
+}
@@ -0,0 +1,45 @@
+package entities
+
+import "time"
+
+// kinds of notification, they are also the categories a user can turn off
+const (
+	NotificationFollow        = "follow"
+	NotificationFollowRequest = "follow_request"
+	NotificationLike          = "like"
+	NotificationReaction      = "reaction"
+	NotificationReply         = "reply"
+	NotificationMention       = "mention"
+	NotificationRepost        = "repost"
+	NotificationQuote         = "quote"
+)
+
+// NotificationTypes lists every kind of notification
+var NotificationTypes = []string{
+	NotificationFollow, NotificationFollowRequest, NotificationLike, NotificationReaction, NotificationReply,
+	NotificationMention, NotificationRepost, NotificationQuote,
+}
+
+// Notification groups the unread events of the same kind on the same post, like every new like on a post.
+// Actors has the most recent users first, ActorCount counts all of them
+type Notification struct {
+	ID         string    `json:"id,omitempty" bson:"_id,omitempty"`
+	Recipient  string    `json:"-" bson:"recipient"`
+	Type       string    `json:"type" bson:"type"`
+	Actor      string    `json:"actor" bson:"actor"`
+	Actors     []string  `json:"actors" bson:"actors"`
+	ActorCount int       `json:"actorCount" bson:"actorCount"`
+	PostID     string    `json:"postId,omitempty" bson:"postId,omitempty"`
+	Summary    string    `json:"summary" bson:"-"`
+	Read       bool      `json:"read" bson:"read"`
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+type NotificationsPage struct {
+	UnreadCount   int            `json:"unreadCount"`
+	Notifications []Notification `json:"notifications"`
+}
+
+// NotificationPreferences tells for each kind of notification if it's turned on
+type NotificationPreferences map[string]bool
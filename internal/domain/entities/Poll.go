@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 6
+	maxPollOptionLength = 80
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// MinPollDuration is how long a poll stays open at least after its post is published
+const MinPollDuration = 5 * time.Minute
+
+// Poll is a question asked on a post, single-choice unless Multiple. Its results are only shown to the ones
+// who voted, or to anyone once it closes at ExpiresAt
+type Poll struct {
+	Options    []PollOption `json:"options" bson:"options"`
+	Multiple   bool         `json:"multiple" bson:"multiple"`
+	ExpiresAt  time.Time    `json:"expiresAt" bson:"expiresAt"`
+	VoterCount int          `json:"voterCount" bson:"voterCount"`
+	// Closed and OwnVotes are filled for the one reading the post
+	Closed   bool  `json:"closed" bson:"-"`
+	OwnVotes []int `json:"ownVotes,omitempty" bson:"-"`
+}
+
+type PollOption struct {
+	Text string `json:"text" bson:"text"`
+	// Votes is kept hidden, Results shows it to the ones allowed to see the results
+	Votes   int  `json:"-" bson:"votes"`
+	Results *int `json:"votes,omitempty" bson:"-"`
+}
+
+// PollVote is the choice of a user on the poll of a post, Options are positions on the poll options
+type PollVote struct {
+	PostID    string    `json:"postId" bson:"postId"`
+	Nick      string    `json:"-" bson:"nick"`
+	Options   []int     `json:"options" bson:"options"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// Prepare validates a new poll of a post published at opensAt. The votes it carries are ignored, a poll
+// starts without any
+func (poll *Poll) Prepare(opensAt time.Time) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return errors.New("A poll must have between 2 and 6 options")
+	}
+
+	seen := map[string]bool{}
+	for i := range poll.Options {
+		option := &poll.Options[i]
+		option.Text = strings.TrimSpace(option.Text)
+		option.Votes = 0
+		option.Results = nil
+
+		if option.Text == "" {
+			return errors.New("The poll options can't be empty")
+		}
+
+		if utf8.RuneCountInString(option.Text) > maxPollOptionLength {
+			return errors.New("A poll option can't be longer than 80 characters")
+		}
+
+		key := strings.ToLower(option.Text)
+		if seen[key] {
+			return errors.New("The poll options must be different from each other")
+		}
+		seen[key] = true
+	}
+
+	duration := poll.ExpiresAt.Sub(opensAt)
+	if duration < MinPollDuration || duration > maxPollDuration {
+		return errors.New("A poll must close between 5 minutes and 7 days after the post is published")
+	}
+
+	poll.VoterCount = 0
+	poll.Closed = false
+	poll.OwnVotes = nil
+
+	return nil
+}
+
+// IsClosed tells if the poll doesn't accept votes anymore
+func (poll *Poll) IsClosed(now time.Time) bool {
+	return !now.Before(poll.ExpiresAt)
+}
+
+// CanOpen tells if a post published at opensAt still leaves the poll open for MinPollDuration. A draft or a
+// scheduled post validated earlier may be published too late for it
+func (poll *Poll) CanOpen(opensAt time.Time) bool {
+	return poll.ExpiresAt.Sub(opensAt) >= MinPollDuration
+}
+
+// CheckVote validates the options chosen, returning them sorted and without repetitions
+func (poll *Poll) CheckVote(options []int) ([]int, error) {
+	if len(options) == 0 {
+		return nil, errors.New("You must choose at least one option")
+	}
+
+	chosen := []int{}
+	seen := map[int]bool{}
+	for _, option := range options {
+		if option < 0 || option >= len(poll.Options) {
+			return nil, fmt.Errorf("There's no option %d on this poll", option)
+		}
+
+		if !seen[option] {
+			seen[option] = true
+			chosen = append(chosen, option)
+		}
+	}
+
+	if !poll.Multiple && len(chosen) > 1 {
+		return nil, errors.New("This poll accepts a single option")
+	}
+
+	sort.Ints(chosen)
+	return chosen, nil
+}
+
+// Reveal fills the poll for its reader, given the options they voted on. The results are shown once the reader
+// voted or the poll closed
+func (poll *Poll) Reveal(ownVotes []int, now time.Time) {
+	poll.Closed = poll.IsClosed(now)
+	poll.OwnVotes = ownVotes
+
+	if !poll.Closed && len(ownVotes) == 0 {
+		return
+	}
+
+	for i := range poll.Options {
+		votes := poll.Options[i].Votes
+		poll.Options[i].Results = &votes
+	}
+}
@@ -6,14 +6,71 @@ import (
 	"time"
 )
 
+const maxMediaPerPost = 4
+
+// who can read a post. Unlisted posts are public but left out of the public listings, like the tags
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityMentioned = "mentioned"
+	VisibilityUnlisted  = "unlisted"
+)
+
+// states of a post not published yet, a published post has no status. Drafts wait for their author while
+// scheduled posts are published by the scheduler once their publishAt time comes
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+)
+
 type Post struct {
-	Title      string    `json:"title,omitempty" bson:"title"`
-	Content    string    `json:"content,omitempty" bson:"content"`
-	AuthorID   string    `json:"authorId,omitempty" bson:"authorId"`
-	AuthorNick string    `json:"authorNick,omitempty" bson:"authorNick"`
-	Likes      int       `json:"likes" bson:"likes"`
-	CreatedAt  time.Time `json:"createdAt,omitempty" bson:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt,omitempty" bson:"updatedAt"`
+	ID         string   `json:"id,omitempty" bson:"_id,omitempty"`
+	Title      string   `json:"title,omitempty" bson:"title"`
+	Content    string   `json:"content,omitempty" bson:"content"`
+	AuthorID   string   `json:"authorId,omitempty" bson:"authorId"`
+	AuthorNick string   `json:"authorNick,omitempty" bson:"authorNick"`
+	Likes      int      `json:"likes" bson:"likes"`
+	MediaIDs   []string `json:"mediaIds,omitempty" bson:"mediaIds,omitempty"`
+	// Visibility is one of the visibility levels, posts saved before it existed are public
+	Visibility string `json:"visibility,omitempty" bson:"visibility,omitempty"`
+	// Tags are the hashtags found on the content
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// Mentions are the users mentioned on the content
+	Mentions []Mention `json:"mentions,omitempty" bson:"mentions,omitempty"`
+	// ParentID is the post being replied, RootID is the first post of the conversation
+	ParentID   string `json:"parentId,omitempty" bson:"parentId,omitempty"`
+	RootID     string `json:"rootId,omitempty" bson:"rootId,omitempty"`
+	ReplyCount int    `json:"replyCount" bson:"replyCount"`
+	// RepostOf is the post shared as is, QuoteOf is the post shared with the content as commentary
+	RepostOf    string `json:"repostOf,omitempty" bson:"repostOf,omitempty"`
+	QuoteOf     string `json:"quoteOf,omitempty" bson:"quoteOf,omitempty"`
+	RepostCount int    `json:"repostCount" bson:"repostCount"`
+	QuoteCount  int    `json:"quoteCount" bson:"quoteCount"`
+	// Original is the reposted or quoted post, filled when the post is read
+	Original *Post `json:"original,omitempty" bson:"-"`
+	// Reactions counts the reactions on the post by type, OwnReactions are the ones of the reader
+	Reactions    map[string]int `json:"reactions,omitempty" bson:"reactions,omitempty"`
+	OwnReactions []string       `json:"ownReactions,omitempty" bson:"-"`
+	// LegacyLikes are the likes given before who liked was recorded, LegacyUnliked the users who took one back
+	LegacyLikes   int      `json:"-" bson:"legacyLikes,omitempty"`
+	LegacyUnliked []string `json:"-" bson:"legacyUnliked,omitempty"`
+	// Poll is an optional question the readers vote on
+	Poll *Poll `json:"poll,omitempty" bson:"poll,omitempty"`
+	// Pinned marks the posts the author pinned to the profile, filled when the author's posts are listed
+	Pinned bool `json:"pinned,omitempty" bson:"-"`
+	// Deleted marks a tombstone, a deleted post kept without its content so its replies aren't orphaned
+	Deleted bool `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	// Edited tells the post was updated after being created, its previous versions are kept as revisions
+	Edited    bool `json:"edited,omitempty" bson:"edited,omitempty"`
+	EditCount int  `json:"editCount,omitempty" bson:"editCount,omitempty"`
+	// Status is set while the post isn't published, PublishAt is when a scheduled post will be
+	Status    string     `json:"status,omitempty" bson:"status,omitempty"`
+	PublishAt *time.Time `json:"publishAt,omitempty" bson:"publishAt,omitempty"`
+	// DeletedAt is when the post was moved to the trash, DeletedWithAuthor tells it went along with the account
+	DeletedAt         *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedWithAuthor bool       `json:"-" bson:"deletedWithAuthor,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt,omitempty" bson:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt,omitempty" bson:"updatedAt"`
 }
 
 func (post *Post) Prepare() error {
@@ -26,7 +83,12 @@ func (post *Post) Prepare() error {
 }
 
 func (post *Post) validate() error {
-	if post.Title == "" {
+	if post.ParentID != "" && post.QuoteOf != "" {
+		return errors.New("A post can't be a reply and a quote at the same time")
+	}
+
+	// replies and quotes refer to another post, so only standalone posts need a title
+	if post.Title == "" && post.ParentID == "" && post.QuoteOf == "" {
 		return errors.New("The title is required and can't be empty")
 	}
 
@@ -34,10 +96,102 @@ func (post *Post) validate() error {
 		return errors.New("The content is required and can't be empty")
 	}
 
+	if len(post.MediaIDs) > maxMediaPerPost {
+		return errors.New("A post can't have more than 4 media")
+	}
+
+	switch post.Visibility {
+	case "", VisibilityPublic, VisibilityFollowers, VisibilityMentioned, VisibilityUnlisted:
+	default:
+		return errors.New("The visibility must be public, followers, mentioned or unlisted")
+	}
+
+	switch post.Status {
+	case "":
+	case PostStatusDraft, PostStatusScheduled:
+		if post.ParentID != "" || post.QuoteOf != "" {
+			return errors.New("Only standalone posts can be drafts or scheduled")
+		}
+	default:
+		return errors.New("The status must be draft or scheduled")
+	}
+
+	if post.Status == PostStatusScheduled && (post.PublishAt == nil || !post.PublishAt.After(time.Now())) {
+		return errors.New("A scheduled post needs a publishAt time in the future")
+	}
+
+	if post.Status != PostStatusScheduled && post.PublishAt != nil {
+		return errors.New("Only scheduled posts can have a publishAt time")
+	}
+
+	if post.Poll != nil {
+		opensAt := time.Now()
+		if post.PublishAt != nil {
+			opensAt = *post.PublishAt
+		}
+
+		if err := post.Poll.Prepare(opensAt); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (post *Post) format() {
 	post.Title = strings.TrimSpace(post.Title)
 	post.Content = strings.TrimSpace(post.Content)
+	post.ParentID = strings.TrimSpace(post.ParentID)
+	post.QuoteOf = strings.TrimSpace(post.QuoteOf)
+	if post.Visibility == "" {
+		post.Visibility = VisibilityPublic
+	}
+	post.Tags = ExtractHashtags(post.Content)
+	post.Mentions = ExtractMentions(post.Content)
+
+	mediaIDs := []string{}
+	seen := map[string]bool{}
+	for _, mediaID := range post.MediaIDs {
+		mediaID = strings.TrimSpace(mediaID)
+		if mediaID == "" || seen[mediaID] {
+			continue
+		}
+		seen[mediaID] = true
+		mediaIDs = append(mediaIDs, mediaID)
+	}
+	post.MediaIDs = mediaIDs
+}
+
+// Published tells if the post is out of the drafts and the schedule
+func (post *Post) Published() bool {
+	return post.Status == ""
+}
+
+// ChangedFrom tells if editing previous into the post changes its title, content, media or visibility,
+// an edit that doesn't is neither marked nor kept as a revision
+func (post *Post) ChangedFrom(previous Post) bool {
+	previousVisibility := previous.Visibility
+	if previousVisibility == "" {
+		previousVisibility = VisibilityPublic
+	}
+
+	if post.Title != previous.Title || post.Content != previous.Content || post.Visibility != previousVisibility {
+		return true
+	}
+
+	if len(post.MediaIDs) != len(previous.MediaIDs) {
+		return true
+	}
+	for i := range post.MediaIDs {
+		if post.MediaIDs[i] != previous.MediaIDs[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadableByAnyone tells if the visibility lets anyone read the post
+func (post *Post) ReadableByAnyone() bool {
+	return post.Visibility == "" || post.Visibility == VisibilityPublic || post.Visibility == VisibilityUnlisted
 }
@@ -0,0 +1,10 @@
+package entities
+
+import "time"
+
+// PostLike records who liked a post, so the like can be undone by them or when their account is deleted
+type PostLike struct {
+	PostID    string    `json:"postId" bson:"postId"`
+	Nick      string    `json:"nick" bson:"nick"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// PostReaction records the reaction of a user on a post, a user reacts once with each type on a post
+type PostReaction struct {
+	PostID    string    `json:"postId" bson:"postId"`
+	Nick      string    `json:"nick" bson:"nick"`
+	Type      string    `json:"type" bson:"type"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// PostRevision is a previous version of an edited post, kept from when it was written until it was replaced
+type PostRevision struct {
+	ID         string    `json:"id,omitempty" bson:"_id,omitempty"`
+	PostID     string    `json:"postId" bson:"postId"`
+	Title      string    `json:"title,omitempty" bson:"title,omitempty"`
+	Content    string    `json:"content" bson:"content"`
+	MediaIDs   []string  `json:"mediaIds,omitempty" bson:"mediaIds,omitempty"`
+	WrittenAt  time.Time `json:"writtenAt" bson:"writtenAt"`
+	ReplacedAt time.Time `json:"replacedAt" bson:"replacedAt"`
+}
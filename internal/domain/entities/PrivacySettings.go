@@ -0,0 +1,7 @@
+package entities
+
+type PrivacySettings struct {
+	Private bool `json:"private" bson:"private"`
+	// DMFollowingOnly only accepts direct messages from the users being followed
+	DMFollowingOnly bool `json:"dmFollowingOnly" bson:"dmFollowingOnly"`
+}
@@ -0,0 +1,13 @@
+package entities
+
+// Relationship describes how the viewer relates to another user
+type Relationship struct {
+	Nick        string `json:"nick"`
+	Following   bool   `json:"following"`
+	FollowedBy  bool   `json:"followedBy"`
+	Blocking    bool   `json:"blocking"`
+	BlockedBy   bool   `json:"blockedBy"`
+	Muting      bool   `json:"muting"`
+	Requested   bool   `json:"requested"`
+	RequestedBy bool   `json:"requestedBy"`
+}
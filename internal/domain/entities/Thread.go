@@ -0,0 +1,12 @@
+package entities
+
+// Thread is a post inside its conversation, with the posts it replies and the replies it got
+type Thread struct {
+	Ancestors []Post     `json:"ancestors"`
+	Post      ThreadNode `json:"post"`
+}
+
+type ThreadNode struct {
+	Post
+	Replies []ThreadNode `json:"replies"`
+}
@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+// TagBucketSize is the time span the activity of a tag is counted together
+const TagBucketSize = 5 * time.Minute
+
+// activities recorded for the tags of a post, each one is a counter of TagStats
+const (
+	ActivityPost   = "posts"
+	ActivityLike   = "likes"
+	ActivityReply  = "replies"
+	ActivityRepost = "reposts"
+)
+
+// TagStats counts the activity of a hashtag during one time bucket
+type TagStats struct {
+	Tag     string    `json:"tag" bson:"tag"`
+	Bucket  time.Time `json:"bucket" bson:"bucket"`
+	Posts   int       `json:"posts" bson:"posts"`
+	Likes   int       `json:"likes" bson:"likes"`
+	Replies int       `json:"replies" bson:"replies"`
+	Reposts int       `json:"reposts" bson:"reposts"`
+}
+
+type Trend struct {
+	Tag        string  `json:"tag"`
+	Score      float64 `json:"score"`
+	Posts      int     `json:"posts"`
+	Engagement int     `json:"engagement"`
+}
+
+// TagBucket returns the start of the bucket the time belongs to
+func TagBucket(at time.Time) time.Time {
+	return at.UTC().Truncate(TagBucketSize)
+}
@@ -2,24 +2,61 @@ package entities
 
 import (
 	"errors"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"api/internal/domain/security"
 
 	"github.com/badoux/checkmail"
 )
 
+const (
+	maxBioLength       = 160
+	maxLocationLength  = 30
+	maxPronounsLength  = 20
+	maxURLLength       = 200
+	maxTechStackTags   = 10
+	maxTechStackTagLen = 25
+)
+
+var techStackTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.\-]*$`)
+
 type User struct {
 	ID        uint64    `json:"id,omitempty" bson:"id"`
 	Name      string    `json:"name,omitempty" bson:"name"`
 	Nick      string    `json:"nick,omitempty" bson:"nick"`
+	NickKey   string    `json:"-" bson:"nickKey,omitempty"`
 	Email     string    `json:"email,omitempty" bson:"email"`
 	Password  string    `json:"password,omitempty" bson:"password"`
+	Bio       string    `json:"bio,omitempty" bson:"bio,omitempty"`
+	Location  string    `json:"location,omitempty" bson:"location,omitempty"`
+	Website   string    `json:"website,omitempty" bson:"website,omitempty"`
+	Pronouns  string    `json:"pronouns,omitempty" bson:"pronouns,omitempty"`
+	AvatarURL string    `json:"avatarUrl,omitempty" bson:"avatarUrl,omitempty"`
+	BannerURL string    `json:"bannerUrl,omitempty" bson:"bannerUrl,omitempty"`
+	AvatarID  string    `json:"avatarId,omitempty" bson:"avatarId,omitempty"`
+	BannerID  string    `json:"bannerId,omitempty" bson:"bannerId,omitempty"`
+	TechStack []string  `json:"techStack,omitempty" bson:"techStack,omitempty"`
 	CreatedAt time.Time `json:"createdAt,omitempty" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt,omitempty" bson:"updatedAt"`
 	Followers []string  `json:"followers" bson:"followers"`
 	Following []string  `json:"following" bson:"following"`
+	Private   bool      `json:"private" bson:"private"`
+	// PinnedPosts are the posts highlighted on the profile, the last pinned first
+	PinnedPosts []string `json:"pinnedPosts,omitempty" bson:"pinnedPosts,omitempty"`
+	// DMFollowingOnly only accepts direct messages from the users being followed
+	DMFollowingOnly bool `json:"dmFollowingOnly" bson:"dmFollowingOnly,omitempty"`
+	// blocks, mutes and pending follow requests are only shown to the user itself, on their own endpoints
+	Blocked        []string `json:"-" bson:"blocked,omitempty"`
+	Muted          []string `json:"-" bson:"muted,omitempty"`
+	FollowRequests []string `json:"-" bson:"followRequests,omitempty"`
+	// DisabledNotifications are the kinds of notification the user turned off
+	DisabledNotifications []string `json:"-" bson:"disabledNotifications,omitempty"`
+	// DeletedAt is when the account was deleted, it can be restored until the trash retention passes
+	DeletedAt *time.Time `json:"-" bson:"deletedAt,omitempty"`
 }
 
 func (user *User) Prepare(step string) error {
@@ -34,24 +71,70 @@ func (user *User) Prepare(step string) error {
 }
 
 func (user *User) validate(step string) error {
-	if user.Name == "" {
+	// on updates every field is optional, only the ones sent are validated
+	isUpdate := step == "updateUser"
+
+	if user.Name == "" && !isUpdate {
 		return errors.New("The name is required and can't be empty")
 	}
 
-	if user.Nick == "" {
+	if user.Nick == "" && !isUpdate {
 		return errors.New("The nick is required and can't be empty")
 	}
 
-	if user.Email == "" {
-
+	if user.Email == "" && !isUpdate {
 		return errors.New("The email is required and can't be empty")
 	}
+
+	if user.Nick != "" {
+		if err := ValidateNick(user.Nick); err != nil {
+			return err
+		}
+	}
+
 	if step == "createUser" && user.Password == "" {
 		return errors.New("The password is required and can't be empty")
 	}
 
-	if err := checkmail.ValidateFormat(user.Email); err != nil {
-		return errors.New("The inserted email is invalid")
+	if user.Email != "" {
+		if err := checkmail.ValidateFormat(user.Email); err != nil {
+			return errors.New("The inserted email is invalid")
+		}
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(user.Bio)) > maxBioLength {
+		return errors.New("The bio can't be longer than 160 characters")
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(user.Location)) > maxLocationLength {
+		return errors.New("The location can't be longer than 30 characters")
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(user.Pronouns)) > maxPronounsLength {
+		return errors.New("The pronouns can't be longer than 20 characters")
+	}
+
+	if err := validateURL(user.Website, "website"); err != nil {
+		return err
+	}
+
+	if err := validateURL(user.AvatarURL, "avatar"); err != nil {
+		return err
+	}
+
+	if err := validateURL(user.BannerURL, "banner"); err != nil {
+		return err
+	}
+
+	if len(user.TechStack) > maxTechStackTags {
+		return errors.New("The tech stack can't have more than 10 tags")
+	}
+
+	for _, tag := range user.TechStack {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTechStackTagLen || !techStackTagPattern.MatchString(tag) {
+			return errors.New("The tech stack tag " + tag + " is invalid")
+		}
 	}
 
 	return nil
@@ -59,8 +142,17 @@ func (user *User) validate(step string) error {
 
 func (user *User) format(step string) error {
 	user.Name = strings.TrimSpace(user.Name)
-	user.Nick = strings.TrimSpace(user.Nick)
+	user.Nick = NormalizeNick(user.Nick)
 	user.Email = strings.TrimSpace(user.Email)
+	user.Bio = strings.TrimSpace(user.Bio)
+	user.Location = strings.TrimSpace(user.Location)
+	user.Website = strings.TrimSpace(user.Website)
+	user.Pronouns = strings.TrimSpace(user.Pronouns)
+	user.AvatarURL = strings.TrimSpace(user.AvatarURL)
+	user.BannerURL = strings.TrimSpace(user.BannerURL)
+	user.AvatarID = strings.TrimSpace(user.AvatarID)
+	user.BannerID = strings.TrimSpace(user.BannerID)
+	user.TechStack = formatTechStack(user.TechStack)
 
 	if step == "createUser" {
 		passwordHash, err := security.Hash(user.Password)
@@ -72,3 +164,40 @@ func (user *User) format(step string) error {
 	}
 	return nil
 }
+
+func validateURL(rawURL string, field string) error {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil
+	}
+
+	if len(rawURL) > maxURLLength {
+		return errors.New("The " + field + " URL is too long")
+	}
+
+	parsedURL, err := url.ParseRequestURI(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return errors.New("The " + field + " URL is invalid")
+	}
+
+	return nil
+}
+
+func formatTechStack(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	formatted := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		formatted = append(formatted, tag)
+	}
+
+	return formatted
+}
@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// UserSummary is the short version of a user returned on listings like search results
+type UserSummary struct {
+	Nick             string    `json:"nick" bson:"nick"`
+	NickKey          string    `json:"-" bson:"nickKey"`
+	Name             string    `json:"name" bson:"name"`
+	Bio              string    `json:"bio,omitempty" bson:"bio"`
+	AvatarID         string    `json:"avatarId,omitempty" bson:"avatarId"`
+	AvatarURL        string    `json:"avatarUrl,omitempty" bson:"avatarUrl"`
+	TechStack        []string  `json:"techStack,omitempty" bson:"techStack"`
+	Private          bool      `json:"private" bson:"private"`
+	FollowersCount   int       `json:"followersCount" bson:"followersCount"`
+	FollowedByViewer bool      `json:"followedByViewer" bson:"followedByViewer"`
+	MutualCount      int       `json:"mutualCount,omitempty" bson:"mutualCount"`
+	LastPostAt       time.Time `json:"-" bson:"lastPostAt"`
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+)
+
+// UserUpdate is a partial update of the profile. A field left out stays as it is and a field sent empty is
+// cleared, apart from the name and the email that can't be empty. Nick is only accepted when it's the
+// current one, it's changed through its own endpoint
+type UserUpdate struct {
+	Name      *string  `json:"name"`
+	Nick      *string  `json:"nick"`
+	Email     *string  `json:"email"`
+	Bio       *string  `json:"bio"`
+	Location  *string  `json:"location"`
+	Website   *string  `json:"website"`
+	Pronouns  *string  `json:"pronouns"`
+	AvatarURL *string  `json:"avatarUrl"`
+	BannerURL *string  `json:"bannerUrl"`
+	AvatarID  *string  `json:"avatarId"`
+	BannerID  *string  `json:"bannerId"`
+	TechStack []string `json:"techStack"`
+}
+
+// Prepare validates and formats the fields sent, with the same rules as the profile itself
+func (update *UserUpdate) Prepare() error {
+	for _, field := range []*string{update.Name, update.Email, update.Bio, update.Location, update.Website,
+		update.Pronouns, update.AvatarURL, update.BannerURL, update.AvatarID, update.BannerID} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+
+	if update.Name != nil && *update.Name == "" {
+		return errors.New("The name can't be empty")
+	}
+
+	if update.Email != nil && *update.Email == "" {
+		return errors.New("The email can't be empty")
+	}
+
+	user := User{
+		Name:      valueOf(update.Name),
+		Email:     valueOf(update.Email),
+		Bio:       valueOf(update.Bio),
+		Location:  valueOf(update.Location),
+		Website:   valueOf(update.Website),
+		Pronouns:  valueOf(update.Pronouns),
+		AvatarURL: valueOf(update.AvatarURL),
+		BannerURL: valueOf(update.BannerURL),
+		TechStack: update.TechStack,
+	}
+	if err := user.validate("updateUser"); err != nil {
+		return err
+	}
+
+	update.TechStack = formatTechStack(update.TechStack)
+
+	return nil
+}
+
+func valueOf(field *string) string {
+	if field == nil {
+		return ""
+	}
+
+	return *field
+}
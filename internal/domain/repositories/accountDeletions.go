@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"time"
+)
+
+type AccountDeletionsRepository interface {
+	Schedule(before time.Time, limit int) (int, error)
+	GetPending(limit int) ([]entities.AccountDeletion, error)
+	Advance(nick string, step string) error
+	Fail(nick string, reason string) error
+	Complete(nick string) error
+}
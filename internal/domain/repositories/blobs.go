@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Get and GetStream when nothing is saved under the key
+var ErrBlobNotFound = errors.New("This file doesn't exists")
+
+// BlobStore keeps the raw bytes of uploaded files, the metadata lives on MediaRepository
+type BlobStore interface {
+	Put(key string, contentType string, data []byte) error
+	// PutStream saves the size bytes read from data without holding them all in memory
+	PutStream(key string, contentType string, data io.ReadSeeker, size int64) error
+	Get(key string) ([]byte, error)
+	// GetStream opens the blob to be read without holding it all in memory, along with its size. The caller
+	// closes it
+	GetStream(key string) (io.ReadCloser, int64, error)
+	Delete(key string) error
+}
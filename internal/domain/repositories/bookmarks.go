@@ -0,0 +1,15 @@
+package repositories
+
+import "api/internal/domain/entities"
+
+type BookmarksRepository interface {
+	Bookmark(postID string, nick string, collectionID string) error
+	RemoveBookmark(postID string, nick string) error
+	GetBookmarks(nick string, collectionID string, page int, limit int) ([]entities.Bookmark, error)
+	CreateCollection(collection entities.BookmarkCollection) (entities.BookmarkCollection, error)
+	GetCollection(collectionID string) (entities.BookmarkCollection, error)
+	GetCollections(nick string) ([]entities.BookmarkCollection, error)
+	RenameCollection(collectionID string, name string) error
+	DeleteCollection(collectionID string) error
+	DeleteUserBookmarks(nick string) error
+}
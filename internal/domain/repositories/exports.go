@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"time"
+)
+
+type ExportsRepository interface {
+	CreateExport(nick string) (entities.DataExport, error)
+	GetExport(exportID string) (entities.DataExport, error)
+	GetExports(nick string) ([]entities.DataExport, error)
+	ClaimExport(owner string, lease time.Duration) (entities.DataExport, bool, error)
+	CompleteExport(exportID string, owner string, key string, size int, expiresAt time.Time) error
+	FailExport(exportID string, owner string, reason string) error
+	GetExpiredExports(now time.Time, limit int) ([]entities.DataExport, error)
+	ExpireExport(exportID string) error
+	DeleteExport(exportID string) error
+}
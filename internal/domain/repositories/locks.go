@@ -0,0 +1,8 @@
+package repositories
+
+import "time"
+
+type LocksRepository interface {
+	Acquire(name string, owner string, ttl time.Duration) (bool, error)
+	Release(name string, owner string) error
+}
@@ -0,0 +1,10 @@
+package repositories
+
+import "api/internal/domain/entities"
+
+type MediaRepository interface {
+	CreateMedia(media entities.Media) (entities.Media, error)
+	GetMedia(mediaID string) (entities.Media, error)
+	DeleteMedia(mediaID string) error
+	GetMediaByOwner(nick string, page int, limit int) ([]entities.Media, error)
+}
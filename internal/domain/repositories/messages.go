@@ -0,0 +1,16 @@
+package repositories
+
+import "api/internal/domain/entities"
+
+type MessagesRepository interface {
+	CreateConversation(conversation entities.Conversation) (entities.Conversation, error)
+	GetConversation(conversationID string) (entities.Conversation, error)
+	GetConversationByKey(key string) (entities.Conversation, error)
+	GetConversations(nick string, hiddenSenders []string, page int, limit int) ([]entities.Conversation, error)
+	AddMessage(message entities.Message, blockedBy []string) (entities.Message, error)
+	GetMessages(conversationID string, nick string, hiddenSenders []string, page int, limit int) ([]entities.Message, error)
+	MarkConversationRead(conversationID string, nick string) error
+	DeleteMessage(conversationID string, messageID string, nick string) error
+	DeleteConversation(conversationID string, nick string) error
+	RemoveParticipant(nick string) error
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AccountDeletionsRepositoryMock struct {
+	mock.Mock
+}
+
+func NewAccountDeletionsRepositoryMock() *AccountDeletionsRepositoryMock {
+	return &AccountDeletionsRepositoryMock{}
+}
+
+func (repository *AccountDeletionsRepositoryMock) Schedule(before time.Time, limit int) (int, error) {
+	args := repository.Called(before, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *AccountDeletionsRepositoryMock) GetPending(limit int) ([]entities.AccountDeletion, error) {
+	args := repository.Called(limit)
+	return args.Get(0).([]entities.AccountDeletion), args.Error(1)
+}
+
+func (repository *AccountDeletionsRepositoryMock) Advance(nick string, step string) error {
+	args := repository.Called(nick, step)
+	return args.Error(0)
+}
+
+func (repository *AccountDeletionsRepositoryMock) Fail(nick string, reason string) error {
+	args := repository.Called(nick, reason)
+	return args.Error(0)
+}
+
+func (repository *AccountDeletionsRepositoryMock) Complete(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
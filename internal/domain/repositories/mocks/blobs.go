@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type BlobStoreMock struct {
+	mock.Mock
+}
+
+func NewBlobStoreMock() *BlobStoreMock {
+	return &BlobStoreMock{}
+}
+
+func (store *BlobStoreMock) Put(key string, contentType string, data []byte) error {
+	args := store.Called(key, contentType, data)
+	return args.Error(0)
+}
+
+func (store *BlobStoreMock) PutStream(key string, contentType string, data io.ReadSeeker, size int64) error {
+	args := store.Called(key, contentType, data, size)
+	return args.Error(0)
+}
+
+func (store *BlobStoreMock) Get(key string) ([]byte, error) {
+	args := store.Called(key)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (store *BlobStoreMock) GetStream(key string) (io.ReadCloser, int64, error) {
+	args := store.Called(key)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(int64), args.Error(2)
+}
+
+func (store *BlobStoreMock) Delete(key string) error {
+	args := store.Called(key)
+	return args.Error(0)
+}
@@ -0,0 +1,60 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type BookmarksRepositoryMock struct {
+	mock.Mock
+}
+
+func NewBookmarksRepositoryMock() *BookmarksRepositoryMock {
+	return &BookmarksRepositoryMock{}
+}
+
+func (repository *BookmarksRepositoryMock) Bookmark(postID string, nick string, collectionID string) error {
+	args := repository.Called(postID, nick, collectionID)
+	return args.Error(0)
+}
+
+func (repository *BookmarksRepositoryMock) RemoveBookmark(postID string, nick string) error {
+	args := repository.Called(postID, nick)
+	return args.Error(0)
+}
+
+func (repository *BookmarksRepositoryMock) GetBookmarks(nick string, collectionID string, page int, limit int) ([]entities.Bookmark, error) {
+	args := repository.Called(nick, collectionID, page, limit)
+	return args.Get(0).([]entities.Bookmark), args.Error(1)
+}
+
+func (repository *BookmarksRepositoryMock) CreateCollection(collection entities.BookmarkCollection) (entities.BookmarkCollection, error) {
+	args := repository.Called(collection)
+	return args.Get(0).(entities.BookmarkCollection), args.Error(1)
+}
+
+func (repository *BookmarksRepositoryMock) GetCollection(collectionID string) (entities.BookmarkCollection, error) {
+	args := repository.Called(collectionID)
+	return args.Get(0).(entities.BookmarkCollection), args.Error(1)
+}
+
+func (repository *BookmarksRepositoryMock) GetCollections(nick string) ([]entities.BookmarkCollection, error) {
+	args := repository.Called(nick)
+	return args.Get(0).([]entities.BookmarkCollection), args.Error(1)
+}
+
+func (repository *BookmarksRepositoryMock) RenameCollection(collectionID string, name string) error {
+	args := repository.Called(collectionID, name)
+	return args.Error(0)
+}
+
+func (repository *BookmarksRepositoryMock) DeleteCollection(collectionID string) error {
+	args := repository.Called(collectionID)
+	return args.Error(0)
+}
+
+func (repository *BookmarksRepositoryMock) DeleteUserBookmarks(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ExportsRepositoryMock struct {
+	mock.Mock
+}
+
+func NewExportsRepositoryMock() *ExportsRepositoryMock {
+	return &ExportsRepositoryMock{}
+}
+
+func (repository *ExportsRepositoryMock) CreateExport(nick string) (entities.DataExport, error) {
+	args := repository.Called(nick)
+	return args.Get(0).(entities.DataExport), args.Error(1)
+}
+
+func (repository *ExportsRepositoryMock) GetExport(exportID string) (entities.DataExport, error) {
+	args := repository.Called(exportID)
+	return args.Get(0).(entities.DataExport), args.Error(1)
+}
+
+func (repository *ExportsRepositoryMock) GetExports(nick string) ([]entities.DataExport, error) {
+	args := repository.Called(nick)
+	return args.Get(0).([]entities.DataExport), args.Error(1)
+}
+
+func (repository *ExportsRepositoryMock) ClaimExport(owner string, lease time.Duration) (entities.DataExport, bool, error) {
+	args := repository.Called(owner, lease)
+	return args.Get(0).(entities.DataExport), args.Bool(1), args.Error(2)
+}
+
+func (repository *ExportsRepositoryMock) CompleteExport(exportID string, owner string, key string, size int, expiresAt time.Time) error {
+	args := repository.Called(exportID, owner, key, size, expiresAt)
+	return args.Error(0)
+}
+
+func (repository *ExportsRepositoryMock) FailExport(exportID string, owner string, reason string) error {
+	args := repository.Called(exportID, owner, reason)
+	return args.Error(0)
+}
+
+func (repository *ExportsRepositoryMock) GetExpiredExports(now time.Time, limit int) ([]entities.DataExport, error) {
+	args := repository.Called(now, limit)
+	return args.Get(0).([]entities.DataExport), args.Error(1)
+}
+
+func (repository *ExportsRepositoryMock) ExpireExport(exportID string) error {
+	args := repository.Called(exportID)
+	return args.Error(0)
+}
+
+func (repository *ExportsRepositoryMock) DeleteExport(exportID string) error {
+	args := repository.Called(exportID)
+	return args.Error(0)
+}
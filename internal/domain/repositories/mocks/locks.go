@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LocksRepositoryMock struct {
+	mock.Mock
+}
+
+func NewLocksRepositoryMock() *LocksRepositoryMock {
+	return &LocksRepositoryMock{}
+}
+
+func (repository *LocksRepositoryMock) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	args := repository.Called(name, owner, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (repository *LocksRepositoryMock) Release(name string, owner string) error {
+	args := repository.Called(name, owner)
+	return args.Error(0)
+}
@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MediaRepositoryMock struct {
+	mock.Mock
+}
+
+func NewMediaRepositoryMock() *MediaRepositoryMock {
+	return &MediaRepositoryMock{}
+}
+
+func (repository *MediaRepositoryMock) CreateMedia(media entities.Media) (entities.Media, error) {
+	args := repository.Called(media)
+	return args.Get(0).(entities.Media), args.Error(1)
+}
+
+func (repository *MediaRepositoryMock) GetMedia(mediaID string) (entities.Media, error) {
+	args := repository.Called(mediaID)
+	return args.Get(0).(entities.Media), args.Error(1)
+}
+
+func (repository *MediaRepositoryMock) DeleteMedia(mediaID string) error {
+	args := repository.Called(mediaID)
+	return args.Error(0)
+}
+
+func (repository *MediaRepositoryMock) GetMediaByOwner(nick string, page int, limit int) ([]entities.Media, error) {
+	args := repository.Called(nick, page, limit)
+	return args.Get(0).([]entities.Media), args.Error(1)
+}
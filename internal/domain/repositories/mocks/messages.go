@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MessagesRepositoryMock struct {
+	mock.Mock
+}
+
+func NewMessagesRepositoryMock() *MessagesRepositoryMock {
+	return &MessagesRepositoryMock{}
+}
+
+func (repository *MessagesRepositoryMock) CreateConversation(conversation entities.Conversation) (entities.Conversation, error) {
+	args := repository.Called(conversation)
+	return args.Get(0).(entities.Conversation), args.Error(1)
+}
+
+func (repository *MessagesRepositoryMock) GetConversation(conversationID string) (entities.Conversation, error) {
+	args := repository.Called(conversationID)
+	return args.Get(0).(entities.Conversation), args.Error(1)
+}
+
+func (repository *MessagesRepositoryMock) GetConversationByKey(key string) (entities.Conversation, error) {
+	args := repository.Called(key)
+	return args.Get(0).(entities.Conversation), args.Error(1)
+}
+
+func (repository *MessagesRepositoryMock) GetConversations(nick string, hiddenSenders []string, page int, limit int) ([]entities.Conversation, error) {
+	args := repository.Called(nick, hiddenSenders, page, limit)
+	return args.Get(0).([]entities.Conversation), args.Error(1)
+}
+
+func (repository *MessagesRepositoryMock) AddMessage(message entities.Message, blockedBy []string) (entities.Message, error) {
+	args := repository.Called(message, blockedBy)
+	return args.Get(0).(entities.Message), args.Error(1)
+}
+
+func (repository *MessagesRepositoryMock) GetMessages(conversationID string, nick string, hiddenSenders []string, page int, limit int) ([]entities.Message, error) {
+	args := repository.Called(conversationID, nick, hiddenSenders, page, limit)
+	return args.Get(0).([]entities.Message), args.Error(1)
+}
+
+func (repository *MessagesRepositoryMock) MarkConversationRead(conversationID string, nick string) error {
+	args := repository.Called(conversationID, nick)
+	return args.Error(0)
+}
+
+func (repository *MessagesRepositoryMock) DeleteMessage(conversationID string, messageID string, nick string) error {
+	args := repository.Called(conversationID, messageID, nick)
+	return args.Error(0)
+}
+
+func (repository *MessagesRepositoryMock) DeleteConversation(conversationID string, nick string) error {
+	args := repository.Called(conversationID, nick)
+	return args.Error(0)
+}
+
+func (repository *MessagesRepositoryMock) RemoveParticipant(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
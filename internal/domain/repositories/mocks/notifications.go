@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type NotificationsRepositoryMock struct {
+	mock.Mock
+}
+
+func NewNotificationsRepositoryMock() *NotificationsRepositoryMock {
+	return &NotificationsRepositoryMock{}
+}
+
+func (repository *NotificationsRepositoryMock) AddNotification(notification entities.Notification) error {
+	args := repository.Called(notification)
+	return args.Error(0)
+}
+
+func (repository *NotificationsRepositoryMock) GetNotifications(recipient string, page int, limit int) ([]entities.Notification, error) {
+	args := repository.Called(recipient, page, limit)
+	return args.Get(0).([]entities.Notification), args.Error(1)
+}
+
+func (repository *NotificationsRepositoryMock) CountUnread(recipient string) (int, error) {
+	args := repository.Called(recipient)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *NotificationsRepositoryMock) MarkRead(recipient string, ids []string) error {
+	args := repository.Called(recipient, ids)
+	return args.Error(0)
+}
+
+func (repository *NotificationsRepositoryMock) MarkAllRead(recipient string) error {
+	args := repository.Called(recipient)
+	return args.Error(0)
+}
+
+func (repository *NotificationsRepositoryMock) DeleteUserNotifications(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
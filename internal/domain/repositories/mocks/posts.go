@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"api/internal/domain/entities"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -19,8 +20,8 @@ func (repository *PostsRepositoryMock) CreatePost(post entities.Post) (entities.
 	return args.Get(0).(entities.Post), args.Error(1)
 }
 
-func (repository *PostsRepositoryMock) GetPosts(nick string) ([]entities.Post, error) {
-	args := repository.Called(nick)
+func (repository *PostsRepositoryMock) GetPosts(nick string, viewerNick string) ([]entities.Post, error) {
+	args := repository.Called(nick, viewerNick)
 	return args.Get(0).([]entities.Post), args.Error(1)
 }
 
@@ -29,6 +30,121 @@ func (repository *PostsRepositoryMock) GetPostWithId(id string) (entities.Post,
 	return args.Get(0).(entities.Post), args.Error(1)
 }
 
+func (repository *PostsRepositoryMock) GetRevisions(postID string, page int, limit int) ([]entities.PostRevision, error) {
+	args := repository.Called(postID, page, limit)
+	return args.Get(0).([]entities.PostRevision), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetAuthorPosts(nick string, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(nick, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetDrafts(nick string, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(nick, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) UpdateDraft(postID string, updatedPost entities.Post) error {
+	args := repository.Called(postID, updatedPost)
+	return args.Error(0)
+}
+
+func (repository *PostsRepositoryMock) PublishPost(postID string) (entities.Post, error) {
+	args := repository.Called(postID)
+	return args.Get(0).(entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) PublishDuePosts(now time.Time, limit int) ([]entities.Post, error) {
+	args := repository.Called(now, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetTrash(nick string, since time.Time, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(nick, since, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) RestorePost(postID string, nick string, since time.Time) error {
+	args := repository.Called(postID, nick, since)
+	return args.Error(0)
+}
+
+func (repository *PostsRepositoryMock) PurgeTrashedPosts(before time.Time, limit int) (int, error) {
+	args := repository.Called(before, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetLikesBy(nick string, page int, limit int) ([]entities.PostLike, error) {
+	args := repository.Called(nick, page, limit)
+	return args.Get(0).([]entities.PostLike), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) RemoveLikesBy(nick string, limit int) (int, error) {
+	args := repository.Called(nick, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) React(postID string, nick string, reaction string) error {
+	args := repository.Called(postID, nick, reaction)
+	return args.Error(0)
+}
+
+func (repository *PostsRepositoryMock) Unreact(postID string, nick string, reaction string) error {
+	args := repository.Called(postID, nick, reaction)
+	return args.Error(0)
+}
+
+func (repository *PostsRepositoryMock) GetReactions(postID string, reaction string, page int, limit int) ([]entities.PostReaction, error) {
+	args := repository.Called(postID, reaction, page, limit)
+	return args.Get(0).([]entities.PostReaction), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetOwnReactions(nick string, postIDs []string) (map[string][]string, error) {
+	args := repository.Called(nick, postIDs)
+	return args.Get(0).(map[string][]string), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetReactionsBy(nick string, page int, limit int) ([]entities.PostReaction, error) {
+	args := repository.Called(nick, page, limit)
+	return args.Get(0).([]entities.PostReaction), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) RemoveReactionsBy(nick string, limit int) (int, error) {
+	args := repository.Called(nick, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) Vote(postID string, nick string, options []int) error {
+	args := repository.Called(postID, nick, options)
+	return args.Error(0)
+}
+
+func (repository *PostsRepositoryMock) GetPollVotes(nick string, postIDs []string) (map[string][]int, error) {
+	args := repository.Called(nick, postIDs)
+	return args.Get(0).(map[string][]int), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetVotesBy(nick string, page int, limit int) ([]entities.PollVote, error) {
+	args := repository.Called(nick, page, limit)
+	return args.Get(0).([]entities.PollVote), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) RemoveVotesBy(nick string, limit int) (int, error) {
+	args := repository.Called(nick, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) PurgeAuthorPosts(nick string, limit int) (int, error) {
+	args := repository.Called(nick, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetVisiblePost(id string, viewerNick string) (entities.Post, error) {
+	args := repository.Called(id, viewerNick)
+	return args.Get(0).(entities.Post), args.Error(1)
+}
+
 func (repository *PostsRepositoryMock) UpdatePost(postID string, updatedPost entities.Post) error {
 	args := repository.Called(postID, updatedPost)
 	return args.Error(0)
@@ -39,17 +155,67 @@ func (repository *PostsRepositoryMock) DeletePost(postID string) error {
 	return args.Error(0)
 }
 
-func (repository *PostsRepositoryMock) GetAllPosts() ([]entities.Post, error) {
-	args := repository.Called()
+func (repository *PostsRepositoryMock) GetAllPosts(viewerNick string) ([]entities.Post, error) {
+	args := repository.Called(viewerNick)
 	return args.Get(0).([]entities.Post), args.Error(1)
 }
 
-func (repository *PostsRepositoryMock) Like(postID string) error {
-	args := repository.Called(postID)
+func (repository *PostsRepositoryMock) Like(postID string, nick string) error {
+	args := repository.Called(postID, nick)
 	return args.Error(0)
 }
 
-func (repository *PostsRepositoryMock) Dislike(postID string) error {
-	args := repository.Called(postID)
+func (repository *PostsRepositoryMock) Dislike(postID string, nick string) error {
+	args := repository.Called(postID, nick)
+	return args.Error(0)
+}
+
+func (repository *PostsRepositoryMock) GetReplies(postID string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(postID, viewerNick, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetConversation(rootID string, viewerNick string) ([]entities.Post, error) {
+	args := repository.Called(rootID, viewerNick)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) Repost(postID string, nick string) (entities.Post, error) {
+	args := repository.Called(postID, nick)
+	return args.Get(0).(entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) Unrepost(postID string, nick string) error {
+	args := repository.Called(postID, nick)
 	return args.Error(0)
 }
+
+func (repository *PostsRepositoryMock) GetPostsByIDs(ids []string) ([]entities.Post, error) {
+	args := repository.Called(ids)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetBookmarkedPosts(nick string, collectionID string, page int, limit int) ([]entities.BookmarkedPost, error) {
+	args := repository.Called(nick, collectionID, page, limit)
+	return args.Get(0).([]entities.BookmarkedPost), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetFeed(nicks []string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(nicks, viewerNick, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetPostsByTag(tag string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(tag, viewerNick, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) SearchTags(prefix string, limit int) ([]entities.Hashtag, error) {
+	args := repository.Called(prefix, limit)
+	return args.Get(0).([]entities.Hashtag), args.Error(1)
+}
+
+func (repository *PostsRepositoryMock) GetMentions(nick string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	args := repository.Called(nick, viewerNick, page, limit)
+	return args.Get(0).([]entities.Post), args.Error(1)
+}
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"api/internal/domain/entities"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TrendsRepositoryMock struct {
+	mock.Mock
+}
+
+func NewTrendsRepositoryMock() *TrendsRepositoryMock {
+	return &TrendsRepositoryMock{}
+}
+
+func (repository *TrendsRepositoryMock) RecordTagActivity(tags []string, activity string, at time.Time) error {
+	args := repository.Called(tags, activity, at)
+	return args.Error(0)
+}
+
+func (repository *TrendsRepositoryMock) GetTagStats(since time.Time) ([]entities.TagStats, error) {
+	args := repository.Called(since)
+	return args.Get(0).([]entities.TagStats), args.Error(1)
+}
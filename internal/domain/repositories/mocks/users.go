@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"api/internal/domain/entities"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -34,8 +35,8 @@ func (repository *UsersRepositoryMock) GetUserByNick(nick string) (entities.User
 	return args.Get(0).(entities.User), args.Error(1)
 }
 
-func (repository *UsersRepositoryMock) UpdateUser(nick string, user entities.User) error {
-	args := repository.Called(nick, user)
+func (repository *UsersRepositoryMock) UpdateUser(nick string, update entities.UserUpdate) error {
+	args := repository.Called(nick, update)
 	return args.Error(0)
 }
 
@@ -44,6 +45,26 @@ func (repository *UsersRepositoryMock) DeleteUser(nick string) error {
 	return args.Error(0)
 }
 
+func (repository *UsersRepositoryMock) RestoreUser(nick string, since time.Time) error {
+	args := repository.Called(nick, since)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) RemoveReferences(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) ReserveDeletedNick(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) PurgeUser(nick string) error {
+	args := repository.Called(nick)
+	return args.Error(0)
+}
+
 func (repository *UsersRepositoryMock) Follow(followerID string, followedID string) error {
 	args := repository.Called(followedID, followerID)
 	return args.Error(0)
@@ -74,7 +95,127 @@ func (repository *UsersRepositoryMock) UpdatePassword(nick string, password stri
 	return args.Error(0)
 }
 
+func (repository *UsersRepositoryMock) ChangeNick(currentNick string, newNick string) error {
+	args := repository.Called(currentNick, newNick)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) ResumeNickChanges(limit int) (int, error) {
+	args := repository.Called(limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) GetNickHistory(nick string) ([]entities.NickChange, error) {
+	args := repository.Called(nick)
+	return args.Get(0).([]entities.NickChange), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) GetNickRedirect(oldNick string) (string, error) {
+	args := repository.Called(oldNick)
+	return args.Get(0).(string), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) Block(blockerID string, blockedID string) error {
+	args := repository.Called(blockerID, blockedID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) Unblock(unblockerID string, unblockedID string) error {
+	args := repository.Called(unblockerID, unblockedID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) GetBlocked(userID string) ([]string, error) {
+	args := repository.Called(userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) IsBlocked(userID string, otherID string) (bool, error) {
+	args := repository.Called(userID, otherID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) UpdatePrivacy(userID string, settings entities.PrivacySettings) error {
+	args := repository.Called(userID, settings)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) RequestFollow(followerID string, followedID string) error {
+	args := repository.Called(followerID, followedID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) GetFollowRequests(userID string) ([]string, error) {
+	args := repository.Called(userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) AcceptFollowRequest(userID string, requesterID string) error {
+	args := repository.Called(userID, requesterID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) RemoveFollowRequest(userID string, requesterID string) error {
+	args := repository.Called(userID, requesterID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) SearchUsers(viewerID string, query string, limit int) ([]entities.UserSummary, error) {
+	args := repository.Called(viewerID, query, limit)
+	return args.Get(0).([]entities.UserSummary), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) PinPost(nick string, postID string, max int) error {
+	args := repository.Called(nick, postID, max)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) UnpinPost(nick string, postID string) error {
+	args := repository.Called(nick, postID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) Mute(muterID string, mutedID string) error {
+	args := repository.Called(muterID, mutedID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) Unmute(unmuterID string, unmutedID string) error {
+	args := repository.Called(unmuterID, unmutedID)
+	return args.Error(0)
+}
+
+func (repository *UsersRepositoryMock) GetMuted(userID string) ([]string, error) {
+	args := repository.Called(userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) GetSuggestionCandidates(userID string, limit int) ([]entities.UserSummary, error) {
+	args := repository.Called(userID, limit)
+	return args.Get(0).([]entities.UserSummary), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) GetRelationships(viewerID string, nicks []string) ([]entities.Relationship, error) {
+	args := repository.Called(viewerID, nicks)
+	return args.Get(0).([]entities.Relationship), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) GetMutuals(userID string) ([]string, error) {
+	args := repository.Called(userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (repository *UsersRepositoryMock) SecurityMock(passwordSavedOnDb string, newPassword string) error {
 	args := repository.Called(passwordSavedOnDb, newPassword)
 	return args.Error(0)
 }
+
+func (repository *UsersRepositoryMock) GetUsersByNicks(nicks []string) ([]entities.User, error) {
+	args := repository.Called(nicks)
+	return args.Get(0).([]entities.User), args.Error(1)
+}
+
+func (repository *UsersRepositoryMock) UpdateNotificationPreferences(userID string, disabled []string) error {
+	args := repository.Called(userID, disabled)
+	return args.Error(0)
+}
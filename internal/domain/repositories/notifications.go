@@ -0,0 +1,12 @@
+package repositories
+
+import "api/internal/domain/entities"
+
+type NotificationsRepository interface {
+	AddNotification(notification entities.Notification) error
+	GetNotifications(recipient string, page int, limit int) ([]entities.Notification, error)
+	CountUnread(recipient string) (int, error)
+	MarkRead(recipient string, ids []string) error
+	MarkAllRead(recipient string) error
+	DeleteUserNotifications(nick string) error
+}
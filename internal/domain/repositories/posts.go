@@ -1,14 +1,50 @@
 package repositories
 
-import "api/internal/domain/entities"
+import (
+	"api/internal/domain/entities"
+	"time"
+)
 
 type PostsRepository interface {
 	CreatePost(post entities.Post) (entities.Post, error)
-	GetPosts(nick string) ([]entities.Post, error)
+	GetPosts(nick string, viewerNick string) ([]entities.Post, error)
 	GetPostWithId(id string) (entities.Post, error)
+	GetVisiblePost(id string, viewerNick string) (entities.Post, error)
 	UpdatePost(postID string, updatedPost entities.Post) error
+	GetRevisions(postID string, page int, limit int) ([]entities.PostRevision, error)
+	GetDrafts(nick string, page int, limit int) ([]entities.Post, error)
+	GetAuthorPosts(nick string, page int, limit int) ([]entities.Post, error)
+	UpdateDraft(postID string, updatedPost entities.Post) error
+	PublishPost(postID string) (entities.Post, error)
+	PublishDuePosts(now time.Time, limit int) ([]entities.Post, error)
 	DeletePost(postID string) error
-	GetAllPosts() ([]entities.Post, error)
-	Like(postID string) error
-	Dislike(postID string) error
+	GetTrash(nick string, since time.Time, page int, limit int) ([]entities.Post, error)
+	RestorePost(postID string, nick string, since time.Time) error
+	PurgeTrashedPosts(before time.Time, limit int) (int, error)
+	GetAllPosts(viewerNick string) ([]entities.Post, error)
+	Like(postID string, nick string) error
+	Dislike(postID string, nick string) error
+	GetLikesBy(nick string, page int, limit int) ([]entities.PostLike, error)
+	RemoveLikesBy(nick string, limit int) (int, error)
+	React(postID string, nick string, reaction string) error
+	Unreact(postID string, nick string, reaction string) error
+	GetReactions(postID string, reaction string, page int, limit int) ([]entities.PostReaction, error)
+	GetOwnReactions(nick string, postIDs []string) (map[string][]string, error)
+	GetReactionsBy(nick string, page int, limit int) ([]entities.PostReaction, error)
+	RemoveReactionsBy(nick string, limit int) (int, error)
+	Vote(postID string, nick string, options []int) error
+	GetPollVotes(nick string, postIDs []string) (map[string][]int, error)
+	GetVotesBy(nick string, page int, limit int) ([]entities.PollVote, error)
+	RemoveVotesBy(nick string, limit int) (int, error)
+	PurgeAuthorPosts(nick string, limit int) (int, error)
+	GetReplies(postID string, viewerNick string, page int, limit int) ([]entities.Post, error)
+	GetConversation(rootID string, viewerNick string) ([]entities.Post, error)
+	Repost(postID string, nick string) (entities.Post, error)
+	Unrepost(postID string, nick string) error
+	GetPostsByIDs(ids []string) ([]entities.Post, error)
+	GetBookmarkedPosts(nick string, collectionID string, page int, limit int) ([]entities.BookmarkedPost, error)
+	GetFeed(nicks []string, viewerNick string, page int, limit int) ([]entities.Post, error)
+	GetPostsByTag(tag string, viewerNick string, page int, limit int) ([]entities.Post, error)
+	SearchTags(prefix string, limit int) ([]entities.Hashtag, error)
+	GetMentions(nick string, viewerNick string, page int, limit int) ([]entities.Post, error)
 }
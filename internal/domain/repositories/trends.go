@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"time"
+)
+
+type TrendsRepository interface {
+	RecordTagActivity(tags []string, activity string, at time.Time) error
+	GetTagStats(since time.Time) ([]entities.TagStats, error)
+}
@@ -1,18 +1,61 @@
 package repositories
 
-import "api/internal/domain/entities"
+import (
+	"api/internal/domain/entities"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNickTaken is returned when another user has the nick, or one that only looks the same
+	ErrNickTaken = errors.New("there is already a user with the same nick")
+	// ErrNickReserved is returned when the nick was recently released by another user
+	ErrNickReserved = errors.New("This nick was recently used by another user and is reserved")
+	// ErrNickChangePending is returned while the references to the previous nick change are still rewritten
+	ErrNickChangePending = errors.New("The previous nick change is still being applied, try again later")
+	// ErrUserNotFound is returned when there is no account with the nick, or it was deleted
+	ErrUserNotFound = errors.New("This user doesn't exists")
+)
 
 type UsersRepository interface {
 	Create(user entities.User) (entities.User, error)
 	SearchByEmail(email string) (entities.User, error)
 	GetAllUsers() ([]entities.User, error)
 	GetUserByNick(nick string) (entities.User, error)
-	UpdateUser(nick string, user entities.User) error
+	GetUsersByNicks(nicks []string) ([]entities.User, error)
+	UpdateUser(nick string, update entities.UserUpdate) error
 	DeleteUser(nick string) error
+	RestoreUser(nick string, since time.Time) error
+	RemoveReferences(nick string) error
+	ReserveDeletedNick(nick string) error
+	PurgeUser(nick string) error
 	Follow(followerID string, followedID string) error
 	Unfollow(unfollowerID string, unfollowedID string) error
 	GetFollowers(userID string) ([]string, error)
 	GetFollowing(userID string) ([]string, error)
 	GetPassword(nick string) (string, error)
 	UpdatePassword(nick string, password string) error
+	ChangeNick(currentNick string, newNick string) error
+	ResumeNickChanges(limit int) (int, error)
+	GetNickHistory(nick string) ([]entities.NickChange, error)
+	GetNickRedirect(oldNick string) (string, error)
+	Block(blockerID string, blockedID string) error
+	Unblock(unblockerID string, unblockedID string) error
+	GetBlocked(userID string) ([]string, error)
+	IsBlocked(userID string, otherID string) (bool, error)
+	UpdatePrivacy(userID string, settings entities.PrivacySettings) error
+	RequestFollow(followerID string, followedID string) error
+	GetFollowRequests(userID string) ([]string, error)
+	AcceptFollowRequest(userID string, requesterID string) error
+	RemoveFollowRequest(userID string, requesterID string) error
+	SearchUsers(viewerID string, query string, limit int) ([]entities.UserSummary, error)
+	PinPost(nick string, postID string, max int) error
+	UnpinPost(nick string, postID string) error
+	Mute(muterID string, mutedID string) error
+	Unmute(unmuterID string, unmutedID string) error
+	GetMuted(userID string) ([]string, error)
+	GetSuggestionCandidates(userID string, limit int) ([]entities.UserSummary, error)
+	GetRelationships(viewerID string, nicks []string) ([]entities.Relationship, error)
+	GetMutuals(userID string) ([]string, error)
+	UpdateNotificationPreferences(userID string, disabled []string) error
 }
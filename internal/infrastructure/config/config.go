@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +14,48 @@ var (
 	ConnectionDBString = ""
 	Port               = 0
 	SecretKey          []byte
+
+	MediaStorage             = ""
+	MediaLocalPath           = ""
+	MediaMaxUploadSize int64 = 5 << 20
+	S3Endpoint               = ""
+	S3Bucket                 = ""
+	S3Region                 = ""
+	S3AccessKey              = ""
+	S3SecretKey              = ""
+
+	NickRedirectGracePeriod = 30 * 24 * time.Hour
+	NickReservationCooldown = 90 * 24 * time.Hour
+	ReservedNicks           = []string{}
+
+	SuggestionsRefreshInterval = time.Hour
+	TrendsRefreshInterval      = 5 * time.Minute
+
+	StreamBufferSize  = 64
+	StreamHistorySize = 1000
+	StreamKeepAlive   = 25 * time.Second
+
+	// PostEditWindow is how long after being created a post can be edited, zero doesn't limit it
+	PostEditWindow time.Duration = 0
+
+	// SchedulerInterval is how often the due posts are looked for, by the instance holding the SchedulerLease
+	SchedulerInterval = 30 * time.Second
+	SchedulerLease    = 2 * time.Minute
+
+	// TrashRetention is how long deleted posts and accounts can be restored before being purged
+	TrashRetention     = 30 * 24 * time.Hour
+	TrashPurgeInterval = time.Hour
+
+	// ExportExpiration is how long a data export can be downloaded, ExportInterval how often the requested
+	// ones are built
+	ExportExpiration = 7 * 24 * time.Hour
+	ExportInterval   = 30 * time.Second
+
+	// MaxPinnedPosts is how many posts a user can pin to the profile
+	MaxPinnedPosts = 3
+
+	// Reactions are the types of reaction a user can leave on a post
+	Reactions = []string{"👍", "🎉", "🚀", "👀", "❤️", "🤔"}
 )
 
 func Load() {
@@ -29,4 +73,102 @@ func Load() {
 	ConnectionDBString = os.Getenv("DB_MYSQL_URI")
 
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+
+	MediaStorage = os.Getenv("MEDIA_STORAGE")
+
+	MediaLocalPath = os.Getenv("MEDIA_LOCAL_PATH")
+	if MediaLocalPath == "" {
+		MediaLocalPath = "./uploads"
+	}
+
+	if maxUploadSize, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_UPLOAD_SIZE"), 10, 64); err == nil {
+		MediaMaxUploadSize = maxUploadSize
+	}
+
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	S3SecretKey = os.Getenv("S3_SECRET_KEY")
+
+	S3Region = os.Getenv("S3_REGION")
+	if S3Region == "" {
+		S3Region = "us-east-1"
+	}
+
+	if gracePeriod, err := time.ParseDuration(os.Getenv("NICK_REDIRECT_GRACE_PERIOD")); err == nil {
+		NickRedirectGracePeriod = gracePeriod
+	}
+
+	if cooldown, err := time.ParseDuration(os.Getenv("NICK_RESERVATION_COOLDOWN")); err == nil {
+		NickReservationCooldown = cooldown
+	}
+
+	if refreshInterval, err := time.ParseDuration(os.Getenv("SUGGESTIONS_REFRESH_INTERVAL")); err == nil {
+		SuggestionsRefreshInterval = refreshInterval
+	}
+
+	if refreshInterval, err := time.ParseDuration(os.Getenv("TRENDS_REFRESH_INTERVAL")); err == nil && refreshInterval > 0 {
+		TrendsRefreshInterval = refreshInterval
+	}
+
+	if bufferSize, err := strconv.Atoi(os.Getenv("STREAM_BUFFER_SIZE")); err == nil && bufferSize > 0 {
+		StreamBufferSize = bufferSize
+	}
+
+	if historySize, err := strconv.Atoi(os.Getenv("STREAM_HISTORY_SIZE")); err == nil && historySize > 0 {
+		StreamHistorySize = historySize
+	}
+
+	if keepAlive, err := time.ParseDuration(os.Getenv("STREAM_KEEPALIVE")); err == nil && keepAlive > 0 {
+		StreamKeepAlive = keepAlive
+	}
+
+	if editWindow, err := time.ParseDuration(os.Getenv("POST_EDIT_WINDOW")); err == nil && editWindow >= 0 {
+		PostEditWindow = editWindow
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL")); err == nil && interval > 0 {
+		SchedulerInterval = interval
+	}
+
+	if lease, err := time.ParseDuration(os.Getenv("SCHEDULER_LEASE")); err == nil && lease > 0 {
+		SchedulerLease = lease
+	}
+
+	if retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION")); err == nil && retention >= 0 {
+		TrashRetention = retention
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("TRASH_PURGE_INTERVAL")); err == nil && interval > 0 {
+		TrashPurgeInterval = interval
+	}
+
+	if expiration, err := time.ParseDuration(os.Getenv("EXPORT_EXPIRATION")); err == nil && expiration > 0 {
+		ExportExpiration = expiration
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("EXPORT_INTERVAL")); err == nil && interval > 0 {
+		ExportInterval = interval
+	}
+
+	if maxPinned, err := strconv.Atoi(os.Getenv("MAX_PINNED_POSTS")); err == nil && maxPinned >= 0 {
+		MaxPinnedPosts = maxPinned
+	}
+
+	// the reaction types are keys of the posts' counters, which can't have dots or start with $
+	reactions := []string{}
+	for _, reaction := range strings.Split(os.Getenv("REACTIONS"), ",") {
+		if reaction = strings.TrimSpace(reaction); reaction != "" && !strings.ContainsAny(reaction, ".$") {
+			reactions = append(reactions, reaction)
+		}
+	}
+	if len(reactions) > 0 {
+		Reactions = reactions
+	}
+
+	for _, nick := range strings.Split(os.Getenv("RESERVED_NICKS"), ",") {
+		if nick = strings.TrimSpace(nick); nick != "" {
+			ReservedNicks = append(ReservedNicks, nick)
+		}
+	}
 }
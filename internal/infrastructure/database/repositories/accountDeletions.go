@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccountDeletionsRepository keeps the progress of the accounts being removed for good, so a deletion
+// interrupted by a restart or a failure resumes from where it stopped
+type AccountDeletionsRepository struct {
+	collection *mongo.Collection
+	users      *mongo.Collection
+}
+
+func NewAccountDeletionsRepository(db *mongo.Database) *AccountDeletionsRepository {
+	collection := db.Collection("account_deletions")
+
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.M{"updatedAt": 1},
+	})
+	if err != nil {
+		log.Printf("could not create the account deletions updatedAt index: %s", err)
+	}
+
+	return &AccountDeletionsRepository{
+		collection,
+		db.Collection("users"),
+	}
+}
+
+// Schedule starts the deletion of up to limit accounts deleted before the given time. The account is only
+// flagged once its deletion exists, so an interrupted schedule finds it again and keeps the same deletion
+func (repository *AccountDeletionsRepository) Schedule(before time.Time, limit int) (int, error) {
+	cursor, err := repository.users.Find(context.Background(),
+		bson.M{"deletedAt": bson.M{"$lte": before}, "deletionScheduled": bson.M{"$exists": false}},
+		options.Find().SetLimit(int64(limit)).SetProjection(bson.M{"nick": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var users []entities.User
+	if err = cursor.All(context.Background(), &users); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		_, err = repository.collection.UpdateOne(context.Background(),
+			bson.M{"_id": user.Nick},
+			bson.M{"$setOnInsert": bson.M{
+				"step":      entities.AccountDeletionSteps[0],
+				"attempts":  0,
+				"startedAt": now,
+				"updatedAt": now,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = repository.users.UpdateOne(context.Background(),
+			bson.M{"nick": user.Nick},
+			bson.M{"$set": bson.M{"deletionScheduled": true}},
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(users), nil
+}
+
+// GetPending returns the deletions that didn't finish, the ones that waited the longest first. A failed step
+// touches its deletion, so a deletion that keeps failing doesn't hold the others back
+func (repository *AccountDeletionsRepository) GetPending(limit int) ([]entities.AccountDeletion, error) {
+	cursor, err := repository.collection.Find(context.Background(), bson.M{},
+		options.Find().SetSort(bson.M{"updatedAt": 1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deletions := []entities.AccountDeletion{}
+	if err = cursor.All(context.Background(), &deletions); err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+// Advance records that the deletion finished the steps before the given one
+func (repository *AccountDeletionsRepository) Advance(nick string, step string) error {
+	_, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"_id": nick},
+		bson.M{
+			"$set":   bson.M{"step": step, "attempts": 0, "updatedAt": time.Now()},
+			"$unset": bson.M{"lastError": ""},
+		},
+	)
+	return err
+}
+
+// Fail records why the current step of the deletion couldn't finish, it runs again on the next purge
+func (repository *AccountDeletionsRepository) Fail(nick string, reason string) error {
+	_, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"_id": nick},
+		bson.M{
+			"$set": bson.M{"lastError": reason, "updatedAt": time.Now()},
+			"$inc": bson.M{"attempts": 1},
+		},
+	)
+	return err
+}
+
+// Complete forgets a deletion whose steps all finished
+func (repository *AccountDeletionsRepository) Complete(nick string) error {
+	_, err := repository.collection.DeleteOne(context.Background(), bson.M{"_id": nick})
+	return err
+}
@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type BookmarksRepository struct {
+	collection  *mongo.Collection
+	collections *mongo.Collection
+}
+
+func NewBookmarksRepository(db *mongo.Database) *BookmarksRepository {
+	collection := db.Collection("bookmarks")
+
+	// a post is bookmarked once by each user
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    primitive.D{{Key: "nick", Value: 1}, {Key: "postId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("could not create the bookmarks nick index: %s", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: primitive.D{{Key: "nick", Value: 1}, {Key: "collectionId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("could not create the bookmarks collection index: %s", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.M{"postId": 1},
+	})
+	if err != nil {
+		log.Printf("could not create the bookmarks postId index: %s", err)
+	}
+
+	collections := db.Collection("bookmark_collections")
+
+	_, err = collections.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    primitive.D{{Key: "nick", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("could not create the bookmark collections name index: %s", err)
+	}
+
+	return &BookmarksRepository{
+		collection,
+		collections,
+	}
+}
+
+// Bookmark saves the post for the user on the collection, or on none when collectionID is empty. Bookmarking
+// a post again only moves it to the collection
+func (repository *BookmarksRepository) Bookmark(postID string, nick string, collectionID string) error {
+	update := bson.M{"$setOnInsert": bson.M{"createdAt": time.Now()}}
+	if collectionID == "" {
+		update["$unset"] = bson.M{"collectionId": ""}
+	} else {
+		update["$set"] = bson.M{"collectionId": collectionID}
+	}
+
+	_, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"nick": nick, "postId": postID},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent request bookmarked the post first, the bookmark exists either way
+		return nil
+	}
+
+	return err
+}
+
+func (repository *BookmarksRepository) RemoveBookmark(postID string, nick string) error {
+	result, err := repository.collection.DeleteOne(context.TODO(), bson.M{"nick": nick, "postId": postID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("You didn't bookmark this post")
+	}
+
+	return nil
+}
+
+// GetBookmarks returns the bookmarks of the user on the collection, or all of them when collectionID is empty,
+// the most recent first
+func (repository *BookmarksRepository) GetBookmarks(nick string, collectionID string, page int, limit int) ([]entities.Bookmark, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	filter := bson.M{"nick": nick}
+	if collectionID != "" {
+		filter["collectionId"] = collectionID
+	}
+
+	cursor, err := repository.collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	bookmarks := []entities.Bookmark{}
+	if err = cursor.All(context.TODO(), &bookmarks); err != nil {
+		return nil, err
+	}
+
+	return bookmarks, nil
+}
+
+func (repository *BookmarksRepository) CreateCollection(collection entities.BookmarkCollection) (entities.BookmarkCollection, error) {
+	collection.CreatedAt = time.Now()
+
+	result, err := repository.collections.InsertOne(context.TODO(), collection)
+	if mongo.IsDuplicateKeyError(err) {
+		return entities.BookmarkCollection{}, errors.New("There's already a collection with this name")
+	}
+	if err != nil {
+		return entities.BookmarkCollection{}, err
+	}
+
+	collection.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return collection, nil
+}
+
+func (repository *BookmarksRepository) GetCollection(collectionID string) (entities.BookmarkCollection, error) {
+	objectID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return entities.BookmarkCollection{}, errors.New("This collection doesn't exists")
+	}
+
+	var collection entities.BookmarkCollection
+	if err = repository.collections.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&collection); err != nil {
+		return entities.BookmarkCollection{}, errors.New("This collection doesn't exists")
+	}
+
+	return collection, nil
+}
+
+// GetCollections returns the collections of the user, by name
+func (repository *BookmarksRepository) GetCollections(nick string) ([]entities.BookmarkCollection, error) {
+	cursor, err := repository.collections.Find(context.TODO(), bson.M{"nick": nick}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	collections := []entities.BookmarkCollection{}
+	if err = cursor.All(context.TODO(), &collections); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (repository *BookmarksRepository) RenameCollection(collectionID string, name string) error {
+	objectID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.collections.UpdateOne(context.TODO(), bson.M{"_id": objectID}, bson.M{"$set": bson.M{"name": name}})
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("There's already a collection with this name")
+	}
+
+	return err
+}
+
+// DeleteCollection removes the collection, its bookmarks are kept outside of any collection
+func (repository *BookmarksRepository) DeleteCollection(collectionID string) error {
+	objectID, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = repository.collections.DeleteOne(context.TODO(), bson.M{"_id": objectID}); err != nil {
+		return err
+	}
+
+	_, err = repository.collection.UpdateMany(context.TODO(),
+		bson.M{"collectionId": collectionID},
+		bson.M{"$unset": bson.M{"collectionId": ""}},
+	)
+	return err
+}
+
+// DeleteUserBookmarks removes every bookmark and collection of the user
+func (repository *BookmarksRepository) DeleteUserBookmarks(nick string) error {
+	if _, err := repository.collection.DeleteMany(context.TODO(), bson.M{"nick": nick}); err != nil {
+		return err
+	}
+
+	_, err := repository.collections.DeleteMany(context.TODO(), bson.M{"nick": nick})
+	return err
+}
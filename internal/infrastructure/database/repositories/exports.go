@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type ExportsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExportsRepository(db *mongo.Database) *ExportsRepository {
+	collection := db.Collection("exports")
+
+	// a user has a single export pending or running at a time
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"nick": 1},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"inProgress": true}),
+	})
+	if err != nil {
+		log.Printf("could not create the exports nick index: %s", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: primitive.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("could not create the exports status index: %s", err)
+	}
+
+	return &ExportsRepository{
+		collection,
+	}
+}
+
+// CreateExport requests a new export of the user's data, to be built in the background
+func (repository *ExportsRepository) CreateExport(nick string) (entities.DataExport, error) {
+	export := entities.DataExport{
+		ID:         primitive.NewObjectID().Hex(),
+		Nick:       nick,
+		Status:     entities.ExportStatusPending,
+		InProgress: true,
+		CreatedAt:  time.Now(),
+	}
+
+	_, err := repository.collection.InsertOne(context.Background(), export)
+	if mongo.IsDuplicateKeyError(err) {
+		return entities.DataExport{}, errors.New("There's already an export in progress")
+	}
+	if err != nil {
+		return entities.DataExport{}, err
+	}
+
+	return export, nil
+}
+
+func (repository *ExportsRepository) GetExport(exportID string) (entities.DataExport, error) {
+	var export entities.DataExport
+	if err := repository.collection.FindOne(context.Background(), bson.M{"_id": exportID}).Decode(&export); err != nil {
+		return entities.DataExport{}, errors.New("This export doesn't exists")
+	}
+
+	return export, nil
+}
+
+// GetExports returns the exports of the user, the most recent first
+func (repository *ExportsRepository) GetExports(nick string) ([]entities.DataExport, error) {
+	cursor, err := repository.collection.Find(context.Background(), bson.M{"nick": nick},
+		options.Find().SetSort(bson.M{"createdAt": -1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	exports := []entities.DataExport{}
+	if err = cursor.All(context.Background(), &exports); err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// ClaimExport hands the oldest pending export to the owner until the lease passes. An export whose owner
+// stopped before finishing it is handed again once its lease passes
+func (repository *ExportsRepository) ClaimExport(owner string, lease time.Duration) (entities.DataExport, bool, error) {
+	now := time.Now()
+
+	var export entities.DataExport
+	err := repository.collection.FindOneAndUpdate(context.Background(),
+		bson.M{"$or": []bson.M{
+			{"status": entities.ExportStatusPending},
+			{"status": entities.ExportStatusRunning, "leaseUntil": bson.M{"$lte": now}},
+		}},
+		bson.M{"$set": bson.M{"status": entities.ExportStatusRunning, "owner": owner, "leaseUntil": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"createdAt": 1}).SetReturnDocument(options.After),
+	).Decode(&export)
+	if err == mongo.ErrNoDocuments {
+		return entities.DataExport{}, false, nil
+	}
+	if err != nil {
+		return entities.DataExport{}, false, err
+	}
+
+	return export, true, nil
+}
+
+// CompleteExport records the archive built for the export, as long as the owner still holds it
+func (repository *ExportsRepository) CompleteExport(exportID string, owner string, key string, size int, expiresAt time.Time) error {
+	result, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"_id": exportID, "owner": owner, "status": entities.ExportStatusRunning},
+		bson.M{
+			"$set":   bson.M{"status": entities.ExportStatusReady, "key": key, "size": size, "completedAt": time.Now(), "expiresAt": expiresAt},
+			"$unset": bson.M{"inProgress": "", "owner": "", "leaseUntil": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("the export was handed to another instance")
+	}
+
+	return nil
+}
+
+// FailExport records why the export couldn't be built, the user can request a new one
+func (repository *ExportsRepository) FailExport(exportID string, owner string, reason string) error {
+	_, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"_id": exportID, "owner": owner, "status": entities.ExportStatusRunning},
+		bson.M{
+			"$set":   bson.M{"status": entities.ExportStatusFailed, "error": reason, "completedAt": time.Now()},
+			"$unset": bson.M{"inProgress": "", "owner": "", "leaseUntil": ""},
+		},
+	)
+	return err
+}
+
+// GetExpiredExports returns up to limit ready exports that can't be downloaded anymore
+func (repository *ExportsRepository) GetExpiredExports(now time.Time, limit int) ([]entities.DataExport, error) {
+	cursor, err := repository.collection.Find(context.Background(),
+		bson.M{"status": entities.ExportStatusReady, "expiresAt": bson.M{"$lte": now}},
+		options.Find().SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	exports := []entities.DataExport{}
+	if err = cursor.All(context.Background(), &exports); err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// ExpireExport records that the archive of the export was removed
+func (repository *ExportsRepository) ExpireExport(exportID string) error {
+	_, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"_id": exportID},
+		bson.M{
+			"$set":   bson.M{"status": entities.ExportStatusExpired},
+			"$unset": bson.M{"key": "", "size": ""},
+		},
+	)
+	return err
+}
+
+func (repository *ExportsRepository) DeleteExport(exportID string) error {
+	_, err := repository.collection.DeleteOne(context.Background(), bson.M{"_id": exportID})
+	return err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// LocksRepository keeps leases, so a background job shared by the API instances runs on a single one at a time
+type LocksRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLocksRepository(db *mongo.Database) *LocksRepository {
+	return &LocksRepository{
+		db.Collection("locks"),
+	}
+}
+
+// Acquire takes the lease for the owner until the ttl passes, or extends it when the owner already holds it.
+// It tells false when another owner holds a lease that didn't expire yet
+func (repository *LocksRepository) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	// a lease held by someone else doesn't match the filter, so the upsert tries to insert a second lock with
+	// the same name and fails on the unique _id
+	_, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": name, "$or": []bson.M{{"owner": owner}, {"expiresAt": bson.M{"$lte": now}}}},
+		bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Release gives the lease up, if it's still held by the owner
+func (repository *LocksRepository) Release(name string, owner string) error {
+	_, err := repository.collection.DeleteOne(context.TODO(), bson.M{"_id": name, "owner": owner})
+	return err
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type MediaRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMediaRepository(db *mongo.Database) *MediaRepository {
+	collection := db.Collection("media")
+
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: primitive.D{{Key: "ownerNick", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("could not create the media index: %s", err)
+	}
+
+	return &MediaRepository{
+		collection,
+	}
+}
+
+func (repository *MediaRepository) CreateMedia(media entities.Media) (entities.Media, error) {
+	if media.ID == "" {
+		media.ID = primitive.NewObjectID().Hex()
+	}
+	media.CreatedAt = time.Now()
+
+	_, err := repository.collection.InsertOne(context.Background(), media)
+	if err != nil {
+		return entities.Media{}, err
+	}
+
+	return media, nil
+}
+
+func (repository *MediaRepository) GetMedia(mediaID string) (entities.Media, error) {
+	var media entities.Media
+	err := repository.collection.FindOne(context.Background(), bson.M{"_id": mediaID}).Decode(&media)
+	if err != nil {
+		return entities.Media{}, fmt.Errorf("This media doesn't exists")
+	}
+
+	return media, nil
+}
+
+func (repository *MediaRepository) DeleteMedia(mediaID string) error {
+	_, err := repository.collection.DeleteOne(context.Background(), bson.M{"_id": mediaID})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMediaByOwner returns the media uploaded by the user, the most recent first
+func (repository *MediaRepository) GetMediaByOwner(nick string, page int, limit int) ([]entities.Media, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.Background(), bson.M{"ownerNick": nick}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	media := []entities.Media{}
+	if err = cursor.All(context.Background(), &media); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
@@ -0,0 +1,358 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type MessagesRepository struct {
+	conversations *mongo.Collection
+	messages      *mongo.Collection
+}
+
+func NewMessagesRepository(db *mongo.Database) *MessagesRepository {
+	conversations := db.Collection("conversations")
+	messages := db.Collection("messages")
+
+	_, err := conversations.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: primitive.D{{Key: "participants.nick", Value: 1}, {Key: "updatedAt", Value: -1}}},
+		// a pair of users has only one one-to-one conversation
+		{
+			Keys:    bson.M{"key": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"key": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		log.Printf("could not create the conversations indexes: %s", err)
+	}
+
+	_, err = messages.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: primitive.D{{Key: "conversationId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("could not create the messages index: %s", err)
+	}
+
+	return &MessagesRepository{
+		conversations,
+		messages,
+	}
+}
+
+func (repository *MessagesRepository) CreateConversation(conversation entities.Conversation) (entities.Conversation, error) {
+	now := time.Now()
+	conversation.CreatedAt = now
+	conversation.UpdatedAt = now
+
+	result, err := repository.conversations.InsertOne(context.TODO(), conversation)
+	if mongo.IsDuplicateKeyError(err) && conversation.Key != "" {
+		// the other user started the same conversation at the same time
+		return repository.GetConversationByKey(conversation.Key)
+	}
+	if err != nil {
+		return entities.Conversation{}, err
+	}
+
+	conversation.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return conversation, nil
+}
+
+func (repository *MessagesRepository) GetConversation(conversationID string) (entities.Conversation, error) {
+	objectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return entities.Conversation{}, errors.New("This conversation doesn't exists")
+	}
+
+	var conversation entities.Conversation
+	if err = repository.conversations.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&conversation); err != nil {
+		return entities.Conversation{}, errors.New("This conversation doesn't exists")
+	}
+
+	return conversation, nil
+}
+
+func (repository *MessagesRepository) GetConversationByKey(key string) (entities.Conversation, error) {
+	var conversation entities.Conversation
+	if err := repository.conversations.FindOne(context.TODO(), bson.M{"key": key}).Decode(&conversation); err != nil {
+		return entities.Conversation{}, errors.New("This conversation doesn't exists")
+	}
+
+	return conversation, nil
+}
+
+// GetConversations lists the conversations of the user with the latest activity first, leaving out the ones
+// the user deleted and that got no message since
+// GetConversations lists the conversations the participant didn't delete, the most recently active first. The
+// last message shown is the latest one the participant still has and wasn't sent by hiddenSenders
+func (repository *MessagesRepository) GetConversations(nick string, hiddenSenders []string, page int, limit int) ([]entities.Conversation, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"updatedAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	filter := bson.M{"participants": bson.M{"$elemMatch": bson.M{"nick": nick, "hidden": false}}}
+
+	cursor, err := repository.conversations.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	conversations := []entities.Conversation{}
+	if err = cursor.All(context.TODO(), &conversations); err != nil {
+		return nil, err
+	}
+
+	for i := range conversations {
+		participant, _ := conversations[i].Participant(nick)
+		conversations[i].UnreadCount = participant.Unread
+
+		lastMessage := conversations[i].LastMessage
+		if lastMessage == nil || !lastMessage.CreatedAt.After(participant.ClearedAt) {
+			conversations[i].LastMessage = nil
+			continue
+		}
+
+		if contains(lastMessage.DeletedFor, nick) || contains(hiddenSenders, lastMessage.SenderNick) {
+			conversations[i].LastMessage, err = repository.lastVisibleMessage(conversations[i].ID, nick, participant.ClearedAt, hiddenSenders)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return conversations, nil
+}
+
+// AddMessage saves the message and counts it as unread for the other participants, bringing the conversation
+// back for the ones that deleted it. The participants in blockedBy blocked the sender, so it's neither counted
+// nor brings the conversation back for them
+func (repository *MessagesRepository) AddMessage(message entities.Message, blockedBy []string) (entities.Message, error) {
+	objectID, err := primitive.ObjectIDFromHex(message.ConversationID)
+	if err != nil {
+		return entities.Message{}, errors.New("This conversation doesn't exists")
+	}
+
+	message.CreatedAt = time.Now()
+
+	result, err := repository.messages.InsertOne(context.TODO(), message)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	message.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	_, err = repository.conversations.UpdateOne(context.TODO(),
+		bson.M{"_id": objectID},
+		bson.M{
+			"$set": bson.M{
+				"lastMessage":                       message,
+				"updatedAt":                         message.CreatedAt,
+				"participants.$[shown].hidden":      false,
+				"participants.$[sender].lastReadAt": message.CreatedAt,
+			},
+			"$inc": bson.M{"participants.$[other].unread": 1},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{
+				bson.M{"sender.nick": message.SenderNick},
+				bson.M{"shown.nick": bson.M{"$nin": blockedBy}},
+				bson.M{"other.nick": bson.M{"$nin": append([]string{message.SenderNick}, blockedBy...)}},
+			},
+		}),
+	)
+	if err != nil {
+		return entities.Message{}, err
+	}
+
+	return message, nil
+}
+
+// GetMessages lists the messages of the conversation the participant still has, the newest first, leaving out
+// the ones sent by hiddenSenders
+func (repository *MessagesRepository) GetMessages(conversationID string, nick string, hiddenSenders []string, page int, limit int) ([]entities.Message, error) {
+	conversation, err := repository.GetConversation(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, found := conversation.Participant(nick)
+	if !found {
+		return nil, errors.New("This conversation doesn't exists")
+	}
+
+	findOptions := options.Find().
+		SetSort(primitive.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.messages.Find(context.TODO(), visibleMessages(conversationID, nick, participant.ClearedAt, hiddenSenders), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	messages := []entities.Message{}
+	if err = cursor.All(context.TODO(), &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// lastVisibleMessage finds the latest message of the conversation the participant still has and wasn't sent
+// by hiddenSenders, it's nil when there is none
+func (repository *MessagesRepository) lastVisibleMessage(conversationID string, nick string, clearedAt time.Time, hiddenSenders []string) (*entities.Message, error) {
+	findOptions := options.FindOne().SetSort(primitive.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+
+	message := entities.Message{}
+	err := repository.messages.FindOne(context.TODO(), visibleMessages(conversationID, nick, clearedAt, hiddenSenders), findOptions).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// visibleMessages matches the messages of the conversation sent after the participant cleared it, that they
+// didn't delete and weren't sent by hiddenSenders
+func visibleMessages(conversationID string, nick string, clearedAt time.Time, hiddenSenders []string) bson.M {
+	return bson.M{
+		"conversationId": conversationID,
+		"deletedFor":     bson.M{"$ne": nick},
+		"createdAt":      bson.M{"$gt": clearedAt},
+		"senderNick":     bson.M{"$nin": hiddenSenders},
+	}
+}
+
+func (repository *MessagesRepository) MarkConversationRead(conversationID string, nick string) error {
+	objectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return errors.New("This conversation doesn't exists")
+	}
+
+	_, err = repository.conversations.UpdateOne(context.TODO(),
+		bson.M{"_id": objectID, "participants.nick": nick},
+		bson.M{"$set": bson.M{"participants.$.lastReadAt": time.Now(), "participants.$.unread": 0}},
+	)
+	return err
+}
+
+// DeleteMessage hides the message from the participant, it's only removed once every participant deleted it
+func (repository *MessagesRepository) DeleteMessage(conversationID string, messageID string, nick string) error {
+	objectID, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return errors.New("This message doesn't exists")
+	}
+
+	conversation, err := repository.GetConversation(conversationID)
+	if err != nil {
+		return err
+	}
+
+	result, err := repository.messages.UpdateOne(context.TODO(),
+		bson.M{"_id": objectID, "conversationId": conversationID},
+		bson.M{"$addToSet": bson.M{"deletedFor": nick}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("This message doesn't exists")
+	}
+
+	_, err = repository.messages.DeleteOne(context.TODO(),
+		bson.M{"_id": objectID, "deletedFor": bson.M{"$all": participantNicks(conversation)}},
+	)
+	return err
+}
+
+// DeleteConversation clears the messages for the participant and hides the conversation until a new message
+// arrives. The messages every participant cleared are removed
+func (repository *MessagesRepository) DeleteConversation(conversationID string, nick string) error {
+	objectID, err := primitive.ObjectIDFromHex(conversationID)
+	if err != nil {
+		return errors.New("This conversation doesn't exists")
+	}
+
+	now := time.Now()
+	_, err = repository.conversations.UpdateOne(context.TODO(),
+		bson.M{"_id": objectID, "participants.nick": nick},
+		bson.M{"$set": bson.M{
+			"participants.$.clearedAt":  now,
+			"participants.$.lastReadAt": now,
+			"participants.$.unread":     0,
+			"participants.$.hidden":     true,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	conversation, err := repository.GetConversation(conversationID)
+	if err != nil {
+		return err
+	}
+
+	clearedByAll := now
+	for _, participant := range conversation.Participants {
+		if participant.ClearedAt.Before(clearedByAll) {
+			clearedByAll = participant.ClearedAt
+		}
+	}
+
+	_, err = repository.messages.DeleteMany(context.TODO(),
+		bson.M{"conversationId": conversationID, "createdAt": bson.M{"$lte": clearedByAll}},
+	)
+	return err
+}
+
+func participantNicks(conversation entities.Conversation) []string {
+	nicks := []string{}
+	for _, participant := range conversation.Participants {
+		nicks = append(nicks, participant.Nick)
+	}
+	return nicks
+}
+
+// RemoveParticipant takes the user out of every conversation, along with the messages they sent. The others
+// keep what they wrote, and one-to-one conversations lose their key so the nick can't reach them again
+func (repository *MessagesRepository) RemoveParticipant(nick string) error {
+	if _, err := repository.messages.DeleteMany(context.TODO(), bson.M{"senderNick": nick}); err != nil {
+		return err
+	}
+
+	_, err := repository.messages.UpdateMany(context.TODO(), bson.M{"deletedFor": nick}, bson.M{"$pull": bson.M{"deletedFor": nick}})
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.conversations.UpdateMany(context.TODO(),
+		bson.M{"lastMessage.senderNick": nick},
+		bson.M{"$unset": bson.M{"lastMessage": ""}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.conversations.UpdateMany(context.TODO(),
+		bson.M{"participants.nick": nick},
+		bson.M{"$pull": bson.M{"participants": bson.M{"nick": nick}}, "$unset": bson.M{"key": ""}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.conversations.DeleteMany(context.TODO(), bson.M{"participants": bson.M{"$size": 0}})
+	return err
+}
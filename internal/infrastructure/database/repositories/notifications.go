@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxNotificationActors is how many of the latest actors are kept on a grouped notification
+const maxNotificationActors = 50
+
+type NotificationsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationsRepository(db *mongo.Database) *NotificationsRepository {
+	collection := db.Collection("notifications")
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: primitive.D{{Key: "recipient", Value: 1}, {Key: "updatedAt", Value: -1}}},
+		// only one unread notification for each kind of event on each post
+		{
+			Keys:    primitive.D{{Key: "recipient", Value: 1}, {Key: "type", Value: 1}, {Key: "postId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"read": false}),
+		},
+	})
+	if err != nil {
+		log.Printf("could not create the notifications indexes: %s", err)
+	}
+
+	return &NotificationsRepository{
+		collection,
+	}
+}
+
+// AddNotification joins the event to the unread notification of the same kind on the same post, creating it
+// when there's none
+func (repository *NotificationsRepository) AddNotification(notification entities.Notification) error {
+	now := time.Now()
+	actors := bson.M{"$ifNull": []interface{}{"$actors", []string{}}}
+
+	filter := bson.M{
+		"recipient": notification.Recipient,
+		"type":      notification.Type,
+		"postId":    notification.PostID,
+		"read":      false,
+	}
+
+	update := []bson.M{
+		{"$set": bson.M{
+			"actor": notification.Actor,
+			"actors": bson.M{"$slice": []interface{}{
+				bson.M{"$concatArrays": []interface{}{
+					[]string{notification.Actor},
+					bson.M{"$filter": bson.M{"input": actors, "cond": bson.M{"$ne": []interface{}{"$$this", notification.Actor}}}},
+				}},
+				maxNotificationActors,
+			}},
+			"actorCount": bson.M{"$cond": []interface{}{
+				bson.M{"$in": []interface{}{notification.Actor, actors}},
+				"$actorCount",
+				bson.M{"$add": []interface{}{bson.M{"$ifNull": []interface{}{"$actorCount", 0}}, 1}},
+			}},
+			"createdAt": bson.M{"$ifNull": []interface{}{"$createdAt", now}},
+			"updatedAt": now,
+		}},
+	}
+
+	_, err := repository.collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// another event created the notification at the same time, now it can be joined
+		_, err = repository.collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+	}
+
+	return err
+}
+
+func (repository *NotificationsRepository) GetNotifications(recipient string, page int, limit int) ([]entities.Notification, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"updatedAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"recipient": recipient}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	notifications := []entities.Notification{}
+	if err = cursor.All(context.TODO(), &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (repository *NotificationsRepository) CountUnread(recipient string) (int, error) {
+	count, err := repository.collection.CountDocuments(context.TODO(), bson.M{"recipient": recipient, "read": false})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (repository *NotificationsRepository) MarkRead(recipient string, ids []string) error {
+	objectIDs := []primitive.ObjectID{}
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+
+	_, err := repository.collection.UpdateMany(context.TODO(),
+		bson.M{"recipient": recipient, "_id": bson.M{"$in": objectIDs}},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	return err
+}
+
+func (repository *NotificationsRepository) MarkAllRead(recipient string) error {
+	_, err := repository.collection.UpdateMany(context.TODO(),
+		bson.M{"recipient": recipient, "read": false},
+		bson.M{"$set": bson.M{"read": true}},
+	)
+	return err
+}
+
+// DeleteUserNotifications removes the notifications the user got, and takes the user out of the ones they
+// caused, removing those left without anyone
+func (repository *NotificationsRepository) DeleteUserNotifications(nick string) error {
+	if _, err := repository.collection.DeleteMany(context.TODO(), bson.M{"recipient": nick}); err != nil {
+		return err
+	}
+
+	update := []bson.M{
+		{"$set": bson.M{
+			"actors":     bson.M{"$filter": bson.M{"input": "$actors", "cond": bson.M{"$ne": []interface{}{"$$this", nick}}}},
+			"actorCount": bson.M{"$subtract": []interface{}{"$actorCount", 1}},
+		}},
+		{"$set": bson.M{"actor": bson.M{"$ifNull": []interface{}{bson.M{"$arrayElemAt": []interface{}{"$actors", 0}}, ""}}}},
+	}
+
+	if _, err := repository.collection.UpdateMany(context.TODO(), bson.M{"actors": nick}, update); err != nil {
+		return err
+	}
+
+	_, err := repository.collection.DeleteMany(context.TODO(), bson.M{"$or": []bson.M{
+		{"actorCount": bson.M{"$lte": 0}},
+		{"actors": bson.M{"$size": 0}},
+	}})
+	return err
+}
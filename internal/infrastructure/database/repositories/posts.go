@@ -3,22 +3,132 @@ package repositories
 import (
 	"api/internal/domain/entities"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 )
 
 type PostsRepository struct {
 	collection *mongo.Collection
+	revisions  *mongo.Collection
+	likes      *mongo.Collection
+	reactions  *mongo.Collection
+	bookmarks  *mongo.Collection
+	votes      *mongo.Collection
+	users      *mongo.Collection
+	migrations *mongo.Collection
+	trends     *TrendsRepository
 }
 
 func NewPostsRepository(db *mongo.Database) *PostsRepository {
 	collection := db.Collection("posts")
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: primitive.D{{Key: "parentId", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.M{"rootId": 1}},
+		{Keys: primitive.D{{Key: "authorNick", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: primitive.D{{Key: "tags", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: primitive.D{{Key: "mentions.nick", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: primitive.D{{Key: "visibility", Value: 1}, {Key: "createdAt", Value: -1}}},
+		// only the unpublished posts have a status, so the drafts and the schedule stay small
+		{
+			Keys:    primitive.D{{Key: "authorNick", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"status": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    primitive.D{{Key: "status", Value: 1}, {Key: "publishAt", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"status": bson.M{"$exists": true}}),
+		},
+		// the trash of each author and the posts due to be purged
+		{
+			Keys:    primitive.D{{Key: "authorNick", Value: 1}, {Key: "deletedAt", Value: -1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.M{"deletedAt": 1},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		},
+		// a user can only repost a post once
+		{
+			Keys: primitive.D{{Key: "repostOf", Value: 1}, {Key: "authorNick", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+				bson.M{"repostOf": bson.M{"$type": "string"}},
+			),
+		},
+	})
+	if err != nil {
+		log.Printf("could not create the posts indexes: %s", err)
+	}
+
+	revisions := db.Collection("post_revisions")
+
+	_, err = revisions.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: primitive.D{{Key: "postId", Value: 1}, {Key: "replacedAt", Value: -1}},
+	})
+	if err != nil {
+		log.Printf("could not create the post revisions index: %s", err)
+	}
+
+	likes := db.Collection("post_likes")
+
+	_, err = likes.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// a user likes a post only once
+		{
+			Keys:    primitive.D{{Key: "postId", Value: 1}, {Key: "nick", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: primitive.D{{Key: "nick", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("could not create the post likes indexes: %s", err)
+	}
+
+	reactions := db.Collection("post_reactions")
+
+	_, err = reactions.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// a user reacts with each type only once on a post
+		{
+			Keys:    primitive.D{{Key: "postId", Value: 1}, {Key: "nick", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: primitive.D{{Key: "postId", Value: 1}, {Key: "type", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: primitive.D{{Key: "nick", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("could not create the post reactions indexes: %s", err)
+	}
+
+	votes := db.Collection("poll_votes")
+
+	_, err = votes.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// a user votes on a poll only once
+		{
+			Keys:    primitive.D{{Key: "postId", Value: 1}, {Key: "nick", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: primitive.D{{Key: "nick", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("could not create the poll votes indexes: %s", err)
+	}
+
 	return &PostsRepository{
 		collection,
+		revisions,
+		likes,
+		reactions,
+		db.Collection("bookmarks"),
+		votes,
+		db.Collection("users"),
+		db.Collection("migrations"),
+		NewTrendsRepository(db),
 	}
 }
 
@@ -29,19 +139,70 @@ func (repository *PostsRepository) CreatePost(post entities.Post) (entities.Post
 		AuthorID:   post.AuthorID,
 		AuthorNick: post.AuthorID,
 		Likes:      0,
+		MediaIDs:   post.MediaIDs,
+		Visibility: post.Visibility,
+		Tags:       post.Tags,
+		Mentions:   post.Mentions,
+		ParentID:   post.ParentID,
+		RootID:     post.RootID,
+		QuoteOf:    post.QuoteOf,
+		Status:     post.Status,
+		PublishAt:  post.PublishAt,
+		Poll:       post.Poll,
 		CreatedAt:  time.Now(),
 	}
 
-	_, err := repository.collection.InsertOne(context.Background(), newPost)
+	result, err := repository.collection.InsertOne(context.Background(), newPost)
 	if err != nil {
 		return entities.Post{}, err
 	}
 
+	if insertedID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		newPost.ID = insertedID.Hex()
+	}
+
+	// drafts and scheduled posts are counted once they are published
+	if newPost.Published() {
+		if err = repository.onPublish(newPost); err != nil {
+			return entities.Post{}, err
+		}
+	}
+
 	return newPost, nil
 }
 
-func (repository *PostsRepository) GetPosts(nick string) ([]entities.Post, error) {
-	cursor, err := repository.collection.Find(context.TODO(), bson.M{"authorNick": nick})
+// onPublish counts the post that was just published for the trends and on the posts it replies and quotes
+func (repository *PostsRepository) onPublish(post entities.Post) error {
+	if post.Visibility == entities.VisibilityPublic {
+		repository.recordTagActivity(post.Tags, entities.ActivityPost)
+	}
+
+	if post.ParentID != "" {
+		if err := repository.incrementCounter(post.ParentID, "replyCount", 1); err != nil {
+			return err
+		}
+		repository.recordPostActivity(post.ParentID, entities.ActivityReply)
+	}
+
+	if post.QuoteOf != "" {
+		if err := repository.incrementCounter(post.QuoteOf, "quoteCount", 1); err != nil {
+			return err
+		}
+		repository.recordPostActivity(post.QuoteOf, entities.ActivityRepost)
+	}
+
+	return nil
+}
+
+func (repository *PostsRepository) GetPosts(nick string, viewerNick string) ([]entities.Post, error) {
+	visible, err := repository.visibleTo(viewerNick, false, bson.M{"authorNick": nick})
+	if err != nil {
+		return []entities.Post{}, err
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"authorNick": nick, "deleted": bson.M{"$ne": true}, "$or": visible}, findOptions)
 	if err != nil {
 		return []entities.Post{}, err
 	}
@@ -62,7 +223,49 @@ func (repository *PostsRepository) GetPosts(nick string) ([]entities.Post, error
 		return []entities.Post{}, err
 	}
 
-	return results, nil
+	return repository.pinnedFirst(nick, results)
+}
+
+// pinnedFirst moves the posts the author pinned to the top, in the order they're pinned. Pinned posts the
+// viewer can't read aren't on the list, so they stay out of it
+func (repository *PostsRepository) pinnedFirst(nick string, posts []entities.Post) ([]entities.Post, error) {
+	author := entities.User{}
+	err := repository.users.FindOne(context.TODO(),
+		bson.M{"nick": nick},
+		options.FindOne().SetProjection(bson.M{"pinnedPosts": 1}),
+	).Decode(&author)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if len(author.PinnedPosts) == 0 {
+		return posts, nil
+	}
+
+	positions := map[string]int{}
+	for position, postID := range author.PinnedPosts {
+		positions[postID] = position
+	}
+
+	pinned := make([]entities.Post, len(author.PinnedPosts))
+	others := []entities.Post{}
+	for _, post := range posts {
+		if position, found := positions[post.ID]; found {
+			post.Pinned = true
+			pinned[position] = post
+			continue
+		}
+		others = append(others, post)
+	}
+
+	ordered := []entities.Post{}
+	for _, post := range pinned {
+		if post.ID != "" {
+			ordered = append(ordered, post)
+		}
+	}
+
+	return append(ordered, others...), nil
 }
 
 func (repository *PostsRepository) GetPostWithId(id string) (entities.Post, error) {
@@ -77,97 +280,1488 @@ func (repository *PostsRepository) GetPostWithId(id string) (entities.Post, erro
 		return entities.Post{}, fmt.Errorf("This post doens't exists")
 	}
 
-	return result, nil
+	return asTombstone(result), nil
+}
+
+// GetVisiblePost finds the post only when the viewer can read it
+func (repository *PostsRepository) GetVisiblePost(id string, viewerNick string) (entities.Post, error) {
+	idString, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return entities.Post{}, err
+	}
+
+	visible, err := repository.visibleTo(viewerNick, false, bson.M{"_id": idString})
+	if err != nil {
+		return entities.Post{}, err
+	}
+
+	var result entities.Post
+	err = repository.collection.FindOne(context.TODO(), bson.M{"_id": idString, "$or": append(visible, trashedWithReplies)}).Decode(&result)
+	if err != nil {
+		return entities.Post{}, fmt.Errorf("This post doens't exists")
+	}
+
+	return asTombstone(result), nil
+}
+
+// trashedWithReplies matches the posts in the trash that conversations still show, as tombstones
+var trashedWithReplies = bson.M{"deletedAt": bson.M{"$exists": true}, "replyCount": bson.M{"$gt": 0}}
+
+// asTombstone hides what a post in the trash had, the way a deleted post is shown while it can be restored
+func asTombstone(post entities.Post) entities.Post {
+	if post.DeletedAt == nil {
+		return post
+	}
+
+	return entities.Post{
+		ID:         post.ID,
+		ParentID:   post.ParentID,
+		RootID:     post.RootID,
+		ReplyCount: post.ReplyCount,
+		QuoteCount: post.QuoteCount,
+		Deleted:    true,
+		CreatedAt:  post.CreatedAt,
+		UpdatedAt:  post.UpdatedAt,
+	}
+}
+
+func asTombstones(posts []entities.Post) []entities.Post {
+	for i := range posts {
+		posts[i] = asTombstone(posts[i])
+	}
+	return posts
+}
+
+// visibleTo are the conditions, one of which a post must match for the viewer to read it. Listings leave out
+// the unlisted posts, which are only reached by their link, the profile of the author, feeds and conversations.
+// Posts not published yet or in the trash aren't read by anyone, their author reaches them through the drafts
+// and the trash. Only the authors of the posts matching scope, the rest of the filter of the query, are checked
+func (repository *PostsRepository) visibleTo(viewerNick string, listing bool, scope bson.M) ([]bson.M, error) {
+	readableByAnyone := []interface{}{nil, entities.VisibilityPublic}
+	if !listing {
+		readableByAnyone = append(readableByAnyone, entities.VisibilityUnlisted)
+	}
+
+	viewer := entities.User{}
+	if viewerNick != "" {
+		err := repository.users.FindOne(context.TODO(),
+			bson.M{"nick": viewerNick},
+			options.FindOne().SetProjection(bson.M{"following": 1, "blocked": 1}),
+		).Decode(&viewer)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	candidates, err := repository.collection.Distinct(context.TODO(), "authorNick", scope)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := repository.hiddenAuthors(viewerNick, viewer, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []bson.M{{"visibility": bson.M{"$in": readableByAnyone}, "authorNick": bson.M{"$nin": hidden}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}}}
+	if viewerNick == "" {
+		return conditions, nil
+	}
+
+	following := viewer.Following
+	if following == nil {
+		following = []string{}
+	}
+
+	ownPosts := bson.M{"authorNick": viewerNick, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}}
+	if listing {
+		ownPosts["visibility"] = bson.M{"$ne": entities.VisibilityUnlisted}
+	}
+
+	return append(conditions,
+		ownPosts,
+		bson.M{"visibility": entities.VisibilityFollowers, "authorNick": bson.M{"$in": following, "$nin": hidden}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"visibility": entities.VisibilityMentioned, "mentions.nick": viewerNick, "authorNick": bson.M{"$nin": hidden}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}},
+	), nil
+}
+
+// hiddenAuthors lists, out of the candidates, the authors whose posts the viewer can't see: private accounts
+// the viewer doesn't follow, the ones blocking the viewer and the ones the viewer blocked
+func (repository *PostsRepository) hiddenAuthors(viewerNick string, viewer entities.User, candidates []interface{}) ([]string, error) {
+	hidden := []string{}
+	if len(candidates) == 0 {
+		return hidden, nil
+	}
+
+	filter := bson.M{"nick": bson.M{"$in": candidates}, "private": true}
+	if viewerNick != "" {
+		filter = bson.M{"nick": bson.M{"$in": candidates}, "$or": []bson.M{
+			{"private": true, "nick": bson.M{"$nin": append([]string{viewerNick}, viewer.Following...)}},
+			{"blocked": viewerNick},
+		}}
+	}
+
+	cursor, err := repository.users.Find(context.TODO(), filter, options.Find().SetProjection(bson.M{"nick": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []entities.User{}
+	if err = cursor.All(context.TODO(), &users); err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		hidden = append(hidden, user.Nick)
+	}
+
+	blocked := map[string]bool{}
+	for _, nick := range viewer.Blocked {
+		blocked[nick] = true
+	}
+	for _, candidate := range candidates {
+		if nick, ok := candidate.(string); ok && blocked[nick] {
+			hidden = append(hidden, nick)
+		}
+	}
+
+	return hidden, nil
 }
 
+// UpdatePost replaces the content of the post, keeping the version it had as a revision
 func (repository *PostsRepository) UpdatePost(postID string, updatedPost entities.Post) error {
 	idString, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
 		return err
 	}
 
-	update := bson.M{"$set": bson.M{"title": updatedPost.Title, "content": updatedPost.Content, "updatedAt": time.Now()}}
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{"title": updatedPost.Title, "content": updatedPost.Content, "mediaIds": updatedPost.MediaIDs, "tags": updatedPost.Tags, "mentions": updatedPost.Mentions, "visibility": updatedPost.Visibility, "edited": true, "updatedAt": now},
+		"$inc": bson.M{"editCount": 1},
+	}
+
+	// the post is read as it was before the update, so a concurrent edit can't slip between both
+	var previous entities.Post
+	err = repository.collection.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": idString, "deleted": bson.M{"$ne": true}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return fmt.Errorf("This post doens't exists")
+	}
+	if err != nil {
+		return err
+	}
+
+	writtenAt := previous.UpdatedAt
+	if writtenAt.IsZero() {
+		writtenAt = previous.CreatedAt
+	}
 
-	_, err = repository.collection.UpdateOne(context.TODO(), bson.M{"_id": idString}, update)
+	_, err = repository.revisions.InsertOne(context.TODO(), entities.PostRevision{
+		PostID:     postID,
+		Title:      previous.Title,
+		Content:    previous.Content,
+		MediaIDs:   previous.MediaIDs,
+		WrittenAt:  writtenAt,
+		ReplacedAt: now,
+	})
 	if err != nil {
+		// an edit isn't kept without its revision, it's undone unless another edit came meanwhile
+		restore := bson.M{"title": previous.Title, "content": previous.Content, "mediaIds": previous.MediaIDs, "tags": previous.Tags, "mentions": previous.Mentions, "edited": previous.Edited}
+		unset := bson.M{}
+
+		// legacy posts have neither, and an empty visibility would hide them from everyone
+		if previous.Visibility == "" {
+			unset["visibility"] = ""
+		} else {
+			restore["visibility"] = previous.Visibility
+		}
+		if previous.UpdatedAt.IsZero() {
+			unset["updatedAt"] = ""
+		} else {
+			restore["updatedAt"] = previous.UpdatedAt
+		}
+
+		undo := bson.M{"$set": restore, "$inc": bson.M{"editCount": -1}}
+		if len(unset) > 0 {
+			undo["$unset"] = unset
+		}
+
+		if _, undoErr := repository.collection.UpdateOne(context.TODO(), bson.M{"_id": idString, "updatedAt": now}, undo); undoErr != nil {
+			return fmt.Errorf("%w, and the edit couldn't be undone: %s", err, undoErr)
+		}
 		return err
 	}
 
 	return nil
 }
 
-func (repository *PostsRepository) DeletePost(postID string) error {
+// GetDrafts returns the drafts and scheduled posts of the user, the most recent first
+func (repository *PostsRepository) GetDrafts(nick string, page int, limit int) ([]entities.Post, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"authorNick": nick, "status": bson.M{"$exists": true}, "deletedAt": bson.M{"$exists": false}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// GetAuthorPosts returns every post the user wrote, published or not and including the ones on the trash, the
+// oldest first
+func (repository *PostsRepository) GetAuthorPosts(nick string, page int, limit int) ([]entities.Post, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": 1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"authorNick": nick, "deleted": bson.M{"$ne": true}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// UpdateDraft replaces the content and the schedule of a post not published yet, drafts keep no revisions
+func (repository *PostsRepository) UpdateDraft(postID string, updatedPost entities.Post) error {
 	idString, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
 		return err
 	}
 
-	_, err = repository.collection.DeleteOne(context.TODO(), bson.M{"_id": idString})
+	set := bson.M{"title": updatedPost.Title, "content": updatedPost.Content, "mediaIds": updatedPost.MediaIDs, "tags": updatedPost.Tags, "mentions": updatedPost.Mentions, "visibility": updatedPost.Visibility, "status": updatedPost.Status, "updatedAt": time.Now()}
+	update := bson.M{"$set": set}
+	unset := bson.M{}
+	if updatedPost.PublishAt != nil {
+		set["publishAt"] = updatedPost.PublishAt
+	} else {
+		unset["publishAt"] = ""
+	}
+	if updatedPost.Poll != nil {
+		set["poll"] = updatedPost.Poll
+	} else {
+		unset["poll"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(), bson.M{"_id": idString, "status": bson.M{"$exists": true}, "deletedAt": bson.M{"$exists": false}}, update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("This post doens't exists")
+	}
+
 	return nil
 }
 
-func (repository *PostsRepository) GetAllPosts() ([]entities.Post, error) {
-	cursor, err := repository.collection.Find(context.Background(), bson.M{})
+// PublishPost publishes a draft or scheduled post right away. The status is taken off in a single update, so
+// a post published at the same time by the scheduler is only published once
+func (repository *PostsRepository) PublishPost(postID string) (entities.Post, error) {
+	idString, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
-		return nil, err
+		return entities.Post{}, err
 	}
-	defer cursor.Close(context.Background())
 
-	var posts []entities.Post
+	now := time.Now()
+	var post entities.Post
+	err = repository.collection.FindOneAndUpdate(context.TODO(),
+		bson.M{"_id": idString, "status": bson.M{"$exists": true}, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"createdAt": now, "updatedAt": now}, "$unset": bson.M{"status": "", "publishAt": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&post)
+	if err == mongo.ErrNoDocuments {
+		return entities.Post{}, fmt.Errorf("This post doens't exists")
+	}
+	if err != nil {
+		return entities.Post{}, err
+	}
 
-	for cursor.Next(context.Background()) {
+	// the post is already published, so failing to count it doesn't fail the request
+	if err = repository.onPublish(post); err != nil {
+		log.Printf("could not count the published post %s: %s", post.ID, err)
+	}
+
+	return post, nil
+}
+
+// PublishDuePosts publishes up to limit scheduled posts whose time came, dated when they were scheduled for.
+// Each post is taken off the schedule in a single update, so two instances never publish the same post.
+// Posts whose poll would close less than entities.MinPollDuration after now go back to the drafts instead
+func (repository *PostsRepository) PublishDuePosts(now time.Time, limit int) ([]entities.Post, error) {
+	posts := []entities.Post{}
+
+	due := bson.M{"status": entities.PostStatusScheduled, "publishAt": bson.M{"$lte": now}, "deletedAt": bson.M{"$exists": false}}
+	pollTooShort := bson.M{"$lt": now.Add(entities.MinPollDuration)}
+
+	_, err := repository.collection.UpdateMany(context.TODO(),
+		bson.M{"$and": []bson.M{due, {"poll.expiresAt": pollTooShort}}},
+		bson.M{"$set": bson.M{"status": entities.PostStatusDraft, "updatedAt": now}, "$unset": bson.M{"publishAt": ""}},
+	)
+	if err != nil {
+		return posts, err
+	}
+
+	for len(posts) < limit {
 		var post entities.Post
-		if err := cursor.Decode(&post); err != nil {
-			return nil, err
+		err := repository.collection.FindOneAndUpdate(context.TODO(),
+			bson.M{"$and": []bson.M{due, {"poll.expiresAt": bson.M{"$not": pollTooShort}}}},
+			[]bson.M{
+				{"$set": bson.M{"createdAt": "$publishAt", "updatedAt": now}},
+				{"$unset": []string{"status", "publishAt"}},
+			},
+			options.FindOneAndUpdate().
+				SetSort(bson.M{"publishAt": 1}).
+				SetReturnDocument(options.After),
+		).Decode(&post)
+		if err == mongo.ErrNoDocuments {
+			break
 		}
+		if err != nil {
+			return posts, err
+		}
+
+		if err = repository.onPublish(post); err != nil {
+			log.Printf("could not count the published post %s: %s", post.ID, err)
+		}
+
 		posts = append(posts, post)
 	}
 
 	return posts, nil
 }
 
-func (repository *PostsRepository) Like(postID string) error {
+// GetRevisions returns the previous versions of the post, the most recent first
+func (repository *PostsRepository) GetRevisions(postID string, page int, limit int) ([]entities.PostRevision, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"replacedAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.revisions.Find(context.TODO(), bson.M{"postId": postID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	revisions := []entities.PostRevision{}
+	if err = cursor.All(context.TODO(), &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// DeletePost moves the post to the trash, where it can be restored until it's purged. Reposts have nothing to
+// restore, so they are removed right away
+func (repository *PostsRepository) DeletePost(postID string) error {
 	idString, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
 		return err
 	}
 
-	_, err = repository.collection.UpdateOne(context.TODO(), bson.M{"_id": idString}, bson.M{"$inc": bson.M{"likes": 1}})
+	var post entities.Post
+	err = repository.collection.FindOne(context.TODO(), bson.M{"_id": idString, "deleted": bson.M{"$ne": true}, "deletedAt": bson.M{"$exists": false}}).Decode(&post)
+	if err != nil {
+		return fmt.Errorf("This post doens't exists")
+	}
+
+	if post.RepostOf != "" {
+		return repository.purgePost(post)
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": time.Now()}},
+	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// a deleted post doesn't hold one of the author's pins
+	_, err = repository.users.UpdateOne(context.TODO(),
+		bson.M{"nick": post.AuthorNick, "pinnedPosts": postID},
+		bson.M{"$pull": bson.M{"pinnedPosts": postID}},
+	)
+	return err
+}
+
+// GetTrash returns the posts the user deleted after since, the last deleted first. The posts deleted along with
+// the account aren't listed, they come back when the account is restored
+func (repository *PostsRepository) GetTrash(nick string, since time.Time, page int, limit int) ([]entities.Post, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"deletedAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	filter := bson.M{"authorNick": nick, "deletedAt": bson.M{"$gt": since}, "deletedWithAuthor": bson.M{"$exists": false}}
+	cursor, err := repository.collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
 }
 
-func (repository *PostsRepository) Dislike(postID string) error {
+// RestorePost takes the post of the user out of the trash, if it was deleted after since
+func (repository *PostsRepository) RestorePost(postID string, nick string, since time.Time) error {
 	idString, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
 		return err
 	}
 
-	var result entities.Post
-	err = repository.collection.FindOne(context.TODO(), bson.M{"_id": idString}).Decode(&result)
+	filter := bson.M{"_id": idString, "authorNick": nick, "deletedAt": bson.M{"$gt": since}, "deletedWithAuthor": bson.M{"$exists": false}}
+	result, err := repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$unset": bson.M{"deletedAt": ""}})
 	if err != nil {
 		return err
 	}
 
-	if result.Likes <= 0 {
-		return fmt.Errorf("Like count is already 0")
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("There's no post on the trash with this id")
 	}
 
-	update := bson.M{
-		"$inc": bson.M{"likes": -1},
+	return nil
+}
+
+// PurgeTrashedPosts removes for good up to limit posts moved to the trash before the given time
+func (repository *PostsRepository) PurgeTrashedPosts(before time.Time, limit int) (int, error) {
+	purged := 0
+
+	for purged < limit {
+		var post entities.Post
+		err := repository.collection.FindOne(context.TODO(), bson.M{"deletedAt": bson.M{"$lte": before}}).Decode(&post)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return purged, err
+		}
+
+		if err = repository.purgePost(post); err != nil {
+			return purged, err
+		}
+		purged++
 	}
 
-	_, err = repository.collection.UpdateOne(context.TODO(), bson.M{"_id": idString}, update)
+	return purged, nil
+}
+
+// PurgeAuthorPosts removes for good up to limit posts of the author, whatever their state. The ones with replies
+// are left as tombstones, without anything that tells who wrote them
+func (repository *PostsRepository) PurgeAuthorPosts(nick string, limit int) (int, error) {
+	purged := 0
+
+	for purged < limit {
+		var post entities.Post
+		err := repository.collection.FindOne(context.TODO(), bson.M{"authorNick": nick}).Decode(&post)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return purged, err
+		}
+
+		if err = repository.purgePost(post); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purgePost removes the post for good, along with what depends on it
+func (repository *PostsRepository) purgePost(post entities.Post) error {
+	idString, err := primitive.ObjectIDFromHex(post.ID)
 	if err != nil {
 		return err
 	}
+	postID := post.ID
 
-	return nil
+	// reposts and previous versions of a deleted post go away with it
+	if _, err = repository.collection.DeleteMany(context.TODO(), bson.M{"repostOf": postID}); err != nil {
+		return err
+	}
+
+	if _, err = repository.revisions.DeleteMany(context.TODO(), bson.M{"postId": postID}); err != nil {
+		return err
+	}
+
+	if _, err = repository.likes.DeleteMany(context.TODO(), bson.M{"postId": postID}); err != nil {
+		return err
+	}
+
+	if _, err = repository.reactions.DeleteMany(context.TODO(), bson.M{"postId": postID}); err != nil {
+		return err
+	}
+
+	if _, err = repository.bookmarks.DeleteMany(context.TODO(), bson.M{"postId": postID}); err != nil {
+		return err
+	}
+
+	if _, err = repository.votes.DeleteMany(context.TODO(), bson.M{"postId": postID}); err != nil {
+		return err
+	}
+
+	// a post with replies becomes a tombstone, so the conversation below it keeps its place
+	if post.ReplyCount > 0 {
+		update := bson.M{
+			"$set": bson.M{"deleted": true, "repostCount": 0, "updatedAt": time.Now()},
+			"$unset": bson.M{
+				"title": "", "content": "", "mediaIds": "", "tags": "", "mentions": "", "authorId": "", "authorNick": "", "likes": "", "legacyLikes": "", "legacyUnliked": "", "reactions": "", "quoteOf": "", "edited": "", "editCount": "", "poll": "", "deletedAt": "", "deletedWithAuthor": "",
+			},
+		}
+
+		if _, err = repository.collection.UpdateOne(context.TODO(), bson.M{"_id": idString}, update); err != nil {
+			return err
+		}
+	} else {
+		if _, err = repository.collection.DeleteOne(context.TODO(), bson.M{"_id": idString}); err != nil {
+			return err
+		}
+
+		// drafts and scheduled posts were never counted on the posts they reply and quote
+		if !post.Published() {
+			return nil
+		}
+
+		if err = repository.removeReply(post.ParentID); err != nil {
+			return err
+		}
+	}
+
+	if post.RepostOf != "" {
+		return repository.incrementCounter(post.RepostOf, "repostCount", -1)
+	}
+
+	if post.QuoteOf != "" {
+		return repository.incrementCounter(post.QuoteOf, "quoteCount", -1)
+	}
+
+	return nil
+}
+
+// removeReply decrements the reply count of the parent, removing the tombstones left without replies
+func (repository *PostsRepository) removeReply(parentID string) error {
+	for parentID != "" {
+		idString, err := primitive.ObjectIDFromHex(parentID)
+		if err != nil {
+			return err
+		}
+
+		var parent entities.Post
+		err = repository.collection.FindOneAndUpdate(
+			context.TODO(),
+			bson.M{"_id": idString, "replyCount": bson.M{"$gt": 0}},
+			bson.M{"$inc": bson.M{"replyCount": -1}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&parent)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !parent.Deleted || parent.ReplyCount > 0 {
+			return nil
+		}
+
+		if _, err = repository.collection.DeleteOne(context.TODO(), bson.M{"_id": idString}); err != nil {
+			return err
+		}
+
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+// recordTagActivity counts the activity for the trends, failing to do so doesn't fail the request
+func (repository *PostsRepository) recordTagActivity(tags []string, activity string) {
+	if err := repository.trends.RecordTagActivity(tags, activity, time.Now()); err != nil {
+		log.Printf("could not record the %s of the tags %v: %s", activity, tags, err)
+	}
+}
+
+// recordPostActivity counts the engagement on a post for its tags
+func (repository *PostsRepository) recordPostActivity(postID string, activity string) {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return
+	}
+
+	var post entities.Post
+	err = repository.collection.FindOne(context.TODO(), bson.M{"_id": idString}, options.FindOne().SetProjection(bson.M{"tags": 1, "visibility": 1})).Decode(&post)
+	if err != nil {
+		log.Printf("could not find the tags of the post %s: %s", postID, err)
+		return
+	}
+
+	// only the posts on the public listings can trend
+	if post.Visibility != "" && post.Visibility != entities.VisibilityPublic {
+		return
+	}
+
+	repository.recordTagActivity(post.Tags, activity)
+}
+
+func (repository *PostsRepository) incrementCounter(postID string, counter string, value int) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": idString}
+	if value < 0 {
+		filter[counter] = bson.M{"$gt": 0}
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$inc": bson.M{counter: value}})
+	return err
+}
+
+func (repository *PostsRepository) GetAllPosts(viewerNick string) ([]entities.Post, error) {
+	visible, err := repository.visibleTo(viewerNick, true, bson.M{"deleted": bson.M{"$ne": true}})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := repository.collection.Find(context.Background(), bson.M{"deleted": bson.M{"$ne": true}, "$or": visible})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var posts []entities.Post
+
+	for cursor.Next(context.Background()) {
+		var post entities.Post
+		if err := cursor.Decode(&post); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// Like counts the like of the user on the post, liking a post again doesn't count twice
+func (repository *PostsRepository) Like(postID string, nick string) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": idString, "deleted": bson.M{"$ne": true}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}}
+	count, err := repository.collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("This post doens't exists")
+	}
+
+	_, err = repository.likes.InsertOne(context.TODO(), entities.PostLike{PostID: postID, Nick: nick, CreatedAt: time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$inc": bson.M{"likes": 1}}); err != nil {
+		return err
+	}
+
+	repository.recordPostActivity(postID, entities.ActivityLike)
+
+	return nil
+}
+
+// Dislike undoes the like of the user on the post
+func (repository *PostsRepository) Dislike(postID string, nick string) error {
+	result, err := repository.likes.DeleteOne(context.TODO(), bson.M{"postId": postID, "nick": nick})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return repository.removeLegacyLike(postID, nick)
+	}
+
+	return repository.removeLike(postID)
+}
+
+// removeLegacyLike takes back one of the likes given before who liked was recorded, each user can take back
+// only one of them. A user who liked back then can also like again, as there's no way to tell them apart
+func (repository *PostsRepository) removeLegacyLike(postID string, nick string) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, "legacyLikes": bson.M{"$gt": 0}, "likes": bson.M{"$gt": 0}, "legacyUnliked": bson.M{"$ne": nick}},
+		bson.M{"$inc": bson.M{"likes": -1, "legacyLikes": -1}, "$push": bson.M{"legacyUnliked": nick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("You didn't like this post")
+	}
+
+	return nil
+}
+
+// legacyLikesMigration marks the migration of the likes given before who liked was recorded
+const legacyLikesMigration = "legacy-likes"
+
+// MigrateLegacyLikes records on every post how many of its likes were given before who liked was recorded,
+// as the difference between the counter and the recorded likes. Those likes stay counted, even when the
+// account that gave them is deleted. It runs once, later calls return right away
+func (repository *PostsRepository) MigrateLegacyLikes() (int, error) {
+	count, err := repository.migrations.CountDocuments(context.TODO(), bson.M{"_id": legacyLikesMigration})
+	if err != nil || count > 0 {
+		return 0, err
+	}
+
+	cursor, err := repository.collection.Find(context.TODO(),
+		bson.M{"likes": bson.M{"$gt": 0}, "legacyLikes": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"likes": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	migrated := 0
+	for cursor.Next(context.TODO()) {
+		var post entities.Post
+		if err = cursor.Decode(&post); err != nil {
+			return migrated, err
+		}
+
+		recorded, err := repository.likes.CountDocuments(context.TODO(), bson.M{"postId": post.ID})
+		if err != nil {
+			return migrated, err
+		}
+
+		legacyLikes := post.Likes - int(recorded)
+		if legacyLikes <= 0 {
+			continue
+		}
+
+		idString, _ := primitive.ObjectIDFromHex(post.ID)
+		_, err = repository.collection.UpdateOne(context.TODO(),
+			bson.M{"_id": idString, "legacyLikes": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"legacyLikes": legacyLikes}},
+		)
+		if err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	if err = cursor.Err(); err != nil {
+		return migrated, err
+	}
+
+	_, err = repository.migrations.InsertOne(context.TODO(), bson.M{"_id": legacyLikesMigration, "ranAt": time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		err = nil
+	}
+
+	return migrated, err
+}
+
+// GetLikesBy returns the likes of the user, the most recent first
+func (repository *PostsRepository) GetLikesBy(nick string, page int, limit int) ([]entities.PostLike, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.likes.Find(context.TODO(), bson.M{"nick": nick}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	likes := []entities.PostLike{}
+	if err = cursor.All(context.TODO(), &likes); err != nil {
+		return nil, err
+	}
+
+	return likes, nil
+}
+
+// Vote records the options the user chose on the poll of the post. Voting again the same options does
+// nothing, while a different vote is refused, as a vote can't be changed
+func (repository *PostsRepository) Vote(postID string, nick string, chosen []int) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = repository.votes.InsertOne(context.TODO(), entities.PollVote{PostID: postID, Nick: nick, Options: chosen, CreatedAt: now})
+	if mongo.IsDuplicateKeyError(err) {
+		var previous entities.PollVote
+		if err = repository.votes.FindOne(context.TODO(), bson.M{"postId": postID, "nick": nick}).Decode(&previous); err != nil {
+			return err
+		}
+
+		if !sameOptions(previous.Options, chosen) {
+			return fmt.Errorf("You already voted on this poll")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	inc := bson.M{"poll.voterCount": 1}
+	for _, option := range chosen {
+		inc[fmt.Sprintf("poll.options.%d.votes", option)] = 1
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, "poll.expiresAt": bson.M{"$gt": now}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$inc": inc},
+	)
+	if err == nil && result.MatchedCount == 0 {
+		err = fmt.Errorf("This poll is closed")
+	}
+	if err != nil {
+		// the vote didn't count, so it's taken back
+		repository.votes.DeleteOne(context.TODO(), bson.M{"postId": postID, "nick": nick})
+		return err
+	}
+
+	return nil
+}
+
+func sameOptions(options []int, otherOptions []int) bool {
+	if len(options) != len(otherOptions) {
+		return false
+	}
+
+	for i := range options {
+		if options[i] != otherOptions[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetPollVotes returns the options the user voted on, by post, among the given posts
+func (repository *PostsRepository) GetPollVotes(nick string, postIDs []string) (map[string][]int, error) {
+	cursor, err := repository.votes.Find(context.TODO(), bson.M{"nick": nick, "postId": bson.M{"$in": postIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	votes := []entities.PollVote{}
+	if err = cursor.All(context.TODO(), &votes); err != nil {
+		return nil, err
+	}
+
+	votesByPost := map[string][]int{}
+	for _, vote := range votes {
+		votesByPost[vote.PostID] = vote.Options
+	}
+
+	return votesByPost, nil
+}
+
+// GetVotesBy returns the poll votes of the user, the most recent first
+func (repository *PostsRepository) GetVotesBy(nick string, page int, limit int) ([]entities.PollVote, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.votes.Find(context.TODO(), bson.M{"nick": nick}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	votes := []entities.PollVote{}
+	if err = cursor.All(context.TODO(), &votes); err != nil {
+		return nil, err
+	}
+
+	return votes, nil
+}
+
+// RemoveVotesBy takes back up to limit votes of the user, discounting them from their polls
+func (repository *PostsRepository) RemoveVotesBy(nick string, limit int) (int, error) {
+	removed := 0
+
+	for removed < limit {
+		var vote entities.PollVote
+		err := repository.votes.FindOneAndDelete(context.TODO(), bson.M{"nick": nick}).Decode(&vote)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return removed, err
+		}
+
+		if err = repository.removeVote(vote); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// removeVote decrements the voters of the poll and the votes of the options chosen
+func (repository *PostsRepository) removeVote(vote entities.PollVote) error {
+	idString, err := primitive.ObjectIDFromHex(vote.PostID)
+	if err != nil {
+		return err
+	}
+
+	inc := bson.M{"poll.voterCount": -1}
+	for _, option := range vote.Options {
+		inc[fmt.Sprintf("poll.options.%d.votes", option)] = -1
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, "poll.voterCount": bson.M{"$gt": 0}},
+		bson.M{"$inc": inc},
+	)
+	return err
+}
+
+// React leaves the reaction of the user on the post, reacting again with the same type does nothing
+func (repository *PostsRepository) React(postID string, nick string, reaction string) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": idString, "deleted": bson.M{"$ne": true}, "status": bson.M{"$exists": false}, "deletedAt": bson.M{"$exists": false}}
+	count, err := repository.collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("This post doens't exists")
+	}
+
+	_, err = repository.reactions.InsertOne(context.TODO(), entities.PostReaction{PostID: postID, Nick: nick, Type: reaction, CreatedAt: time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$inc": bson.M{"reactions." + reaction: 1}})
+	return err
+}
+
+// Unreact takes back the reaction of the user on the post
+func (repository *PostsRepository) Unreact(postID string, nick string, reaction string) error {
+	result, err := repository.reactions.DeleteOne(context.TODO(), bson.M{"postId": postID, "nick": nick, "type": reaction})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("You didn't react with %s to this post", reaction)
+	}
+
+	return repository.removeReaction(postID, reaction)
+}
+
+// GetReactions returns who reacted to the post, the most recent first. An empty reaction type lists all of them
+func (repository *PostsRepository) GetReactions(postID string, reaction string, page int, limit int) ([]entities.PostReaction, error) {
+	filter := bson.M{"postId": postID}
+	if reaction != "" {
+		filter["type"] = reaction
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.reactions.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	reactions := []entities.PostReaction{}
+	if err = cursor.All(context.TODO(), &reactions); err != nil {
+		return nil, err
+	}
+
+	return reactions, nil
+}
+
+// GetOwnReactions returns the reaction types the user left, by post, among the given posts
+func (repository *PostsRepository) GetOwnReactions(nick string, postIDs []string) (map[string][]string, error) {
+	findOptions := options.Find().SetSort(bson.M{"createdAt": 1})
+
+	cursor, err := repository.reactions.Find(context.TODO(), bson.M{"nick": nick, "postId": bson.M{"$in": postIDs}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	reactions := []entities.PostReaction{}
+	if err = cursor.All(context.TODO(), &reactions); err != nil {
+		return nil, err
+	}
+
+	reactionsByPost := map[string][]string{}
+	for _, reaction := range reactions {
+		reactionsByPost[reaction.PostID] = append(reactionsByPost[reaction.PostID], reaction.Type)
+	}
+
+	return reactionsByPost, nil
+}
+
+// GetReactionsBy returns the reactions of the user, the most recent first
+func (repository *PostsRepository) GetReactionsBy(nick string, page int, limit int) ([]entities.PostReaction, error) {
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.reactions.Find(context.TODO(), bson.M{"nick": nick}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	reactions := []entities.PostReaction{}
+	if err = cursor.All(context.TODO(), &reactions); err != nil {
+		return nil, err
+	}
+
+	return reactions, nil
+}
+
+// RemoveReactionsBy takes back up to limit reactions of the user, the most recent first
+func (repository *PostsRepository) RemoveReactionsBy(nick string, limit int) (int, error) {
+	removed := 0
+
+	for removed < limit {
+		var reaction entities.PostReaction
+		err := repository.reactions.FindOneAndDelete(context.TODO(), bson.M{"nick": nick}).Decode(&reaction)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return removed, err
+		}
+
+		if err = repository.removeReaction(reaction.PostID, reaction.Type); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// removeReaction decrements the counter of the reaction type, dropping it once nobody reacted with it
+func (repository *PostsRepository) removeReaction(postID string, reaction string) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	counter := "reactions." + reaction
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, counter: bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{counter: -1}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, counter: bson.M{"$lte": 0}},
+		bson.M{"$unset": bson.M{counter: ""}},
+	)
+	return err
+}
+
+// RemoveLikesBy undoes up to limit likes of the user, the most recent first. The legacy likes aren't known
+// to be the user's, so they stay counted
+func (repository *PostsRepository) RemoveLikesBy(nick string, limit int) (int, error) {
+	removed := 0
+
+	for removed < limit {
+		var like entities.PostLike
+		err := repository.likes.FindOneAndDelete(context.TODO(), bson.M{"nick": nick}).Decode(&like)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			return removed, err
+		}
+
+		if err = repository.removeLike(like.PostID); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+func (repository *PostsRepository) removeLike(postID string) error {
+	idString, err := primitive.ObjectIDFromHex(postID)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"_id": idString, "likes": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"likes": -1}},
+	)
+	return err
+}
+
+func (repository *PostsRepository) GetReplies(postID string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	visible, err := repository.visibleTo(viewerNick, false, bson.M{"parentId": postID})
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": 1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"parentId": postID, "$or": append(visible, trashedWithReplies)}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	replies := []entities.Post{}
+	if err = cursor.All(context.TODO(), &replies); err != nil {
+		return nil, err
+	}
+
+	return asTombstones(replies), nil
+}
+
+// GetConversation returns the first post of a conversation and every reply under it the viewer can read
+func (repository *PostsRepository) GetConversation(rootID string, viewerNick string) ([]entities.Post, error) {
+	idString, err := primitive.ObjectIDFromHex(rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := repository.visibleTo(viewerNick, false, bson.M{"$or": []bson.M{{"_id": idString}, {"rootId": rootID}}})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"$and": []bson.M{
+		{"$or": []bson.M{{"_id": idString}, {"rootId": rootID}}},
+		{"$or": append(visible, trashedWithReplies)},
+	}}
+	cursor, err := repository.collection.Find(context.TODO(), filter, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return asTombstones(posts), nil
+}
+
+func (repository *PostsRepository) Repost(postID string, nick string) (entities.Post, error) {
+	count, err := repository.collection.CountDocuments(context.TODO(), bson.M{"repostOf": postID, "authorNick": nick})
+	if err != nil {
+		return entities.Post{}, err
+	}
+
+	if count > 0 {
+		return entities.Post{}, errors.New("You already reposted this post")
+	}
+
+	repost := entities.Post{
+		AuthorID:   nick,
+		AuthorNick: nick,
+		RepostOf:   postID,
+		CreatedAt:  time.Now(),
+	}
+
+	result, err := repository.collection.InsertOne(context.TODO(), repost)
+	if mongo.IsDuplicateKeyError(err) {
+		return entities.Post{}, errors.New("You already reposted this post")
+	}
+	if err != nil {
+		return entities.Post{}, err
+	}
+
+	if insertedID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		repost.ID = insertedID.Hex()
+	}
+
+	if err = repository.incrementCounter(postID, "repostCount", 1); err != nil {
+		return entities.Post{}, err
+	}
+
+	repository.recordPostActivity(postID, entities.ActivityRepost)
+
+	return repost, nil
+}
+
+func (repository *PostsRepository) Unrepost(postID string, nick string) error {
+	result, err := repository.collection.DeleteOne(context.TODO(), bson.M{"repostOf": postID, "authorNick": nick})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("You haven't reposted this post")
+	}
+
+	return repository.incrementCounter(postID, "repostCount", -1)
+}
+
+func (repository *PostsRepository) GetPostsByIDs(ids []string) ([]entities.Post, error) {
+	objectIDs := []primitive.ObjectID{}
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return asTombstones(posts), nil
+}
+
+// GetBookmarkedPosts returns the bookmarks of the user on the collection, or all of them when collectionID is
+// empty, along with their posts, the most recent first. The bookmarks of posts the user can't read are left
+// out before paginating, so the pages come full
+func (repository *PostsRepository) GetBookmarkedPosts(nick string, collectionID string, page int, limit int) ([]entities.BookmarkedPost, error) {
+	filter := bson.M{"nick": nick}
+	if collectionID != "" {
+		filter["collectionId"] = collectionID
+	}
+
+	postIDs, err := repository.bookmarks.Distinct(context.TODO(), "postId", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	objectIDs := []primitive.ObjectID{}
+	for _, postID := range postIDs {
+		if id, ok := postID.(string); ok {
+			if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+				objectIDs = append(objectIDs, objectID)
+			}
+		}
+	}
+
+	visible, err := repository.visibleTo(nick, false, bson.M{"_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$sort": primitive.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{"$lookup": bson.M{
+			"from": repository.collection.Name(),
+			"let":  bson.M{"postId": bson.M{"$convert": bson.M{"input": "$postId", "to": "objectId", "onError": nil}}},
+			"pipeline": []bson.M{
+				{"$match": bson.M{"$expr": bson.M{"$eq": []string{"$_id", "$$postId"}}}},
+				{"$match": bson.M{"deleted": bson.M{"$ne": true}, "$or": visible}},
+			},
+			"as": "post",
+		}},
+		{"$unwind": "$post"},
+		{"$skip": (page - 1) * limit},
+		{"$limit": limit},
+	}
+
+	cursor, err := repository.bookmarks.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	bookmarked := []entities.BookmarkedPost{}
+	if err = cursor.All(context.TODO(), &bookmarked); err != nil {
+		return nil, err
+	}
+
+	return bookmarked, nil
+}
+
+// GetFeed returns the posts and reposts of the given users the viewer can read, newest first
+func (repository *PostsRepository) GetFeed(nicks []string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	visible, err := repository.visibleTo(viewerNick, false, bson.M{"authorNick": bson.M{"$in": nicks}})
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	filter := bson.M{"authorNick": bson.M{"$in": nicks}, "deleted": bson.M{"$ne": true}, "$or": visible}
+	cursor, err := repository.collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (repository *PostsRepository) GetPostsByTag(tag string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	visible, err := repository.visibleTo(viewerNick, true, bson.M{"tags": tag})
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"tags": tag, "deleted": bson.M{"$ne": true}, "$or": visible}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// SearchTags returns the tags starting with the prefix, the most used first. Only the public posts count, so
+// the suggestions don't reveal the tags of posts not everyone can read
+func (repository *PostsRepository) SearchTags(prefix string, limit int) ([]entities.Hashtag, error) {
+	tagFilter := bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"tags":       tagFilter,
+			"deleted":    bson.M{"$ne": true},
+			"visibility": bson.M{"$in": []interface{}{nil, entities.VisibilityPublic}},
+			"status":     bson.M{"$exists": false},
+			"deletedAt":  bson.M{"$exists": false},
+		}},
+		{"$unwind": "$tags"},
+		{"$match": bson.M{"tags": tagFilter}},
+		{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+		{"$sort": primitive.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit},
+		{"$project": bson.M{"_id": 0, "tag": "$_id", "count": 1}},
+	}
+
+	cursor, err := repository.collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	tags := []entities.Hashtag{}
+	if err = cursor.All(context.TODO(), &tags); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (repository *PostsRepository) GetMentions(nick string, viewerNick string, page int, limit int) ([]entities.Post, error) {
+	visible, err := repository.visibleTo(viewerNick, false, bson.M{"mentions.nick": nick})
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"mentions.nick": nick, "deleted": bson.M{"$ne": true}, "$or": visible}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	posts := []entities.Post{}
+	if err = cursor.All(context.TODO(), &posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
 }
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// tagStatsRetention is how long the buckets are kept, a bit more than the longest trends window and the one
+// before it, which is its baseline
+const tagStatsRetention = 15 * 24 * time.Hour
+
+type TrendsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTrendsRepository(db *mongo.Database) *TrendsRepository {
+	collection := db.Collection("tag_stats")
+
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    primitive.D{{Key: "tag", Value: 1}, {Key: "bucket", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"bucket": 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(tagStatsRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		log.Printf("could not create the tag stats indexes: %s", err)
+	}
+
+	// the buckets were kept for less time before, an existing index doesn't take the new retention by itself
+	err = db.RunCommand(context.Background(), primitive.D{
+		{Key: "collMod", Value: collection.Name()},
+		{Key: "index", Value: bson.M{"keyPattern": bson.M{"bucket": 1}, "expireAfterSeconds": int32(tagStatsRetention.Seconds())}},
+	}).Err()
+	if err != nil {
+		log.Printf("could not update the tag stats retention: %s", err)
+	}
+
+	return &TrendsRepository{
+		collection,
+	}
+}
+
+// RecordTagActivity increments the activity counter of each tag on the bucket of the given time
+func (repository *TrendsRepository) RecordTagActivity(tags []string, activity string, at time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	bucket := entities.TagBucket(at)
+
+	models := []mongo.WriteModel{}
+	for _, tag := range tags {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"tag": tag, "bucket": bucket}).
+			SetUpdate(bson.M{"$inc": bson.M{activity: 1}}).
+			SetUpsert(true),
+		)
+	}
+
+	_, err := repository.collection.BulkWrite(context.TODO(), models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (repository *TrendsRepository) GetTagStats(since time.Time) ([]entities.TagStats, error) {
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"bucket": bson.M{"$gte": since}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	stats := []entities.TagStats{}
+	if err = cursor.All(context.TODO(), &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -2,24 +2,80 @@ package repositories
 
 import (
 	"api/internal/domain/entities"
+	domain "api/internal/domain/repositories"
+	"api/internal/infrastructure/config"
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 )
 
+// deletedAccountNick owns the nick history of deleted accounts, it can't be taken as it isn't a valid nick
+const deletedAccountNick = "(deleted)"
+
+// nickChangeLease is how long a nick change is left to the request that started it before being resumed
+const nickChangeLease = 5 * time.Minute
+
 type UsersRepository struct {
-	collection *mongo.Collection
+	collection    *mongo.Collection
+	posts         *mongo.Collection
+	likes         *mongo.Collection
+	media         *mongo.Collection
+	exports       *mongo.Collection
+	bookmarks     *mongo.Collection
+	collections   *mongo.Collection
+	votes         *mongo.Collection
+	reactions     *mongo.Collection
+	nickHistory   *mongo.Collection
+	conversations *mongo.Collection
+	messages      *mongo.Collection
+	notifications *mongo.Collection
 }
 
 func NewUsersRepository(db *mongo.Database) *UsersRepository {
 	collection := db.Collection("users")
+
+	// nicks are unique ignoring case and look-alike characters, users created before the key existed are skipped
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.M{"nickKey": 1},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(
+			bson.M{"nickKey": bson.M{"$type": "string"}},
+		),
+	})
+	if err != nil {
+		log.Printf("could not create the users nickKey index: %s", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"deletedAt": 1},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		log.Printf("could not create the users deletedAt index: %s", err)
+	}
+
 	return &UsersRepository{
 		collection,
+		db.Collection("posts"),
+		db.Collection("post_likes"),
+		db.Collection("media"),
+		db.Collection("exports"),
+		db.Collection("bookmarks"),
+		db.Collection("bookmark_collections"),
+		db.Collection("poll_votes"),
+		db.Collection("post_reactions"),
+		db.Collection("nick_history"),
+		db.Collection("conversations"),
+		db.Collection("messages"),
+		db.Collection("notifications"),
 	}
 }
 
@@ -30,22 +86,40 @@ func (repository *UsersRepository) Create(user entities.User) (entities.User, er
 		return entities.User{}, errors.New("there is already a user with the same email")
 	}
 
-	err = repository.collection.FindOne(context.Background(), bson.M{"nick": user.Nick}).Decode(&existingUser)
+	nickKey := entities.NickKey(user.Nick)
+	err = repository.collection.FindOne(context.Background(), nickFilter(user.Nick, nickKey)).Decode(&existingUser)
 	if err == nil {
-		return entities.User{}, errors.New("there is already a user with the same nick")
+		return entities.User{}, domain.ErrNickTaken
+	}
+
+	if err = repository.checkNickReservation(user.Nick, ""); err != nil {
+		return entities.User{}, err
 	}
 
 	newUser := entities.User{
 		Name:      user.Name,
 		Nick:      user.Nick,
+		NickKey:   nickKey,
 		Email:     user.Email,
 		Password:  user.Password,
+		Bio:       user.Bio,
+		Location:  user.Location,
+		Website:   user.Website,
+		Pronouns:  user.Pronouns,
+		AvatarURL: user.AvatarURL,
+		BannerURL: user.BannerURL,
+		AvatarID:  user.AvatarID,
+		BannerID:  user.BannerID,
+		TechStack: user.TechStack,
 		CreatedAt: time.Now(),
 		Followers: []string{},
 		Following: []string{},
 	}
 
 	_, err = repository.collection.InsertOne(context.Background(), newUser)
+	if mongo.IsDuplicateKeyError(err) {
+		return entities.User{}, domain.ErrNickTaken
+	}
 	if err != nil {
 		return entities.User{}, err
 	}
@@ -65,7 +139,7 @@ func (repository *UsersRepository) SearchByEmail(email string) (entities.User, e
 }
 
 func (repository *UsersRepository) GetAllUsers() ([]entities.User, error) {
-	cursor, err := repository.collection.Find(context.Background(), bson.M{}, options.Find().SetProjection(bson.M{"password": 0}))
+	cursor, err := repository.collection.Find(context.Background(), bson.M{"deletedAt": bson.M{"$exists": false}}, options.Find().SetProjection(bson.M{"password": 0}))
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +163,10 @@ func (repository *UsersRepository) GetUserByNick(nick string) (entities.User, er
 
 	options := options.FindOne().SetProjection(bson.M{"password": 0})
 
-	err := repository.collection.FindOne(context.Background(), bson.M{"nick": nick}, options).Decode(&existingUser)
+	err := repository.collection.FindOne(context.Background(), bson.M{"nick": nick, "deletedAt": bson.M{"$exists": false}}, options).Decode(&existingUser)
+	if err == mongo.ErrNoDocuments {
+		return entities.User{}, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return entities.User{}, err
 	}
@@ -97,12 +174,25 @@ func (repository *UsersRepository) GetUserByNick(nick string) (entities.User, er
 	return existingUser, nil
 }
 
-func (repository *UsersRepository) UpdateUser(nick string, user entities.User) error {
-	update := bson.M{
-		"$set": bson.M{"name": user.Name, "nick": user.Nick, "email": user.Email, "updatedAt": time.Now()},
+func (repository *UsersRepository) GetUsersByNicks(nicks []string) ([]entities.User, error) {
+	options := options.Find().SetProjection(bson.M{"password": 0})
+
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"nick": bson.M{"$in": nicks}, "deletedAt": bson.M{"$exists": false}}, options)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []entities.User{}
+	if err = cursor.All(context.TODO(), &users); err != nil {
+		return nil, err
 	}
 
-	_, err := repository.collection.UpdateOne(context.Background(), bson.M{"nick": nick}, update)
+	return users, nil
+}
+
+func (repository *UsersRepository) UpdateUser(nick string, update entities.UserUpdate) error {
+	_, err := repository.collection.UpdateOne(context.Background(), bson.M{"nick": nick}, userUpdateFields(update, time.Now()))
 	if err != nil {
 		return err
 	}
@@ -110,13 +200,141 @@ func (repository *UsersRepository) UpdateUser(nick string, user entities.User) e
 	return nil
 }
 
+// userUpdateFields sets only the fields sent on the request, the ones sent empty are removed
+func userUpdateFields(update entities.UserUpdate, now time.Time) bson.M {
+	fields := bson.M{"updatedAt": now}
+	cleared := bson.M{}
+
+	setOrClear(fields, cleared, "name", update.Name)
+	setOrClear(fields, cleared, "email", update.Email)
+	setOrClear(fields, cleared, "bio", update.Bio)
+	setOrClear(fields, cleared, "location", update.Location)
+	setOrClear(fields, cleared, "website", update.Website)
+	setOrClear(fields, cleared, "pronouns", update.Pronouns)
+	setOrClear(fields, cleared, "avatarUrl", update.AvatarURL)
+	setOrClear(fields, cleared, "bannerUrl", update.BannerURL)
+	setOrClear(fields, cleared, "avatarId", update.AvatarID)
+	setOrClear(fields, cleared, "bannerId", update.BannerID)
+	if update.TechStack != nil {
+		if len(update.TechStack) == 0 {
+			cleared["techStack"] = ""
+		} else {
+			fields["techStack"] = update.TechStack
+		}
+	}
+
+	result := bson.M{"$set": fields}
+	if len(cleared) > 0 {
+		result["$unset"] = cleared
+	}
+
+	return result
+}
+
+// DeleteUser moves the account to the trash along with its posts, both are purged once the trash retention
+// passes. The posts deleted before keep their own deletion time
 func (repository *UsersRepository) DeleteUser(nick string) error {
-	_, err := repository.collection.DeleteOne(context.Background(), bson.M{"nick": nick})
+	now := time.Now()
+
+	_, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"nick": nick, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": now}},
+	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = repository.posts.UpdateMany(context.Background(),
+		bson.M{"authorNick": nick, "deletedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deletedAt": now, "deletedWithAuthor": true}},
+	)
+	return err
+}
+
+// RestoreUser brings back the account deleted after since, with the posts deleted along with it
+func (repository *UsersRepository) RestoreUser(nick string, since time.Time) error {
+	result, err := repository.collection.UpdateOne(context.Background(),
+		bson.M{"nick": nick, "deletedAt": bson.M{"$gt": since}, "deletionScheduled": bson.M{"$exists": false}},
+		bson.M{"$unset": bson.M{"deletedAt": ""}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("This account can't be restored anymore")
+	}
+
+	_, err = repository.posts.UpdateMany(context.Background(),
+		bson.M{"authorNick": nick, "deletedWithAuthor": true},
+		bson.M{"$unset": bson.M{"deletedAt": "", "deletedWithAuthor": ""}},
+	)
+	return err
+}
+
+// nickReferences are the lists of a user holding the nicks of other users
+var nickReferences = []string{"followers", "following", "followRequests", "blocked", "muted"}
+
+// nickReferenceUpdates rename the nick on the follows, follow requests, blocks and mutes of every other user
+func nickReferenceUpdates(currentNick string, newNick string) []mongo.WriteModel {
+	updates := []mongo.WriteModel{}
+	for _, field := range nickReferences {
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.M{field: currentNick}).
+			SetUpdate(bson.M{"$set": bson.M{field + ".$": newNick}}),
+		)
+	}
+
+	return updates
+}
+
+// RemoveReferences takes the nick out of the follows, follow requests, blocks and mutes of every other user
+func (repository *UsersRepository) RemoveReferences(nick string) error {
+	filter := []bson.M{}
+	pull := bson.M{}
+	for _, field := range nickReferences {
+		filter = append(filter, bson.M{field: nick})
+		pull[field] = nick
+	}
+
+	_, err := repository.collection.UpdateMany(context.Background(), bson.M{"$or": filter}, bson.M{"$pull": pull})
+	return err
+}
+
+// ReserveDeletedNick keeps the nick of a deleted account, and the nicks it had before, from being taken for the
+// reservation cooldown. The tokens are stateless, so this is what keeps the ones issued to the account from
+// acting for whoever takes the nick next
+func (repository *UsersRepository) ReserveDeletedNick(nick string) error {
+	now := time.Now()
+
+	// the previous nicks stop redirecting and no longer belong to anyone
+	_, err := repository.nickHistory.UpdateMany(context.Background(),
+		bson.M{"currentNick": nick},
+		bson.M{"$set": bson.M{"currentNick": deletedAccountNick, "redirectUntil": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.nickHistory.UpdateOne(context.Background(),
+		bson.M{"oldNick": nick, "newNick": "", "currentNick": deletedAccountNick},
+		bson.M{"$setOnInsert": entities.NickChange{
+			OldNick:       nick,
+			OldNickKey:    entities.NickKey(nick),
+			CurrentNick:   deletedAccountNick,
+			ChangedAt:     now,
+			RedirectUntil: now,
+			ReservedUntil: now.Add(config.NickReservationCooldown),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// PurgeUser removes the account document for good, once everything else of it is gone
+func (repository *UsersRepository) PurgeUser(nick string) error {
+	_, err := repository.collection.DeleteOne(context.Background(), bson.M{"nick": nick, "deletedAt": bson.M{"$exists": true}})
+	return err
 }
 
 func (repository *UsersRepository) Follow(followerID string, followedID string) error {
@@ -276,3 +494,1061 @@ func (repository *UsersRepository) UpdatePassword(nick string, password string)
 
 	return nil
 }
+
+func setOrClear(fields bson.M, cleared bson.M, key string, value *string) {
+	if value == nil {
+		return
+	}
+
+	if *value == "" {
+		cleared[key] = ""
+		return
+	}
+
+	fields[key] = *value
+}
+
+// ChangeNick gives the user the new nick and then rewrites the references to the old one. The change is
+// recorded on the nick history along with the step it's at, so when a step fails the nick stays changed and
+// ResumeNickChanges finishes rewriting the references later
+func (repository *UsersRepository) ChangeNick(currentNick string, newNick string) error {
+	newNickKey := entities.NickKey(newNick)
+
+	// a user may change only the case or look of the nick, so the own document doesn't count
+	existingUser := entities.User{}
+	filter := bson.M{"$and": []bson.M{nickFilter(newNick, newNickKey), {"nick": bson.M{"$ne": currentNick}}}}
+	err := repository.collection.FindOne(context.Background(), filter).Decode(&existingUser)
+	if err == nil {
+		return domain.ErrNickTaken
+	}
+
+	if err = repository.checkNickReservation(newNick, currentNick); err != nil {
+		return err
+	}
+
+	// a change rewriting the references of the previous one would be overtaken by this one
+	pending, err := repository.nickHistory.CountDocuments(context.Background(),
+		bson.M{"newNick": currentNick, "step": bson.M{"$exists": true}},
+	)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return domain.ErrNickChangePending
+	}
+
+	now := time.Now()
+	change := entities.NickChange{
+		OldNick:       currentNick,
+		OldNickKey:    entities.NickKey(currentNick),
+		NewNick:       newNick,
+		CurrentNick:   newNick,
+		ChangedAt:     now,
+		RedirectUntil: now.Add(config.NickRedirectGracePeriod),
+		ReservedUntil: now.Add(config.NickReservationCooldown),
+		Step:          entities.NickChangeSteps[0],
+		UpdatedAt:     now,
+	}
+
+	// the old nick is recorded first, so nobody can take it while the references are rewritten
+	result, err := repository.nickHistory.InsertOne(context.Background(), change)
+	if err != nil {
+		return err
+	}
+	change.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	if err = repository.runNickChangeStep(change, entities.NickChangeStepAccount); err != nil {
+		// the account kept its nick, so the change never happened
+		if _, deleteErr := repository.nickHistory.DeleteOne(context.Background(), bson.M{"_id": result.InsertedID}); deleteErr != nil {
+			return fmt.Errorf("%s, and the nick change couldn't be undone: %s", err, deleteErr)
+		}
+		return err
+	}
+
+	if err = repository.runNickChange(change, entities.NickChangeSteps[1]); err != nil {
+		log.Printf("could not rewrite the references of %s to %s, it's resumed later: %s", currentNick, newNick, err)
+	}
+
+	return nil
+}
+
+// ResumeNickChanges carries on up to limit nick changes interrupted for longer than nickChangeLease, it tells
+// how many of them finished. A change interrupted before the account took the new nick is dropped, as it
+// never happened
+func (repository *UsersRepository) ResumeNickChanges(limit int) (int, error) {
+	cursor, err := repository.nickHistory.Find(context.Background(),
+		bson.M{"step": bson.M{"$exists": true}, "updatedAt": bson.M{"$lt": time.Now().Add(-nickChangeLease)}},
+		options.Find().SetSort(bson.M{"updatedAt": 1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := []entities.NickChange{}
+	if err = cursor.All(context.Background(), &changes); err != nil {
+		return 0, err
+	}
+
+	finished := 0
+	for _, change := range changes {
+		objectID, err := primitive.ObjectIDFromHex(change.ID)
+		if err != nil {
+			return finished, err
+		}
+
+		step := change.Step
+		if step == entities.NickChangeStepAccount {
+			count, err := repository.collection.CountDocuments(context.Background(), bson.M{"nick": change.OldNick})
+			if err != nil {
+				return finished, err
+			}
+			if count > 0 {
+				if _, err = repository.nickHistory.DeleteOne(context.Background(), bson.M{"_id": objectID}); err != nil {
+					return finished, err
+				}
+				finished++
+				continue
+			}
+			step = entities.NickChangeSteps[1]
+		}
+
+		if err = repository.runNickChange(change, step); err != nil {
+			log.Printf("could not rewrite the references of %s to %s: %s", change.OldNick, change.NewNick, err)
+			continue
+		}
+		finished++
+	}
+
+	return finished, nil
+}
+
+// runNickChange runs the steps of the change from the given one on, recording each one it gets to
+func (repository *UsersRepository) runNickChange(change entities.NickChange, from string) error {
+	objectID, err := primitive.ObjectIDFromHex(change.ID)
+	if err != nil {
+		return err
+	}
+
+	steps := entities.NickChangeSteps
+	start := len(steps)
+	for i, step := range steps {
+		if step == from {
+			start = i
+			break
+		}
+	}
+
+	for i := start; i < len(steps); i++ {
+		update := bson.M{"$set": bson.M{"step": steps[i], "updatedAt": time.Now()}, "$unset": bson.M{"lastError": ""}}
+		if _, err = repository.nickHistory.UpdateOne(context.Background(), bson.M{"_id": objectID}, update); err != nil {
+			return err
+		}
+
+		if err = repository.runNickChangeStep(change, steps[i]); err != nil {
+			_, failErr := repository.nickHistory.UpdateOne(context.Background(),
+				bson.M{"_id": objectID},
+				bson.M{"$set": bson.M{"lastError": err.Error(), "updatedAt": time.Now()}},
+			)
+			if failErr != nil {
+				log.Printf("could not record the failed nick change of %s: %s", change.OldNick, failErr)
+			}
+			return fmt.Errorf("step %s: %s", steps[i], err)
+		}
+	}
+
+	_, err = repository.nickHistory.UpdateOne(context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$unset": bson.M{"step": "", "lastError": ""}},
+	)
+	return err
+}
+
+func (repository *UsersRepository) runNickChangeStep(change entities.NickChange, step string) error {
+	currentNick, newNick := change.OldNick, change.NewNick
+
+	switch step {
+	case entities.NickChangeStepAccount:
+		result, err := repository.collection.UpdateOne(context.Background(),
+			bson.M{"nick": currentNick},
+			bson.M{"$set": bson.M{"nick": newNick, "nickKey": entities.NickKey(newNick), "updatedAt": change.ChangedAt}},
+		)
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.ErrNickTaken
+		}
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return errors.New("This user doesn't exists")
+		}
+		return nil
+
+	case entities.NickChangeStepHistory:
+		// older records of the same user keep redirecting to the newest nick
+		_, err := repository.nickHistory.UpdateMany(context.Background(),
+			bson.M{"currentNick": currentNick},
+			bson.M{"$set": bson.M{"currentNick": newNick}},
+		)
+		return err
+
+	case entities.NickChangeStepRelationships:
+		_, err := repository.collection.BulkWrite(context.Background(), nickReferenceUpdates(currentNick, newNick))
+		return err
+
+	case entities.NickChangeStepPosts:
+		_, err := repository.posts.UpdateMany(context.Background(),
+			bson.M{"authorNick": currentNick},
+			bson.M{"$set": bson.M{"authorNick": newNick, "authorId": newNick}},
+		)
+		return err
+
+	case entities.NickChangeStepOwned:
+		_, err := repository.likes.UpdateMany(context.Background(),
+			bson.M{"nick": currentNick},
+			bson.M{"$set": bson.M{"nick": newNick}},
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = repository.media.UpdateMany(context.Background(),
+			bson.M{"ownerNick": currentNick},
+			bson.M{"$set": bson.M{"ownerNick": newNick}},
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, collection := range []*mongo.Collection{repository.exports, repository.bookmarks, repository.collections, repository.votes, repository.reactions} {
+			_, err = collection.UpdateMany(context.Background(),
+				bson.M{"nick": currentNick},
+				bson.M{"$set": bson.M{"nick": newNick}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case entities.NickChangeStepMentions:
+		// the content keeps the old @nick, only the link to the user follows the change
+		_, err := repository.posts.UpdateMany(context.Background(),
+			bson.M{"mentions.nick": currentNick},
+			bson.M{"$set": bson.M{"mentions.$[mention].nick": newNick}},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"mention.nick": currentNick}},
+			}),
+		)
+		return err
+
+	case entities.NickChangeStepNotifications:
+		return repository.changeNotificationsNick(currentNick, newNick)
+
+	case entities.NickChangeStepConversations:
+		return repository.changeConversationsNick(currentNick, newNick)
+	}
+
+	return nil
+}
+
+// changeNotificationsNick moves the inbox of the user to the new nick and renames them as actor, so the
+// grouped notifications don't point to a nick someone else may take
+func (repository *UsersRepository) changeNotificationsNick(currentNick string, newNick string) error {
+	_, err := repository.notifications.UpdateMany(context.Background(),
+		bson.M{"recipient": currentNick},
+		bson.M{"$set": bson.M{"recipient": newNick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.notifications.UpdateMany(context.Background(),
+		bson.M{"actor": currentNick},
+		bson.M{"$set": bson.M{"actor": newNick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.notifications.UpdateMany(context.Background(),
+		bson.M{"actors": currentNick},
+		bson.M{"$set": bson.M{"actors.$[a]": newNick}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"a": currentNick}},
+		}),
+	)
+	return err
+}
+
+func (repository *UsersRepository) changeConversationsNick(currentNick string, newNick string) error {
+	_, err := repository.messages.UpdateMany(context.Background(),
+		bson.M{"senderNick": currentNick},
+		bson.M{"$set": bson.M{"senderNick": newNick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.messages.UpdateMany(context.Background(),
+		bson.M{"deletedFor": currentNick},
+		bson.M{"$set": bson.M{"deletedFor.$": newNick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.conversations.UpdateMany(context.Background(),
+		bson.M{"lastMessage.senderNick": currentNick},
+		bson.M{"$set": bson.M{"lastMessage.senderNick": newNick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.conversations.UpdateMany(context.Background(),
+		bson.M{"createdBy": currentNick},
+		bson.M{"$set": bson.M{"createdBy": newNick}},
+	)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := repository.conversations.Find(context.Background(), bson.M{"participants.nick": currentNick})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	conversations := []entities.Conversation{}
+	if err = cursor.All(context.Background(), &conversations); err != nil {
+		return err
+	}
+
+	for _, conversation := range conversations {
+		set := bson.M{"participants.$[participant].nick": newNick}
+
+		// the key of a one-to-one conversation is made of both nicks
+		if conversation.Key != "" {
+			for _, participant := range conversation.Participants {
+				if participant.Nick != currentNick {
+					set["key"] = entities.DirectConversationKey(newNick, participant.Nick)
+				}
+			}
+		}
+
+		objectID, err := primitive.ObjectIDFromHex(conversation.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = repository.conversations.UpdateOne(context.Background(),
+			bson.M{"_id": objectID},
+			bson.M{"$set": set},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"participant.nick": currentNick}},
+			}),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) GetNickHistory(nick string) ([]entities.NickChange, error) {
+	cursor, err := repository.nickHistory.Find(context.Background(),
+		bson.M{"currentNick": nick},
+		options.Find().SetSort(bson.M{"changedAt": -1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	history := []entities.NickChange{}
+	if err = cursor.All(context.Background(), &history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (repository *UsersRepository) GetNickRedirect(oldNick string) (string, error) {
+	var nickChange entities.NickChange
+
+	filter := bson.M{"oldNick": oldNick, "redirectUntil": bson.M{"$gt": time.Now()}}
+	options := options.FindOne().SetSort(bson.M{"changedAt": -1})
+
+	err := repository.nickHistory.FindOne(context.Background(), filter, options).Decode(&nickChange)
+	if err != nil {
+		return "", err
+	}
+
+	return nickChange.CurrentNick, nil
+}
+
+// checkNickReservation fails when the nick was recently released by someone other than ownerNick
+func (repository *UsersRepository) checkNickReservation(nick string, ownerNick string) error {
+	filter := bson.M{
+		"oldNickKey":    entities.NickKey(nick),
+		"reservedUntil": bson.M{"$gt": time.Now()},
+		"currentNick":   bson.M{"$ne": ownerNick},
+	}
+
+	count, err := repository.nickHistory.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrNickReserved
+	}
+
+	return nil
+}
+
+// MigrateNickKeys sets nickKey on the users created before it existed. A user whose key is already taken by
+// another one keeps going without it, matched by the nick alone, until one of them changes nick
+func (repository *UsersRepository) MigrateNickKeys() (int, error) {
+	cursor, err := repository.collection.Find(context.TODO(),
+		bson.M{"nickKey": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"nick": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	migrated := 0
+	for cursor.Next(context.TODO()) {
+		var user entities.User
+		if err = cursor.Decode(&user); err != nil {
+			return migrated, err
+		}
+
+		_, err = repository.collection.UpdateOne(context.TODO(),
+			bson.M{"nick": user.Nick, "nickKey": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"nickKey": entities.NickKey(user.Nick)}},
+		)
+		if mongo.IsDuplicateKeyError(err) {
+			log.Printf("could not set the nickKey of %s, another user has the same one", user.Nick)
+			continue
+		}
+		if err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, cursor.Err()
+}
+
+// nickFilter matches the nick itself and, for users created after nickKey existed, any nick with the same key
+func nickFilter(nick string, nickKey string) bson.M {
+	return bson.M{"$or": []bson.M{{"nick": nick}, {"nickKey": nickKey}}}
+}
+
+func (repository *UsersRepository) Block(blockerID string, blockedID string) error {
+	filter := bson.M{
+		"nick":    blockerID,
+		"blocked": blockedID,
+	}
+
+	count, err := repository.collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("You already block this user")
+	}
+
+	// blocking also breaks the follow relationship on both directions
+	updateBlocker := bson.M{
+		"$addToSet": bson.M{"blocked": blockedID},
+		"$pull": bson.M{
+			"following":      blockedID,
+			"followers":      blockedID,
+			"followRequests": blockedID,
+		},
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(), bson.M{"nick": blockerID}, updateBlocker)
+	if err != nil {
+		return err
+	}
+
+	updateBlocked := bson.M{
+		"$pull": bson.M{
+			"following":      blockerID,
+			"followers":      blockerID,
+			"followRequests": blockerID,
+		},
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(), bson.M{"nick": blockedID}, updateBlocked)
+	if err != nil {
+		return err
+	}
+
+	// neither of them can read the posts of the other anymore, so their bookmarks go away
+	if err = repository.removeBookmarksOf(blockerID, blockedID); err != nil {
+		return err
+	}
+
+	return repository.removeBookmarksOf(blockedID, blockerID)
+}
+
+// removeBookmarksOf removes the bookmarks the user has on the posts of the author
+func (repository *UsersRepository) removeBookmarksOf(nick string, authorNick string) error {
+	pipeline := []bson.M{
+		{"$match": bson.M{"nick": nick}},
+		{"$lookup": bson.M{
+			"from": repository.posts.Name(),
+			"let":  bson.M{"postId": bson.M{"$convert": bson.M{"input": "$postId", "to": "objectId", "onError": nil}}},
+			"pipeline": []bson.M{
+				{"$match": bson.M{"$expr": bson.M{"$eq": []string{"$_id", "$$postId"}}, "authorNick": authorNick}},
+				{"$project": bson.M{"_id": 1}},
+			},
+			"as": "post",
+		}},
+		{"$match": bson.M{"post": bson.M{"$ne": []interface{}{}}}},
+		{"$project": bson.M{"_id": 1}},
+	}
+
+	cursor, err := repository.bookmarks.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	bookmarks := []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}{}
+	if err = cursor.All(context.TODO(), &bookmarks); err != nil {
+		return err
+	}
+
+	if len(bookmarks) == 0 {
+		return nil
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, bookmark := range bookmarks {
+		ids = append(ids, bookmark.ID)
+	}
+
+	_, err = repository.bookmarks.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+func (repository *UsersRepository) Unblock(unblockerID string, unblockedID string) error {
+	filter := bson.M{
+		"nick":    unblockerID,
+		"blocked": unblockedID,
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$pull": bson.M{"blocked": unblockedID}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("You don't block this user")
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) GetBlocked(userID string) ([]string, error) {
+	var result entities.User
+
+	options := options.FindOne().SetProjection(bson.M{"blocked": 1})
+	err := repository.collection.FindOne(context.TODO(), bson.M{"nick": userID}, options).Decode(&result)
+	if err != nil {
+		return []string{}, err
+	}
+
+	if result.Blocked == nil {
+		return []string{}, nil
+	}
+
+	return result.Blocked, nil
+}
+
+func (repository *UsersRepository) IsBlocked(userID string, otherID string) (bool, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"nick": userID, "blocked": otherID},
+			{"nick": otherID, "blocked": userID},
+		},
+	}
+
+	count, err := repository.collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (repository *UsersRepository) UpdatePrivacy(userID string, settings entities.PrivacySettings) error {
+	_, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"nick": userID},
+		bson.M{"$set": bson.M{"private": settings.Private, "dmFollowingOnly": settings.DMFollowingOnly, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if settings.Private {
+		return nil
+	}
+
+	// a public account has no reason to keep requests waiting
+	requests, err := repository.GetFollowRequests(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, requesterID := range requests {
+		if err = repository.AcceptFollowRequest(userID, requesterID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) RequestFollow(followerID string, followedID string) error {
+	filter := bson.M{
+		"nick": followedID,
+		"$or": []bson.M{
+			{"followers": followerID},
+			{"followRequests": followerID},
+		},
+	}
+
+	count, err := repository.collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("You already follow or requested to follow this user")
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"nick": followedID},
+		bson.M{"$addToSet": bson.M{"followRequests": followerID}},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) GetFollowRequests(userID string) ([]string, error) {
+	var result entities.User
+
+	options := options.FindOne().SetProjection(bson.M{"followRequests": 1})
+	err := repository.collection.FindOne(context.TODO(), bson.M{"nick": userID}, options).Decode(&result)
+	if err != nil {
+		return []string{}, err
+	}
+
+	if result.FollowRequests == nil {
+		return []string{}, nil
+	}
+
+	return result.FollowRequests, nil
+}
+
+func (repository *UsersRepository) AcceptFollowRequest(userID string, requesterID string) error {
+	filter := bson.M{
+		"nick":           userID,
+		"followRequests": requesterID,
+	}
+
+	update := bson.M{
+		"$pull":     bson.M{"followRequests": requesterID},
+		"$addToSet": bson.M{"followers": requesterID},
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("There is no follow request from this user")
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(),
+		bson.M{"nick": requesterID},
+		bson.M{"$addToSet": bson.M{"following": userID}},
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) RemoveFollowRequest(userID string, requesterID string) error {
+	filter := bson.M{
+		"nick":           userID,
+		"followRequests": requesterID,
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$pull": bson.M{"followRequests": requesterID}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("There is no follow request from this user")
+	}
+
+	return nil
+}
+
+// SearchUsers returns up to limit candidates for the query, the ranking is done by the caller.
+// Users blocked on any direction are left out and private accounts only show up for their
+// followers or when the query is exactly their nick
+func (repository *UsersRepository) SearchUsers(viewerID string, query string, limit int) ([]entities.UserSummary, error) {
+	queryKey := entities.NickKey(query)
+	if queryKey == "" {
+		return []entities.UserSummary{}, nil
+	}
+
+	blocked, err := repository.GetBlocked(viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := bson.M{
+		"blocked":   bson.M{"$ne": viewerID},
+		"deletedAt": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"private": bson.M{"$ne": true}},
+			{"followers": viewerID},
+			{"nickKey": queryKey},
+		},
+	}
+
+	// nicks starting with the query come first, then the ones only sharing its first character, where the
+	// typo tolerance is applied on the rest of the nick, and last the names with a word starting with it.
+	// Every step is sorted before taking what still fits on the limit, the nick ones use the nickKey index
+	steps := []struct {
+		filter bson.M
+		sort   bson.M
+	}{
+		{bson.M{"nickKey": bson.M{"$regex": "^" + regexp.QuoteMeta(queryKey)}}, bson.M{"nickKey": 1}},
+		{bson.M{"nickKey": bson.M{"$regex": "^" + regexp.QuoteMeta(string([]rune(queryKey)[0]))}}, bson.M{"nickKey": 1}},
+		{bson.M{"name": bson.M{"$regex": `(^|\s)` + regexp.QuoteMeta(strings.TrimSpace(query)), "$options": "i"}}, bson.M{"nick": 1}},
+	}
+
+	excluded := append([]string{viewerID}, blocked...)
+	results := []entities.UserSummary{}
+	for _, step := range steps {
+		if len(results) >= limit {
+			break
+		}
+
+		pipeline := []bson.M{
+			{"$match": bson.M{"nick": bson.M{"$nin": excluded}, "$and": []bson.M{visible, step.filter}}},
+			{"$sort": step.sort},
+			{"$limit": limit - len(results)},
+			{"$project": userSummaryProjection(viewerID)},
+		}
+
+		cursor, err := repository.collection.Aggregate(context.TODO(), pipeline)
+		if err != nil {
+			return nil, err
+		}
+
+		found := []entities.UserSummary{}
+		err = cursor.All(context.TODO(), &found)
+		cursor.Close(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range found {
+			excluded = append(excluded, user.Nick)
+		}
+		results = append(results, found...)
+	}
+
+	return results, nil
+}
+
+func userSummaryProjection(viewerID string) bson.M {
+	return bson.M{
+		"nick":             1,
+		"nickKey":          1,
+		"name":             1,
+		"bio":              1,
+		"avatarId":         1,
+		"avatarUrl":        1,
+		"techStack":        1,
+		"private":          1,
+		"followersCount":   bson.M{"$size": bson.M{"$ifNull": []interface{}{"$followers", []string{}}}},
+		"followedByViewer": bson.M{"$in": []interface{}{viewerID, bson.M{"$ifNull": []interface{}{"$followers", []string{}}}}},
+	}
+}
+
+// PinPost pins the post to the top of the user's profile, as long as the user has less than max pinned posts.
+// Pinning a post again does nothing
+func (repository *UsersRepository) PinPost(nick string, postID string, max int) error {
+	if max <= 0 {
+		return fmt.Errorf("It's not possible to pin posts")
+	}
+
+	// the limit is checked on the update itself, so concurrent pins can't go over it
+	filter := bson.M{"nick": nick, "pinnedPosts": bson.M{"$ne": postID}}
+	filter[fmt.Sprintf("pinnedPosts.%d", max-1)] = bson.M{"$exists": false}
+
+	result, err := repository.collection.UpdateOne(context.TODO(),
+		filter,
+		bson.M{"$push": bson.M{"pinnedPosts": bson.M{"$each": []string{postID}, "$position": 0}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	count, err := repository.collection.CountDocuments(context.TODO(), bson.M{"nick": nick, "pinnedPosts": postID})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return fmt.Errorf("It's not possible to pin more than %d posts", max)
+}
+
+func (repository *UsersRepository) UnpinPost(nick string, postID string) error {
+	result, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"nick": nick, "pinnedPosts": postID},
+		bson.M{"$pull": bson.M{"pinnedPosts": postID}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("This post isn't pinned")
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) Mute(muterID string, mutedID string) error {
+	filter := bson.M{
+		"nick":  muterID,
+		"muted": mutedID,
+	}
+
+	count, err := repository.collection.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("You already mute this user")
+	}
+
+	_, err = repository.collection.UpdateOne(context.TODO(), bson.M{"nick": muterID}, bson.M{"$addToSet": bson.M{"muted": mutedID}})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) Unmute(unmuterID string, unmutedID string) error {
+	filter := bson.M{
+		"nick":  unmuterID,
+		"muted": unmutedID,
+	}
+
+	result, err := repository.collection.UpdateOne(context.TODO(), filter, bson.M{"$pull": bson.M{"muted": unmutedID}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("You don't mute this user")
+	}
+
+	return nil
+}
+
+func (repository *UsersRepository) GetMuted(userID string) ([]string, error) {
+	var result entities.User
+
+	options := options.FindOne().SetProjection(bson.M{"muted": 1})
+	err := repository.collection.FindOne(context.TODO(), bson.M{"nick": userID}, options).Decode(&result)
+	if err != nil {
+		return []string{}, err
+	}
+
+	if result.Muted == nil {
+		return []string{}, nil
+	}
+
+	return result.Muted, nil
+}
+
+// GetSuggestionCandidates returns friends of friends and users sharing tech stack tags,
+// with the mutual connections count and the date of their last post
+func (repository *UsersRepository) GetSuggestionCandidates(userID string, limit int) ([]entities.UserSummary, error) {
+	var user entities.User
+	err := repository.collection.FindOne(context.TODO(), bson.M{"nick": userID}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	following := user.Following
+	if following == nil {
+		following = []string{}
+	}
+
+	techStack := user.TechStack
+	if techStack == nil {
+		techStack = []string{}
+	}
+
+	excluded := append([]string{userID}, following...)
+	excluded = append(excluded, user.Blocked...)
+	excluded = append(excluded, user.Muted...)
+
+	match := bson.M{
+		"nick":           bson.M{"$nin": excluded},
+		"blocked":        bson.M{"$ne": userID},
+		"followRequests": bson.M{"$ne": userID},
+		"deletedAt":      bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"followers": bson.M{"$in": following}},
+			{"techStack": bson.M{"$in": techStack}},
+		},
+	}
+
+	projection := userSummaryProjection(userID)
+	projection["mutualCount"] = 1
+	projection["lastPostAt"] = bson.M{"$arrayElemAt": []interface{}{"$lastPost.createdAt", 0}}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$addFields": bson.M{"mutualCount": bson.M{"$size": bson.M{"$setIntersection": []interface{}{
+			bson.M{"$ifNull": []interface{}{"$followers", []string{}}}, following,
+		}}}}},
+		{"$sort": bson.M{"mutualCount": -1}},
+		{"$limit": limit},
+		{"$lookup": bson.M{
+			"from": "posts",
+			"let":  bson.M{"nick": "$nick"},
+			"pipeline": []bson.M{
+				{"$match": bson.M{
+					"$expr":     bson.M{"$eq": []string{"$authorNick", "$$nick"}},
+					"status":    bson.M{"$exists": false},
+					"deletedAt": bson.M{"$exists": false},
+				}},
+				{"$sort": bson.M{"createdAt": -1}},
+				{"$limit": 1},
+				{"$project": bson.M{"createdAt": 1}},
+			},
+			"as": "lastPost",
+		}},
+		{"$project": projection},
+	}
+
+	cursor, err := repository.collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	candidates := []entities.UserSummary{}
+	if err = cursor.All(context.TODO(), &candidates); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// GetRelationships returns the relationship of the viewer with each of the existing users on nicks
+func (repository *UsersRepository) GetRelationships(viewerID string, nicks []string) ([]entities.Relationship, error) {
+	var viewer entities.User
+	err := repository.collection.FindOne(context.TODO(), bson.M{"nick": viewerID}).Decode(&viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	options := options.Find().SetProjection(bson.M{"nick": 1, "blocked": 1, "followRequests": 1})
+	cursor, err := repository.collection.Find(context.TODO(), bson.M{"nick": bson.M{"$in": nicks}, "deletedAt": bson.M{"$exists": false}}, options)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []entities.User{}
+	if err = cursor.All(context.TODO(), &users); err != nil {
+		return nil, err
+	}
+
+	relationships := []entities.Relationship{}
+	for _, user := range users {
+		relationships = append(relationships, entities.Relationship{
+			Nick:        user.Nick,
+			Following:   contains(viewer.Following, user.Nick),
+			FollowedBy:  contains(viewer.Followers, user.Nick),
+			Blocking:    contains(viewer.Blocked, user.Nick),
+			BlockedBy:   contains(user.Blocked, viewerID),
+			Muting:      contains(viewer.Muted, user.Nick),
+			Requested:   contains(user.FollowRequests, viewerID),
+			RequestedBy: contains(viewer.FollowRequests, user.Nick),
+		})
+	}
+
+	return relationships, nil
+}
+
+// GetMutuals returns the users that follow userID and are followed back
+func (repository *UsersRepository) GetMutuals(userID string) ([]string, error) {
+	var user entities.User
+
+	options := options.FindOne().SetProjection(bson.M{"followers": 1, "following": 1})
+	err := repository.collection.FindOne(context.TODO(), bson.M{"nick": userID}, options).Decode(&user)
+	if err != nil {
+		return []string{}, err
+	}
+
+	mutuals := []string{}
+	for _, follower := range user.Followers {
+		if contains(user.Following, follower) {
+			mutuals = append(mutuals, follower)
+		}
+	}
+
+	return mutuals, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (repository *UsersRepository) UpdateNotificationPreferences(userID string, disabled []string) error {
+	_, err := repository.collection.UpdateOne(context.TODO(),
+		bson.M{"nick": userID},
+		bson.M{"$set": bson.M{"disabledNotifications": disabled, "updatedAt": time.Now()}},
+	)
+	return err
+}
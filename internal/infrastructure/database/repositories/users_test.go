@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"api/internal/domain/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestNickReferenceUpdates(t *testing.T) {
+	updates := nickReferenceUpdates("old", "new")
+
+	renamed := map[string]bool{}
+	for _, update := range updates {
+		model, ok := update.(*mongo.UpdateManyModel)
+		assert.True(t, ok)
+
+		for field, nick := range model.Filter.(bson.M) {
+			assert.Equal(t, "old", nick)
+			assert.Equal(t, bson.M{"$set": bson.M{field + ".$": "new"}}, model.Update)
+			renamed[field] = true
+		}
+	}
+
+	for _, field := range []string{"followers", "following", "followRequests", "blocked", "muted"} {
+		assert.True(t, renamed[field], "%s isn't renamed", field)
+	}
+}
+
+func TestUserUpdateFields(t *testing.T) {
+	now := time.Now()
+	name, bio, website := "Gopher", "", ""
+
+	update := userUpdateFields(entities.UserUpdate{Name: &name, Bio: &bio, Website: &website, TechStack: []string{}}, now)
+
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"name": "Gopher", "updatedAt": now},
+		"$unset": bson.M{"bio": "", "website": "", "techStack": ""},
+	}, update)
+
+	update = userUpdateFields(entities.UserUpdate{TechStack: []string{"go"}}, now)
+
+	assert.Equal(t, bson.M{"$set": bson.M{"techStack": []string{"go"}, "updatedAt": now}}, update)
+}
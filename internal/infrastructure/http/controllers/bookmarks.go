@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/application/visibility"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/http/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type BookmarksController struct {
+	bookmarkRepository repositories.BookmarksRepository
+	postRepository     repositories.PostsRepository
+	userRepository     repositories.UsersRepository
+}
+
+func NewBookmarksController(bookmarkRepository repositories.BookmarksRepository, postRepository repositories.PostsRepository, userRepository repositories.UsersRepository) *BookmarksController {
+	return &BookmarksController{
+		bookmarkRepository,
+		postRepository,
+		userRepository,
+	}
+}
+
+type bookmarkRequest struct {
+	CollectionID string `json:"collectionId"`
+}
+
+// BookmarkPost saves the post for the user, on the collection sent if any. Bookmarking it again moves it to
+// the collection sent
+func (controller *BookmarksController) BookmarkPost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var request bookmarkRequest
+	if len(reqbody) > 0 {
+		if err = json.Unmarshal(reqbody, &request); err != nil {
+			responses.Error(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	post, err := controller.postRepository.GetVisiblePost(postID, userNick)
+	if err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	if post.RepostOf != "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to bookmark a repost, bookmark the original post instead"))
+		return
+	}
+
+	if post.AuthorNick != userNick {
+		users, err := controller.userRepository.GetUsersByNicks([]string{userNick, post.AuthorNick})
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		viewer, author := entities.User{}, entities.User{}
+		for _, user := range users {
+			if user.Nick == userNick {
+				viewer = user
+			}
+			if user.Nick == post.AuthorNick {
+				author = user
+			}
+		}
+
+		if !visibility.CanSee(viewer, author) {
+			responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+			return
+		}
+	}
+
+	if request.CollectionID != "" {
+		if _, err = controller.ownCollection(request.CollectionID, userNick); err != nil {
+			responses.Error(w, http.StatusNotFound, err)
+			return
+		}
+	}
+
+	if err = controller.bookmarkRepository.Bookmark(postID, userNick, request.CollectionID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *BookmarksController) RemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	if err = controller.bookmarkRepository.RemoveBookmark(postID, userNick); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// GetBookmarks lists the bookmarked posts of the user, the ones of a single collection when it's sent. The
+// posts in the trash, or the user can't see for now, are left out but keep their bookmark, as they may come
+// back. The bookmarks of posts gone for good are removed when the post is purged, or when a block keeps the
+// user from reading them
+func (controller *BookmarksController) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	collectionID := r.URL.Query().Get("collection")
+	if collectionID != "" {
+		if _, err = controller.ownCollection(collectionID, userNick); err != nil {
+			responses.Error(w, http.StatusNotFound, err)
+			return
+		}
+	}
+
+	page, limit := getPagination(r)
+
+	bookmarked, err := controller.postRepository.GetBookmarkedPosts(userNick, collectionID, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts := []entities.Post{}
+	for _, bookmark := range bookmarked {
+		posts = append(posts, bookmark.Post)
+	}
+
+	posts, err = resolvePostReferences(controller.postRepository, controller.userRepository, userNick, posts)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// reposts whose original can't be seen are left out
+	postsByID := map[string]entities.Post{}
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	resolved := []entities.BookmarkedPost{}
+	for _, bookmark := range bookmarked {
+		if post, found := postsByID[bookmark.PostID]; found {
+			bookmark.Post = post
+			resolved = append(resolved, bookmark)
+		}
+	}
+
+	responses.JSON(w, http.StatusOK, resolved)
+}
+
+func (controller *BookmarksController) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var collection entities.BookmarkCollection
+	if err = json.Unmarshal(reqbody, &collection); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = collection.Prepare(); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	collection.Nick = userNick
+
+	collection, err = controller.bookmarkRepository.CreateCollection(collection)
+	if err != nil {
+		responses.Error(w, http.StatusConflict, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, collection)
+}
+
+func (controller *BookmarksController) GetCollections(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	collections, err := controller.bookmarkRepository.GetCollections(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, collections)
+}
+
+func (controller *BookmarksController) RenameCollection(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	collectionID := params["collectionID"]
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var collection entities.BookmarkCollection
+	if err = json.Unmarshal(reqbody, &collection); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = collection.Prepare(); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err = controller.ownCollection(collectionID, userNick); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err = controller.bookmarkRepository.RenameCollection(collectionID, collection.Name); err != nil {
+		responses.Error(w, http.StatusConflict, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeleteCollection removes the collection, its bookmarks are kept outside of any collection
+func (controller *BookmarksController) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	collectionID := params["collectionID"]
+
+	if _, err = controller.ownCollection(collectionID, userNick); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err = controller.bookmarkRepository.DeleteCollection(collectionID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// ownCollection finds the collection, as long as it belongs to the user. Other's collections are private, so
+// they're reported as not existing
+func (controller *BookmarksController) ownCollection(collectionID string, nick string) (entities.BookmarkCollection, error) {
+	collection, err := controller.bookmarkRepository.GetCollection(collectionID)
+	if err != nil || collection.Nick != nick {
+		return entities.BookmarkCollection{}, errors.New("This collection doesn't exists")
+	}
+
+	return collection, nil
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBookmarkPost(t *testing.T) {
+	postID := "64a399cdb6a0487490ed730c"
+
+	tests := []struct {
+		name               string
+		input              string
+		post               entities.Post
+		expectedGetError   error
+		author             entities.User
+		collection         entities.BookmarkCollection
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on BookmarkPost",
+			input:              "",
+			post:               entities.Post{ID: postID, AuthorNick: "2"},
+			author:             entities.User{Nick: "2"},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Success on BookmarkPost, on a collection",
+			input:              `{"collectionId": "c1"}`,
+			post:               entities.Post{ID: postID, AuthorNick: "2"},
+			author:             entities.User{Nick: "2"},
+			collection:         entities.BookmarkCollection{ID: "c1", Nick: "1"},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on BookmarkPost, other's collection",
+			input:              `{"collectionId": "c1"}`,
+			post:               entities.Post{ID: postID, AuthorNick: "2"},
+			author:             entities.User{Nick: "2"},
+			collection:         entities.BookmarkCollection{ID: "c1", Nick: "2"},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on BookmarkPost, unexistent post",
+			input:              "",
+			expectedGetError:   errors.New("This post doens't exists"),
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on BookmarkPost, deleted post",
+			input:              "",
+			post:               entities.Post{ID: postID, Deleted: true},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on BookmarkPost, repost",
+			input:              "",
+			post:               entities.Post{ID: postID, AuthorNick: "2", RepostOf: "64a399cdb6a0487490ed730d"},
+			author:             entities.User{Nick: "2"},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on BookmarkPost, author blocked the user",
+			input:              "",
+			post:               entities.Post{ID: postID, AuthorNick: "2"},
+			author:             entities.User{Nick: "2", Blocked: []string{"1"}},
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postsMock := mocks.NewPostsRepositoryMock()
+			postsMock.On("GetVisiblePost", postID, "1").Return(test.post, test.expectedGetError)
+
+			usersMock := mocks.NewUsersRepositoryMock()
+			usersMock.On("GetUsersByNicks", mock.Anything).Return([]entities.User{{Nick: "1"}, test.author}, nil)
+
+			repositoryMock := mocks.NewBookmarksRepositoryMock()
+			repositoryMock.On("GetCollection", "c1").Return(test.collection, nil)
+			repositoryMock.On("Bookmark", postID, "1", mock.Anything).Return(nil)
+
+			bookmarksController := NewBookmarksController(repositoryMock, postsMock, usersMock)
+
+			req, _ := http.NewRequest("POST", "/posts/"+postID+"/bookmark", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": postID})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(bookmarksController.BookmarkPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if test.expectedStatusCode == 204 {
+				repositoryMock.AssertCalled(t, "Bookmark", postID, "1", test.collection.ID)
+			} else {
+				repositoryMock.AssertNotCalled(t, "Bookmark", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRemoveBookmark(t *testing.T) {
+	postID := "64a399cdb6a0487490ed730c"
+
+	tests := []struct {
+		name                string
+		validToken          string
+		expectedRemoveError error
+		expectedStatusCode  int
+	}{
+		{
+			name:                "Success on RemoveBookmark",
+			validToken:          ValidToken,
+			expectedRemoveError: nil,
+			expectedStatusCode:  204,
+		},
+		{
+			name:                "Error on RemoveBookmark, post not bookmarked",
+			validToken:          ValidToken,
+			expectedRemoveError: errors.New("You didn't bookmark this post"),
+			expectedStatusCode:  404,
+		},
+		{
+			name:                "Error on RemoveBookmark, invalid token",
+			validToken:          "invalid",
+			expectedRemoveError: nil,
+			expectedStatusCode:  401,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewBookmarksRepositoryMock()
+			repositoryMock.On("RemoveBookmark", postID, "1").Return(test.expectedRemoveError)
+
+			bookmarksController := NewBookmarksController(repositoryMock, mocks.NewPostsRepositoryMock(), mocks.NewUsersRepositoryMock())
+
+			req, _ := http.NewRequest("DELETE", "/posts/"+postID+"/bookmark", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": postID})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(bookmarksController.RemoveBookmark)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetBookmarks(t *testing.T) {
+	// the bookmarks of posts the user can't read are left out by the repository, r1 reposts a post that's gone
+	bookmarked := []entities.BookmarkedPost{
+		{Bookmark: entities.Bookmark{PostID: "p1", Nick: "1"}, Post: entities.Post{ID: "p1", AuthorNick: "2", Content: "kept"}},
+		{Bookmark: entities.Bookmark{PostID: "r1", Nick: "1"}, Post: entities.Post{ID: "r1", AuthorNick: "2", RepostOf: "p3"}},
+	}
+
+	postsMock := mocks.NewPostsRepositoryMock()
+	postsMock.On("GetBookmarkedPosts", "1", "", 1, 20).Return(bookmarked, nil)
+	postsMock.On("GetPostsByIDs", []string{"p3"}).Return([]entities.Post{}, nil)
+	postsMock.On("GetOwnReactions", "1", mock.Anything).Return(map[string][]string{}, nil)
+
+	usersMock := mocks.NewUsersRepositoryMock()
+	usersMock.On("GetUsersByNicks", mock.Anything).Return([]entities.User{{Nick: "1"}, {Nick: "2"}}, nil)
+
+	bookmarksController := NewBookmarksController(mocks.NewBookmarksRepositoryMock(), postsMock, usersMock)
+
+	req, _ := http.NewRequest("GET", "/bookmarks", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(bookmarksController.GetBookmarks)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, 200, rr.Code)
+
+	var result []entities.BookmarkedPost
+	json.Unmarshal(rr.Body.Bytes(), &result)
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "kept", result[0].Post.Content)
+}
+
+func TestCreateCollection(t *testing.T) {
+
+	tests := []struct {
+		name                string
+		input               string
+		expectedCreateError error
+		expectedStatusCode  int
+	}{
+		{
+			name:                "Success on CreateCollection",
+			input:               `{"name": " Reading list "}`,
+			expectedCreateError: nil,
+			expectedStatusCode:  201,
+		},
+		{
+			name:                "Error on CreateCollection, empty name",
+			input:               `{"name": "  "}`,
+			expectedCreateError: nil,
+			expectedStatusCode:  400,
+		},
+		{
+			name:                "Error on CreateCollection, name taken",
+			input:               `{"name": "Reading list"}`,
+			expectedCreateError: errors.New("There's already a collection with this name"),
+			expectedStatusCode:  409,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewBookmarksRepositoryMock()
+			repositoryMock.On("CreateCollection", entities.BookmarkCollection{Nick: "1", Name: "Reading list"}).
+				Return(entities.BookmarkCollection{ID: "c1", Nick: "1", Name: "Reading list"}, test.expectedCreateError)
+
+			bookmarksController := NewBookmarksController(repositoryMock, mocks.NewPostsRepositoryMock(), mocks.NewUsersRepositoryMock())
+
+			req, _ := http.NewRequest("POST", "/bookmarks/collections", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(bookmarksController.CreateCollection)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
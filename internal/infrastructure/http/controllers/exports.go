@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/http/responses"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type ExportsController struct {
+	exportRepository repositories.ExportsRepository
+	blobStore        repositories.BlobStore
+}
+
+func NewExportsController(exportRepository repositories.ExportsRepository, blobStore repositories.BlobStore) *ExportsController {
+	return &ExportsController{
+		exportRepository,
+		blobStore,
+	}
+}
+
+// CreateExport requests an export of everything the user has on the API. It's built in the background, the
+// returned export is checked on until it's ready to download
+func (controller *ExportsController) CreateExport(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible exporting other's data"))
+		return
+	}
+
+	export, err := controller.exportRepository.CreateExport(userID)
+	if err != nil {
+		responses.Error(w, http.StatusConflict, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusAccepted, export)
+}
+
+func (controller *ExportsController) GetExports(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible seeing other's exports"))
+		return
+	}
+
+	exports, err := controller.exportRepository.GetExports(userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, exports)
+}
+
+func (controller *ExportsController) GetExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := controller.ownExport(w, r)
+	if !ok {
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, export)
+}
+
+// DownloadExport sends the archive of an export that's ready and didn't expire yet
+func (controller *ExportsController) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := controller.ownExport(w, r)
+	if !ok {
+		return
+	}
+
+	// the export may expire before the cleanup marks it
+	if export.Status == entities.ExportStatusExpired || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		responses.Error(w, http.StatusGone, errors.New("This export expired"))
+		return
+	}
+
+	if export.Status != entities.ExportStatusReady {
+		responses.Error(w, http.StatusConflict, errors.New("This export isn't ready yet"))
+		return
+	}
+
+	archive, size, err := controller.blobStore.GetStream(export.Key)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.zip"`, export.Nick, export.CreatedAt.Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+
+	io.Copy(w, archive)
+}
+
+// ownExport finds the export on the URL, as long as it belongs to the user on the token
+func (controller *ExportsController) ownExport(w http.ResponseWriter, r *http.Request) (entities.DataExport, bool) {
+	params := mux.Vars(r)
+	userID := params["userID"]
+	exportID := params["exportID"]
+
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return entities.DataExport{}, false
+	}
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible seeing other's exports"))
+		return entities.DataExport{}, false
+	}
+
+	export, err := controller.exportRepository.GetExport(exportID)
+	if err != nil || export.Nick != userID {
+		responses.Error(w, http.StatusNotFound, errors.New("This export doesn't exists"))
+		return entities.DataExport{}, false
+	}
+
+	return export, true
+}
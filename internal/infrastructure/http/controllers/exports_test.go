@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExport(t *testing.T) {
+
+	tests := []struct {
+		name                string
+		validToken          string
+		expectedCreateError error
+		expectedStatusCode  int
+	}{
+		{
+			name:                "Success on CreateExport",
+			validToken:          ValidToken,
+			expectedCreateError: nil,
+			expectedStatusCode:  202,
+		},
+		{
+			name:                "Error on CreateExport, other's data",
+			validToken:          DiffToken,
+			expectedCreateError: nil,
+			expectedStatusCode:  403,
+		},
+		{
+			name:                "Error on CreateExport, export in progress",
+			validToken:          ValidToken,
+			expectedCreateError: errors.New("There's already an export in progress"),
+			expectedStatusCode:  409,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewExportsRepositoryMock()
+			repositoryMock.On("CreateExport", "1").Return(entities.DataExport{ID: "1", Nick: "1", Status: entities.ExportStatusPending}, test.expectedCreateError)
+
+			exportsController := NewExportsController(repositoryMock, mocks.NewBlobStoreMock())
+
+			req, _ := http.NewRequest("POST", "/users/1/export", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"userID": "1"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(exportsController.CreateExport)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetExport(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		validToken         string
+		savedExport        entities.DataExport
+		expectedGetError   error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetExport",
+			validToken:         ValidToken,
+			savedExport:        entities.DataExport{ID: "1", Nick: "1", Status: entities.ExportStatusRunning},
+			expectedGetError:   nil,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetExport, other's export",
+			validToken:         DiffToken,
+			savedExport:        entities.DataExport{ID: "1", Nick: "1", Status: entities.ExportStatusRunning},
+			expectedGetError:   nil,
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Error on GetExport, export of another user on the URL",
+			validToken:         ValidToken,
+			savedExport:        entities.DataExport{ID: "1", Nick: "2", Status: entities.ExportStatusRunning},
+			expectedGetError:   nil,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on GetExport, unexistent export",
+			validToken:         ValidToken,
+			savedExport:        entities.DataExport{},
+			expectedGetError:   errors.New("This export doesn't exists"),
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewExportsRepositoryMock()
+			repositoryMock.On("GetExport", "1").Return(test.savedExport, test.expectedGetError)
+
+			exportsController := NewExportsController(repositoryMock, mocks.NewBlobStoreMock())
+
+			req, _ := http.NewRequest("GET", "/users/1/exports/1", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"userID": "1", "exportID": "1"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(exportsController.GetExport)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestDownloadExport(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		status             string
+		expiresAt          time.Time
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on DownloadExport",
+			status:             entities.ExportStatusReady,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on DownloadExport, export not ready",
+			status:             entities.ExportStatusRunning,
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Error on DownloadExport, expired export",
+			status:             entities.ExportStatusExpired,
+			expectedStatusCode: 410,
+		},
+		{
+			name:               "Error on DownloadExport, expired but not marked yet",
+			status:             entities.ExportStatusReady,
+			expiresAt:          time.Now().Add(-time.Minute),
+			expectedStatusCode: 410,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewExportsRepositoryMock()
+			export := entities.DataExport{ID: "1", Nick: "1", Status: test.status, Key: "exports/1.zip"}
+			if !test.expiresAt.IsZero() {
+				export.ExpiresAt = &test.expiresAt
+			}
+			repositoryMock.On("GetExport", "1").Return(export, nil)
+
+			blobStoreMock := mocks.NewBlobStoreMock()
+			blobStoreMock.On("GetStream", "exports/1.zip").Return(io.NopCloser(strings.NewReader("PK")), int64(2), nil)
+
+			exportsController := NewExportsController(repositoryMock, blobStoreMock)
+
+			req, _ := http.NewRequest("GET", "/users/1/exports/1/download", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"userID": "1", "exportID": "1"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(exportsController.DownloadExport)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if test.expectedStatusCode == 200 {
+				assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+				assert.Equal(t, "2", rr.Header().Get("Content-Length"))
+				assert.Equal(t, "PK", rr.Body.String())
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"api/internal/domain/security"
 	"api/internal/infrastructure/http/responses"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 )
@@ -44,6 +45,11 @@ func (controller *LoginController) Login(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if userSavedOnDB.DeletedAt != nil {
+		responses.Error(w, http.StatusForbidden, errors.New("This account was deleted, it has to be restored before logging in"))
+		return
+	}
+
 	token, err := auth.CreateTokenWithNick(userSavedOnDB.Nick)
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
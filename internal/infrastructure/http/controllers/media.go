@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/application/media"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/config"
+	"api/internal/infrastructure/http/responses"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type MediaController struct {
+	mediaRepository repositories.MediaRepository
+	blobStore       repositories.BlobStore
+}
+
+func NewMediaController(mediaRepository repositories.MediaRepository, blobStore repositories.BlobStore) *MediaController {
+	return &MediaController{
+		mediaRepository,
+		blobStore,
+	}
+}
+
+func (controller *MediaController) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// leave some room for the multipart boundaries and headers
+	r.Body = http.MaxBytesReader(w, r.Body, config.MediaMaxUploadSize+1<<20)
+	if err = r.ParseMultipartForm(config.MediaMaxUploadSize); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, errors.New("The file field is required"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, config.MediaMaxUploadSize+1))
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if int64(len(data)) > config.MediaMaxUploadSize {
+		responses.Error(w, http.StatusRequestEntityTooLarge, errors.New("The file is too large"))
+		return
+	}
+
+	processed, err := media.Process(data)
+	if err != nil {
+		responses.Error(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	mediaID, err := newMediaID()
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	newMedia := entities.Media{
+		ID:          mediaID,
+		OwnerNick:   userNick,
+		ContentType: processed.Original.ContentType,
+		Size:        len(processed.Original.Data),
+		Width:       processed.Original.Width,
+		Height:      processed.Original.Height,
+		Key:         "media/" + mediaID + "/original",
+		Thumbnails:  []entities.Thumbnail{},
+	}
+
+	storedKeys := []string{}
+	removeStored := func() {
+		for _, key := range storedKeys {
+			controller.blobStore.Delete(key)
+		}
+	}
+
+	if err = controller.blobStore.Put(newMedia.Key, newMedia.ContentType, processed.Original.Data); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	storedKeys = append(storedKeys, newMedia.Key)
+
+	for _, size := range media.ThumbnailSizes {
+		thumbnail := processed.Thumbnails[size.Name]
+		key := "media/" + mediaID + "/" + size.Name
+
+		if err = controller.blobStore.Put(key, thumbnail.ContentType, thumbnail.Data); err != nil {
+			removeStored()
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		storedKeys = append(storedKeys, key)
+
+		newMedia.Thumbnails = append(newMedia.Thumbnails, entities.Thumbnail{
+			Size:        size.Name,
+			ContentType: thumbnail.ContentType,
+			Width:       thumbnail.Width,
+			Height:      thumbnail.Height,
+			Key:         key,
+		})
+	}
+
+	newMedia, err = controller.mediaRepository.CreateMedia(newMedia)
+	if err != nil {
+		removeStored()
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, newMedia)
+}
+
+func (controller *MediaController) GetMedia(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	mediaID := params["mediaID"]
+
+	savedMedia, err := controller.mediaRepository.GetMedia(mediaID)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, savedMedia)
+}
+
+func (controller *MediaController) GetMediaFile(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	mediaID := params["mediaID"]
+
+	savedMedia, err := controller.mediaRepository.GetMedia(mediaID)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	key, contentType := savedMedia.Key, savedMedia.ContentType
+	if size := r.URL.Query().Get("size"); size != "" && size != "original" {
+		found := false
+		for _, thumbnail := range savedMedia.Thumbnails {
+			if thumbnail.Size == size {
+				key, contentType, found = thumbnail.Key, thumbnail.ContentType, true
+			}
+		}
+
+		if !found {
+			responses.Error(w, http.StatusBadRequest, errors.New("Unknown thumbnail size"))
+			return
+		}
+	}
+
+	data, err := controller.blobStore.Get(key)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (controller *MediaController) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	mediaID := params["mediaID"]
+
+	savedMedia, err := controller.mediaRepository.GetMedia(mediaID)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	if savedMedia.OwnerNick != userNick {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible deleting other's media"))
+		return
+	}
+
+	if err = controller.mediaRepository.DeleteMedia(mediaID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	controller.blobStore.Delete(savedMedia.Key)
+	for _, thumbnail := range savedMedia.Thumbnails {
+		controller.blobStore.Delete(thumbnail.Key)
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// checkMediaOwnership makes sure every attached media exists and was uploaded by the user
+func checkMediaOwnership(mediaRepository repositories.MediaRepository, userNick string, mediaIDs ...string) error {
+	for _, mediaID := range mediaIDs {
+		if mediaID == "" {
+			continue
+		}
+
+		savedMedia, err := mediaRepository.GetMedia(mediaID)
+		if err != nil {
+			return err
+		}
+
+		if savedMedia.OwnerNick != userNick {
+			return errors.New("It's not possible attaching other's media")
+		}
+	}
+
+	return nil
+}
+
+func newMediaID() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
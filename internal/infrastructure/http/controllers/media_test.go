@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func multipartBody(t *testing.T, field string, content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile(field, "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	return body, writer.FormDataContentType()
+}
+
+func pngImage(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		img.Set(x, 10, color.RGBA{R: 255, A: 255})
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestUploadMedia(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		field              string
+		content            []byte
+		expectedPutError   error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on UploadMedia",
+			field:              "file",
+			content:            pngImage(t),
+			expectedPutError:   nil,
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Error on UploadMedia, not an image",
+			field:              "file",
+			content:            []byte("#!/bin/sh\necho hello"),
+			expectedPutError:   nil,
+			expectedStatusCode: 415,
+		},
+		{
+			name:               "Error on UploadMedia, missing file field",
+			field:              "other",
+			content:            pngImage(t),
+			expectedPutError:   nil,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on UploadMedia, storage failure",
+			field:              "file",
+			content:            pngImage(t),
+			expectedPutError:   assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewMediaRepositoryMock()
+			repositoryMock.On("CreateMedia", mock.AnythingOfType("entities.Media")).Return(entities.Media{}, nil)
+
+			blobStoreMock := mocks.NewBlobStoreMock()
+			blobStoreMock.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(test.expectedPutError)
+			blobStoreMock.On("Delete", mock.Anything).Return(nil)
+
+			mediaController := NewMediaController(repositoryMock, blobStoreMock)
+
+			body, contentType := multipartBody(t, test.field, test.content)
+			req := httptest.NewRequest("POST", "/media", body)
+			req.Header.Add("Content-Type", contentType)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(mediaController.UploadMedia)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if test.expectedStatusCode == 201 {
+				created := repositoryMock.Calls[0].Arguments.Get(0).(entities.Media)
+				assert.Equal(t, "1", created.OwnerNick)
+				assert.Equal(t, "image/png", created.ContentType)
+				assert.Len(t, created.Thumbnails, 2)
+				assert.Equal(t, 150, created.Thumbnails[0].Width)
+				blobStoreMock.AssertNumberOfCalls(t, "Put", 3)
+			}
+		})
+	}
+}
+
+func TestDeleteMedia(t *testing.T) {
+	savedMedia := entities.Media{
+		ID:         "64a399cdb6a0487490ed730c",
+		OwnerNick:  "1",
+		Key:        "media/64a399cdb6a0487490ed730c/original",
+		Thumbnails: []entities.Thumbnail{{Size: "small", Key: "media/64a399cdb6a0487490ed730c/small"}},
+	}
+
+	tests := []struct {
+		name               string
+		validToken         string
+		expectedGetError   error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on DeleteMedia",
+			validToken:         ValidToken,
+			expectedGetError:   nil,
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on DeleteMedia, other's media",
+			validToken:         DiffToken,
+			expectedGetError:   nil,
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Error on DeleteMedia, unexistent media",
+			validToken:         ValidToken,
+			expectedGetError:   assert.AnError,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewMediaRepositoryMock()
+			repositoryMock.On("GetMedia", savedMedia.ID).Return(savedMedia, test.expectedGetError)
+			repositoryMock.On("DeleteMedia", savedMedia.ID).Return(nil)
+
+			blobStoreMock := mocks.NewBlobStoreMock()
+			blobStoreMock.On("Delete", mock.Anything).Return(nil)
+
+			mediaController := NewMediaController(repositoryMock, blobStoreMock)
+
+			req, _ := http.NewRequest("DELETE", "/media/", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"mediaID": savedMedia.ID})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(mediaController.DeleteMedia)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
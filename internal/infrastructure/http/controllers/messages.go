@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/application/stream"
+	"api/internal/application/visibility"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/http/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type MessagesController struct {
+	messageRepository repositories.MessagesRepository
+	userRepository    repositories.UsersRepository
+	hub               *stream.Hub
+}
+
+func NewMessagesController(messageRepository repositories.MessagesRepository, userRepository repositories.UsersRepository, hub *stream.Hub) *MessagesController {
+	return &MessagesController{
+		messageRepository,
+		userRepository,
+		hub,
+	}
+}
+
+type createConversationRequest struct {
+	Participants []string `json:"participants"`
+	Name         string   `json:"name"`
+}
+
+// CreateConversation starts a conversation with the users sent, a single user makes a one-to-one conversation
+// and an existing one between the same users is returned instead of creating another
+func (controller *MessagesController) CreateConversation(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var request createConversationRequest
+	if err = json.Unmarshal(reqbody, &request); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	nicks := []string{}
+	seen := map[string]bool{userNick: true}
+	for _, nick := range request.Participants {
+		if !seen[nick] {
+			seen[nick] = true
+			nicks = append(nicks, nick)
+		}
+	}
+
+	conversation := entities.Conversation{
+		Group:        len(nicks) > 1,
+		Name:         request.Name,
+		CreatedBy:    userNick,
+		Participants: []entities.Participant{{Nick: userNick}},
+	}
+	for _, nick := range nicks {
+		conversation.Participants = append(conversation.Participants, entities.Participant{Nick: nick})
+	}
+
+	if err = conversation.Prepare(); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sender, err := controller.userRepository.GetUserByNick(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	recipients, err := controller.userRepository.GetUsersByNicks(nicks)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(recipients) != len(nicks) {
+		responses.Error(w, http.StatusNotFound, errors.New("This user doesn't exists"))
+		return
+	}
+
+	for _, recipient := range recipients {
+		if !visibility.CanMessage(sender, recipient) {
+			responses.Error(w, http.StatusForbidden, errors.New("It's not possible to send messages to "+recipient.Nick))
+			return
+		}
+	}
+
+	if !conversation.Group {
+		conversation.Key = entities.DirectConversationKey(userNick, nicks[0])
+
+		if existing, err := controller.messageRepository.GetConversationByKey(conversation.Key); err == nil {
+			responses.JSON(w, http.StatusOK, existing)
+			return
+		}
+	}
+
+	conversation, err = controller.messageRepository.CreateConversation(conversation)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, conversation)
+}
+
+func (controller *MessagesController) GetConversations(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	user, err := controller.userRepository.GetUserByNick(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	// the last message of a blocked user isn't shown, the same way it's left out of the messages
+	conversations, err := controller.messageRepository.GetConversations(userNick, user.Blocked, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, conversations)
+}
+
+func (controller *MessagesController) GetMessages(w http.ResponseWriter, r *http.Request) {
+	userNick, conversation, ok := controller.getConversation(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := controller.userRepository.GetUserByNick(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	// on groups the blocked users are still participants, only their messages are hidden
+	hiddenSenders := append([]string{}, user.Blocked...)
+
+	messages, err := controller.messageRepository.GetMessages(conversation.ID, userNick, hiddenSenders, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for i := range messages {
+		messages[i].ReadBy = []string{}
+		for _, participant := range conversation.Participants {
+			if participant.Nick != messages[i].SenderNick && !participant.LastReadAt.Before(messages[i].CreatedAt) {
+				messages[i].ReadBy = append(messages[i].ReadBy, participant.Nick)
+			}
+		}
+	}
+
+	responses.JSON(w, http.StatusOK, messages)
+}
+
+func (controller *MessagesController) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userNick, conversation, ok := controller.getConversation(w, r)
+	if !ok {
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var message entities.Message
+	if err = json.Unmarshal(reqbody, &message); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = message.Prepare(); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// blocks and settings may have changed since the conversation started
+	blockedBy := []string{}
+	if !conversation.Group {
+		if err := controller.checkCanMessage(userNick, conversation); err != nil {
+			responses.Error(w, http.StatusForbidden, err)
+			return
+		}
+	} else if blockedBy, err = controller.blockedBy(userNick, conversation); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	message.ConversationID = conversation.ID
+	message.SenderNick = userNick
+
+	message, err = controller.messageRepository.AddMessage(message, blockedBy)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	skipped := map[string]bool{userNick: true}
+	for _, nick := range blockedBy {
+		skipped[nick] = true
+	}
+
+	message.ReadBy = []string{}
+	for _, participant := range conversation.Participants {
+		if !skipped[participant.Nick] {
+			controller.hub.PublishTo(participant.Nick, stream.EventMessage, message)
+		}
+	}
+
+	responses.JSON(w, http.StatusCreated, message)
+}
+
+func (controller *MessagesController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userNick, conversation, ok := controller.getConversation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := controller.messageRepository.MarkConversationRead(conversation.ID, userNick); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	receipt := entities.ReadReceipt{ConversationID: conversation.ID, Nick: userNick, ReadAt: time.Now()}
+	for _, participant := range conversation.Participants {
+		if participant.Nick != userNick {
+			controller.hub.PublishTo(participant.Nick, stream.EventMessagesRead, receipt)
+		}
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *MessagesController) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userNick, conversation, ok := controller.getConversation(w, r)
+	if !ok {
+		return
+	}
+
+	messageID := mux.Vars(r)["messageID"]
+
+	if err := controller.messageRepository.DeleteMessage(conversation.ID, messageID, userNick); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *MessagesController) DeleteConversation(w http.ResponseWriter, r *http.Request) {
+	userNick, conversation, ok := controller.getConversation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := controller.messageRepository.DeleteConversation(conversation.ID, userNick); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// getConversation loads the conversation on the URL, answering not found when the user isn't a participant so
+// other conversations aren't revealed
+func (controller *MessagesController) getConversation(w http.ResponseWriter, r *http.Request) (string, entities.Conversation, bool) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return "", entities.Conversation{}, false
+	}
+
+	conversationID := mux.Vars(r)["conversationID"]
+
+	conversation, err := controller.messageRepository.GetConversation(conversationID)
+	if err == nil {
+		if _, found := conversation.Participant(userNick); found {
+			return userNick, conversation, true
+		}
+	}
+
+	responses.Error(w, http.StatusNotFound, errors.New("This conversation doesn't exists"))
+	return "", entities.Conversation{}, false
+}
+
+// blockedBy lists the participants of the group who blocked the user
+func (controller *MessagesController) blockedBy(userNick string, conversation entities.Conversation) ([]string, error) {
+	nicks := []string{}
+	for _, participant := range conversation.Participants {
+		if participant.Nick != userNick {
+			nicks = append(nicks, participant.Nick)
+		}
+	}
+
+	participants, err := controller.userRepository.GetUsersByNicks(nicks)
+	if err != nil {
+		return nil, err
+	}
+
+	blockedBy := []string{}
+	for _, participant := range participants {
+		if visibility.Blocked(participant, userNick) {
+			blockedBy = append(blockedBy, participant.Nick)
+		}
+	}
+
+	return blockedBy, nil
+}
+
+func (controller *MessagesController) checkCanMessage(userNick string, conversation entities.Conversation) error {
+	sender, err := controller.userRepository.GetUserByNick(userNick)
+	if err != nil {
+		return err
+	}
+
+	for _, participant := range conversation.Participants {
+		if participant.Nick == userNick {
+			continue
+		}
+
+		recipient, err := controller.userRepository.GetUserByNick(participant.Nick)
+		if err != nil || !visibility.CanMessage(sender, recipient) {
+			return errors.New("It's not possible to send messages to " + participant.Nick)
+		}
+	}
+
+	return nil
+}
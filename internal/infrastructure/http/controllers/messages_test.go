@@ -0,0 +1,437 @@
+package controllers
+
+import (
+	"api/internal/application/stream"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const conversationID = "64a399cdb6a0487490ed730c"
+
+func TestCreateConversation(t *testing.T) {
+
+	tests := []struct {
+		name                 string
+		input                string
+		recipients           []entities.User
+		sender               entities.User
+		existing             error
+		expectedCreated      bool
+		expectedParticipants []string
+		expectedStatusCode   int
+	}{
+		{
+			name:                 "Success on CreateConversation, one-to-one",
+			input:                `{"participants": ["2"]}`,
+			recipients:           []entities.User{{Nick: "2"}},
+			existing:             assert.AnError,
+			expectedCreated:      true,
+			expectedParticipants: []string{"1", "2"},
+			expectedStatusCode:   201,
+		},
+		{
+			name:               "Success on CreateConversation, existing one-to-one",
+			input:              `{"participants": ["2", "1"]}`,
+			recipients:         []entities.User{{Nick: "2"}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:                 "Success on CreateConversation, group",
+			input:                `{"participants": ["2", "3", "2"], "name": "Gophers"}`,
+			recipients:           []entities.User{{Nick: "2"}, {Nick: "3", DMFollowingOnly: true, Following: []string{"1"}}},
+			expectedCreated:      true,
+			expectedParticipants: []string{"1", "2", "3"},
+			expectedStatusCode:   201,
+		},
+		{
+			name:               "Error on CreateConversation, no participants",
+			input:              `{"participants": ["1"]}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on CreateConversation, name on a one-to-one",
+			input:              `{"participants": ["2"], "name": "Us"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on CreateConversation, user doesn't exists",
+			input:              `{"participants": ["2", "3"]}`,
+			recipients:         []entities.User{{Nick: "2"}},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on CreateConversation, blocked by the recipient",
+			input:              `{"participants": ["2"]}`,
+			recipients:         []entities.User{{Nick: "2", Blocked: []string{"1"}}},
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Error on CreateConversation, recipient blocked",
+			input:              `{"participants": ["2"]}`,
+			recipients:         []entities.User{{Nick: "2"}},
+			sender:             entities.User{Blocked: []string{"2"}},
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Error on CreateConversation, only accepts from following",
+			input:              `{"participants": ["2"]}`,
+			recipients:         []entities.User{{Nick: "2", DMFollowingOnly: true, Following: []string{"3"}}},
+			expectedStatusCode: 403,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sender := test.sender
+			sender.Nick = "1"
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "1").Return(sender, nil)
+			usersRepositoryMock.On("GetUsersByNicks", mock.Anything).Return(test.recipients, nil)
+
+			messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+			messagesRepositoryMock.On("GetConversationByKey", "1:2").Return(entities.Conversation{ID: conversationID, Key: "1:2"}, test.existing)
+			messagesRepositoryMock.On("CreateConversation", mock.Anything).Return(entities.Conversation{ID: conversationID}, nil)
+
+			messagesController := NewMessagesController(messagesRepositoryMock, usersRepositoryMock, stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/conversations", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(messagesController.CreateConversation)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if test.expectedCreated {
+				created := messagesRepositoryMock.Calls[len(messagesRepositoryMock.Calls)-1].Arguments.Get(0).(entities.Conversation)
+				assert.Equal(t, test.expectedParticipants, participantNicks(created.Participants))
+				assert.Equal(t, len(test.expectedParticipants) > 2, created.Group)
+				assert.Equal(t, "1", created.CreatedBy)
+			} else {
+				messagesRepositoryMock.AssertNotCalled(t, "CreateConversation", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	conversation := entities.Conversation{
+		ID:           conversationID,
+		Key:          "1:2",
+		Participants: []entities.Participant{{Nick: "1"}, {Nick: "2"}},
+	}
+
+	tests := []struct {
+		name               string
+		input              string
+		validToken         string
+		conversation       entities.Conversation
+		recipient          entities.User
+		expectedSent       bool
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on SendMessage",
+			input:              `{"content": " Hi! "}`,
+			validToken:         ValidToken,
+			conversation:       conversation,
+			recipient:          entities.User{Nick: "2"},
+			expectedSent:       true,
+			expectedStatusCode: 201,
+		},
+		{
+			name:       "Success on SendMessage, group with a user that doesn't follow the sender",
+			input:      `{"content": "Hi all"}`,
+			validToken: ValidToken,
+			conversation: entities.Conversation{
+				ID:           conversationID,
+				Group:        true,
+				Participants: []entities.Participant{{Nick: "1"}, {Nick: "2"}, {Nick: "3"}},
+			},
+			expectedSent:       true,
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Error on SendMessage, empty content",
+			input:              `{"content": "  "}`,
+			validToken:         ValidToken,
+			conversation:       conversation,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on SendMessage, blocked after the conversation started",
+			input:              `{"content": "Hi"}`,
+			validToken:         ValidToken,
+			conversation:       conversation,
+			recipient:          entities.User{Nick: "2", Blocked: []string{"1"}},
+			expectedStatusCode: 403,
+		},
+		{
+			name:       "Error on SendMessage, not a participant",
+			input:      `{"content": "Hi"}`,
+			validToken: ValidToken,
+			conversation: entities.Conversation{
+				ID:           conversationID,
+				Participants: []entities.Participant{{Nick: "2"}, {Nick: "3"}},
+			},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on SendMessage, invalid token",
+			input:              `{"content": "Hi"}`,
+			validToken:         ValidToken + "invalidate",
+			expectedStatusCode: 401,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1"}, nil)
+			usersRepositoryMock.On("GetUserByNick", "2").Return(test.recipient, nil)
+			usersRepositoryMock.On("GetUsersByNicks", []string{"2", "3"}).Return([]entities.User{{Nick: "2"}, {Nick: "3"}}, nil)
+
+			messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+			messagesRepositoryMock.On("GetConversation", conversationID).Return(test.conversation, nil)
+			messagesRepositoryMock.On("AddMessage", mock.Anything, []string{}).Return(entities.Message{ID: "64a399cdb6a0487490ed7300", ConversationID: conversationID, SenderNick: "1"}, nil)
+
+			hub := stream.NewHub(10, 10)
+			subscriber, _ := hub.Subscribe("2", nil, nil, 0)
+
+			messagesController := NewMessagesController(messagesRepositoryMock, usersRepositoryMock, hub)
+
+			req, _ := http.NewRequest("POST", "/conversations/"+conversationID+"/messages", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"conversationID": conversationID})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(messagesController.SendMessage)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if test.expectedSent {
+				messagesRepositoryMock.AssertCalled(t, "AddMessage", mock.MatchedBy(func(message entities.Message) bool {
+					return message.ConversationID == conversationID && message.SenderNick == "1" && message.Content != "" && message.Content == strings.TrimSpace(message.Content)
+				}), []string{})
+
+				event := <-subscriber.Events
+				assert.Equal(t, stream.EventMessage, event.Type)
+			} else {
+				messagesRepositoryMock.AssertNotCalled(t, "AddMessage", mock.Anything, mock.Anything)
+				assert.Len(t, subscriber.Events, 0)
+			}
+		})
+	}
+}
+
+func TestSendGroupMessageBlockedBySomeone(t *testing.T) {
+	conversation := entities.Conversation{
+		ID:           conversationID,
+		Group:        true,
+		Participants: []entities.Participant{{Nick: "1"}, {Nick: "2"}, {Nick: "3"}},
+	}
+
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUsersByNicks", []string{"2", "3"}).Return([]entities.User{{Nick: "2", Blocked: []string{"1"}}, {Nick: "3"}}, nil)
+
+	messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+	messagesRepositoryMock.On("GetConversation", conversationID).Return(conversation, nil)
+	messagesRepositoryMock.On("AddMessage", mock.Anything, []string{"2"}).Return(entities.Message{ID: "64a399cdb6a0487490ed7300", ConversationID: conversationID, SenderNick: "1"}, nil)
+
+	hub := stream.NewHub(10, 10)
+	blocker, _ := hub.Subscribe("2", nil, nil, 0)
+	other, _ := hub.Subscribe("3", nil, nil, 0)
+
+	messagesController := NewMessagesController(messagesRepositoryMock, usersRepositoryMock, hub)
+
+	req, _ := http.NewRequest("POST", "/conversations/"+conversationID+"/messages", strings.NewReader(`{"content": "Hi all"}`))
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"conversationID": conversationID})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(messagesController.SendMessage)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// the message isn't pushed, nor counted as unread, to the participant who blocked the sender
+	messagesRepositoryMock.AssertCalled(t, "AddMessage", mock.Anything, []string{"2"})
+	assert.Len(t, blocker.Events, 0)
+	assert.Len(t, other.Events, 1)
+}
+
+func TestGetMessages(t *testing.T) {
+	now := time.Now()
+	conversation := entities.Conversation{
+		ID:    conversationID,
+		Group: true,
+		Participants: []entities.Participant{
+			{Nick: "1", LastReadAt: now},
+			{Nick: "2", LastReadAt: now.Add(-time.Hour)},
+			{Nick: "3", LastReadAt: now.Add(-2 * time.Hour)},
+		},
+	}
+
+	// the messages of the blocked user "3" are left out by the repository
+	messages := []entities.Message{
+		{ID: "3", SenderNick: "1", Content: "Newest", CreatedAt: now.Add(-30 * time.Minute)},
+		{ID: "1", SenderNick: "1", Content: "Oldest", CreatedAt: now.Add(-3 * time.Hour)},
+	}
+
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1", Blocked: []string{"3"}}, nil)
+
+	messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+	messagesRepositoryMock.On("GetConversation", conversationID).Return(conversation, nil)
+	messagesRepositoryMock.On("GetMessages", conversationID, "1", []string{"3"}, 1, 20).Return(messages, nil)
+
+	messagesController := NewMessagesController(messagesRepositoryMock, usersRepositoryMock, stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("GET", "/conversations/"+conversationID+"/messages", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"conversationID": conversationID})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(messagesController.GetMessages)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result []entities.Message
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Len(t, result, 2)
+	assert.Equal(t, []string{}, result[0].ReadBy)
+	assert.Equal(t, []string{"2", "3"}, result[1].ReadBy)
+}
+
+func TestGetConversations(t *testing.T) {
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1", Blocked: []string{"3"}}, nil)
+
+	// the last message of the blocked user "3" is left out by the repository
+	messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+	messagesRepositoryMock.On("GetConversations", "1", []string{"3"}, 1, 20).Return([]entities.Conversation{{ID: conversationID}}, nil)
+
+	messagesController := NewMessagesController(messagesRepositoryMock, usersRepositoryMock, stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("GET", "/conversations", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(messagesController.GetConversations)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	messagesRepositoryMock.AssertCalled(t, "GetConversations", "1", []string{"3"}, 1, 20)
+}
+
+func TestMarkConversationRead(t *testing.T) {
+	conversation := entities.Conversation{
+		ID:           conversationID,
+		Participants: []entities.Participant{{Nick: "1"}, {Nick: "2"}},
+	}
+
+	messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+	messagesRepositoryMock.On("GetConversation", conversationID).Return(conversation, nil)
+	messagesRepositoryMock.On("MarkConversationRead", conversationID, "1").Return(nil)
+
+	hub := stream.NewHub(10, 10)
+	subscriber, _ := hub.Subscribe("2", nil, nil, 0)
+
+	messagesController := NewMessagesController(messagesRepositoryMock, mocks.NewUsersRepositoryMock(), hub)
+
+	req, _ := http.NewRequest("POST", "/conversations/"+conversationID+"/read", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"conversationID": conversationID})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(messagesController.MarkRead)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	messagesRepositoryMock.AssertCalled(t, "MarkConversationRead", conversationID, "1")
+
+	event := <-subscriber.Events
+	assert.Equal(t, stream.EventMessagesRead, event.Type)
+	assert.Equal(t, "1", event.Data.(entities.ReadReceipt).Nick)
+}
+
+func TestDeleteMessage(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		validToken         string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on DeleteMessage",
+			validToken:         ValidToken,
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on DeleteMessage, message doesn't exists",
+			validToken:         ValidToken,
+			expectedError:      assert.AnError,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on DeleteMessage, not a participant",
+			validToken:         DiffToken,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conversation := entities.Conversation{
+				ID:           conversationID,
+				Participants: []entities.Participant{{Nick: "1"}, {Nick: "3"}},
+			}
+
+			messagesRepositoryMock := mocks.NewMessagesRepositoryMock()
+			messagesRepositoryMock.On("GetConversation", conversationID).Return(conversation, nil)
+			messagesRepositoryMock.On("DeleteMessage", conversationID, "64a399cdb6a0487490ed7300", "1").Return(test.expectedError)
+
+			messagesController := NewMessagesController(messagesRepositoryMock, mocks.NewUsersRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("DELETE", "/conversations/"+conversationID+"/messages/64a399cdb6a0487490ed7300", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"conversationID": conversationID, "messageID": "64a399cdb6a0487490ed7300"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(messagesController.DeleteMessage)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func participantNicks(participants []entities.Participant) []string {
+	nicks := []string{}
+	for _, participant := range participants {
+		nicks = append(nicks, participant.Nick)
+	}
+	return nicks
+}
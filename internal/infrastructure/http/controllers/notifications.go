@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/application/notifications"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/http/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
+
+type NotificationsController struct {
+	notificationRepository repositories.NotificationsRepository
+	userRepository         repositories.UsersRepository
+}
+
+func NewNotificationsController(notificationRepository repositories.NotificationsRepository, userRepository repositories.UsersRepository) *NotificationsController {
+	return &NotificationsController{
+		notificationRepository,
+		userRepository,
+	}
+}
+
+type markReadRequest struct {
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+func (controller *NotificationsController) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	userNotifications, err := controller.notificationRepository.GetNotifications(userNick, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	unreadCount, err := controller.notificationRepository.CountUnread(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for i := range userNotifications {
+		userNotifications[i].Summary = notifications.Summarize(userNotifications[i])
+	}
+
+	responses.JSON(w, http.StatusOK, entities.NotificationsPage{
+		UnreadCount:   unreadCount,
+		Notifications: userNotifications,
+	})
+}
+
+func (controller *NotificationsController) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var request markReadRequest
+	if err = json.Unmarshal(reqbody, &request); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.All {
+		err = controller.notificationRepository.MarkAllRead(userNick)
+	} else if len(request.IDs) > 0 {
+		err = controller.notificationRepository.MarkRead(userNick, request.IDs)
+	} else {
+		responses.Error(w, http.StatusBadRequest, errors.New("You must insert the notification IDs or mark all of them"))
+		return
+	}
+
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *NotificationsController) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	user, err := controller.userRepository.GetUserByNick(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, notifications.Preferences(user))
+}
+
+func (controller *NotificationsController) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var changes entities.NotificationPreferences
+	if err = json.Unmarshal(reqbody, &changes); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := controller.userRepository.GetUserByNick(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// only the kinds sent are changed, the others keep their current value
+	preferences := notifications.Preferences(user)
+	for notificationType, enabled := range changes {
+		if _, found := preferences[notificationType]; !found {
+			responses.Error(w, http.StatusBadRequest, errors.New("The notification type "+notificationType+" doesn't exists"))
+			return
+		}
+		preferences[notificationType] = enabled
+	}
+
+	disabled := []string{}
+	for _, notificationType := range entities.NotificationTypes {
+		if !preferences[notificationType] {
+			disabled = append(disabled, notificationType)
+		}
+	}
+
+	if err = controller.userRepository.UpdateNotificationPreferences(userNick, disabled); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, preferences)
+}
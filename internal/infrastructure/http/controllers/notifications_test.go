@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetNotifications(t *testing.T) {
+
+	tests := []struct {
+		name                     string
+		validToken               string
+		expectedNotifications    []entities.Notification
+		expectedNotificationsErr error
+		expectedCountErr         error
+		expectedStatusCode       int
+		expectedSummaries        []string
+	}{
+		{
+			name:       "Success on GetNotifications",
+			validToken: ValidToken,
+			expectedNotifications: []entities.Notification{
+				{Type: entities.NotificationLike, Actor: "alice", Actors: []string{"alice", "bob"}, ActorCount: 13, PostID: "64a399cdb6a0487490ed730c"},
+				{Type: entities.NotificationFollow, Actor: "bob", Actors: []string{"bob", "carol"}, ActorCount: 2},
+				{Type: entities.NotificationMention, Actor: "carol", Actors: []string{"carol"}, ActorCount: 1, Read: true},
+			},
+			expectedStatusCode: 200,
+			expectedSummaries: []string{
+				"alice and 12 others liked your post",
+				"bob and 1 other followed you",
+				"carol mentioned you",
+			},
+		},
+		{
+			name:                     "Error on GetNotifications",
+			validToken:               ValidToken,
+			expectedNotifications:    []entities.Notification{},
+			expectedNotificationsErr: assert.AnError,
+			expectedStatusCode:       500,
+		},
+		{
+			name:                  "Error on GetNotifications, CountUnread",
+			validToken:            ValidToken,
+			expectedNotifications: []entities.Notification{},
+			expectedCountErr:      assert.AnError,
+			expectedStatusCode:    500,
+		},
+		{
+			name:               "Error on GetNotifications, invalid token",
+			validToken:         ValidToken + "invalidate",
+			expectedStatusCode: 401,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("GetNotifications", "1", 1, 20).Return(test.expectedNotifications, test.expectedNotificationsErr)
+			notificationsRepositoryMock.On("CountUnread", "1").Return(2, test.expectedCountErr)
+
+			notificationsController := NewNotificationsController(notificationsRepositoryMock, mocks.NewUsersRepositoryMock())
+
+			req, _ := http.NewRequest("GET", "/notifications", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(notificationsController.GetNotifications)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedSummaries != nil {
+				var page entities.NotificationsPage
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+				assert.Equal(t, 2, page.UnreadCount)
+
+				summaries := []string{}
+				for _, notification := range page.Notifications {
+					summaries = append(summaries, notification.Summary)
+				}
+				assert.Equal(t, test.expectedSummaries, summaries)
+			}
+		})
+	}
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		input              string
+		expectedStatusCode int
+		expectedCall       string
+	}{
+		{
+			name:               "Success on MarkRead, some notifications",
+			input:              `{"ids": ["64a399cdb6a0487490ed730c"]}`,
+			expectedStatusCode: 204,
+			expectedCall:       "MarkRead",
+		},
+		{
+			name:               "Success on MarkRead, all notifications",
+			input:              `{"all": true}`,
+			expectedStatusCode: 204,
+			expectedCall:       "MarkAllRead",
+		},
+		{
+			name:               "Error on MarkRead, nothing to mark",
+			input:              `{}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on MarkRead, invalid body",
+			input:              ``,
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("MarkRead", "1", []string{"64a399cdb6a0487490ed730c"}).Return(nil)
+			notificationsRepositoryMock.On("MarkAllRead", "1").Return(nil)
+
+			notificationsController := NewNotificationsController(notificationsRepositoryMock, mocks.NewUsersRepositoryMock())
+
+			req, _ := http.NewRequest("POST", "/notifications/read", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(notificationsController.MarkRead)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedCall != "" {
+				notificationsRepositoryMock.AssertNumberOfCalls(t, test.expectedCall, 1)
+			}
+		})
+	}
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		input              string
+		currentDisabled    []string
+		expectedDisabled   []string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on UpdatePreferences, turning likes off",
+			input:              `{"like": false}`,
+			currentDisabled:    []string{entities.NotificationFollow},
+			expectedDisabled:   []string{entities.NotificationFollow, entities.NotificationLike},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on UpdatePreferences, turning follows back on",
+			input:              `{"follow": true}`,
+			currentDisabled:    []string{entities.NotificationFollow},
+			expectedDisabled:   []string{},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on UpdatePreferences, unknown type",
+			input:              `{"pokes": false}`,
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1", DisabledNotifications: test.currentDisabled}, nil)
+			usersRepositoryMock.On("UpdateNotificationPreferences", "1", mock.Anything).Return(nil)
+
+			notificationsController := NewNotificationsController(mocks.NewNotificationsRepositoryMock(), usersRepositoryMock)
+
+			req, _ := http.NewRequest("PUT", "/notifications/preferences", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(notificationsController.UpdatePreferences)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedDisabled != nil {
+				usersRepositoryMock.AssertCalled(t, "UpdateNotificationPreferences", "1", test.expectedDisabled)
+			}
+		})
+	}
+}
@@ -2,24 +2,38 @@ package controllers
 
 import (
 	"api/internal/application/auth"
+	"api/internal/application/notifications"
+	"api/internal/application/stream"
+	"api/internal/application/threads"
+	"api/internal/application/visibility"
 	"api/internal/domain/entities"
 	"api/internal/domain/repositories"
+	"api/internal/infrastructure/config"
 	"api/internal/infrastructure/http/responses"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type PostsController struct {
-	PostRepository repositories.PostsRepository
+	PostRepository  repositories.PostsRepository
+	MediaRepository repositories.MediaRepository
+	UserRepository  repositories.UsersRepository
+	Notifier        *notifications.Notifier
+	Hub             *stream.Hub
 }
 
-func NewPostsController(postRepository repositories.PostsRepository) *PostsController {
+func NewPostsController(postRepository repositories.PostsRepository, mediaRepository repositories.MediaRepository, userRepository repositories.UsersRepository, notificationRepository repositories.NotificationsRepository, hub *stream.Hub) *PostsController {
 	return &PostsController{
 		postRepository,
+		mediaRepository,
+		userRepository,
+		notifications.NewNotifier(notificationRepository, userRepository, hub),
+		hub,
 	}
 }
 
@@ -49,12 +63,68 @@ func (controller *PostsController) CreatePost(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	var parent, quoted entities.Post
+	if post.ParentID != "" {
+		parent, err = controller.PostRepository.GetVisiblePost(post.ParentID, userNick)
+		if err != nil {
+			responses.Error(w, http.StatusNotFound, errors.New("It's not possible to reply a post that doesn't exists"))
+			return
+		}
+
+		if parent.Deleted {
+			responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to reply a deleted post"))
+			return
+		}
+
+		if parent.RepostOf != "" {
+			responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to reply a repost"))
+			return
+		}
+
+		post.RootID = parent.RootID
+		if post.RootID == "" {
+			post.RootID = parent.ID
+		}
+	}
+
+	if post.QuoteOf != "" {
+		quoted, err = controller.PostRepository.GetVisiblePost(post.QuoteOf, userNick)
+		if err != nil {
+			responses.Error(w, http.StatusNotFound, errors.New("It's not possible to quote a post that doesn't exists"))
+			return
+		}
+
+		if statusCode, err := controller.checkShareable(userNick, quoted); err != nil {
+			responses.Error(w, statusCode, err)
+			return
+		}
+	}
+
+	if err = checkMediaOwnership(controller.MediaRepository, userNick, post.MediaIDs...); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mentionedUsers := controller.resolveMentions(&post)
+
 	post, err = controller.PostRepository.CreatePost(post)
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	// nobody hears about drafts and scheduled posts until they are published
+	if !post.Published() {
+		responses.JSON(w, http.StatusCreated, post)
+		return
+	}
+
+	if quoted.ID != "" {
+		post.Original = &quoted
+	}
+	controller.Hub.PublishPost(post, stream.EventPost, post)
+	controller.Notifier.NotifyPublished(userNick, post, parent, quoted, mentionedUsers)
+
 	responses.JSON(w, http.StatusCreated, post)
 }
 
@@ -62,7 +132,15 @@ func (controller *PostsController) GetPosts(w http.ResponseWriter, r *http.Reque
 	params := mux.Vars(r)
 	userNick := params["userID"]
 
-	posts, err := controller.PostRepository.GetPosts(userNick)
+	viewerNick, _ := auth.GetUserNick(r)
+
+	posts, err := controller.PostRepository.GetPosts(userNick, viewerNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts, err = controller.resolveReferences(viewerNick, posts)
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
@@ -86,10 +164,22 @@ func (controller *PostsController) UpdatePost(w http.ResponseWriter, r *http.Req
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	if postSavedOnDB.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post was deleted"))
+		return
+	}
 	if postSavedOnDB.AuthorNick != userNick {
 		responses.Error(w, http.StatusForbidden, errors.New("It's not possible to update other's posts"))
 		return
 	}
+	if postSavedOnDB.RepostOf != "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to update a repost"))
+		return
+	}
+	if postSavedOnDB.Published() && config.PostEditWindow > 0 && time.Since(postSavedOnDB.CreatedAt) > config.PostEditWindow {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible to update a post after the edit window"))
+		return
+	}
 
 	reqbody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -103,19 +193,139 @@ func (controller *PostsController) UpdatePost(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// a post can't be moved to another conversation or quote another post
+	post.ParentID = postSavedOnDB.ParentID
+	post.QuoteOf = postSavedOnDB.QuoteOf
+	if post.Visibility == "" {
+		post.Visibility = postSavedOnDB.Visibility
+	}
+
+	if postSavedOnDB.Published() && (!post.Published() || post.PublishAt != nil) {
+		responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to turn a published post back into a draft"))
+		return
+	}
+
+	// the votes already given were on the options as published
+	if postSavedOnDB.Published() && post.Poll != nil {
+		responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to change the poll of a published post"))
+		return
+	}
+
+	// drafts and scheduled posts keep their state unless a new one is sent, they are published on their own route
+	if !postSavedOnDB.Published() && post.Status == "" {
+		post.Status = postSavedOnDB.Status
+		if post.PublishAt == nil {
+			post.PublishAt = postSavedOnDB.PublishAt
+		}
+	}
+
 	if err = post.Prepare(); err != nil {
 		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
+	if err = checkMediaOwnership(controller.MediaRepository, userNick, post.MediaIDs...); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mentionedUsers := controller.resolveMentions(&post)
+
+	if !postSavedOnDB.Published() {
+		if err = controller.PostRepository.UpdateDraft(postID, post); err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		responses.JSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	if !post.ChangedFrom(postSavedOnDB) {
+		responses.JSON(w, http.StatusNoContent, nil)
+		return
+	}
+
 	if err = controller.PostRepository.UpdatePost(postID, post); err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	post.ID = postID
+	post.AuthorNick = userNick
+	controller.Notifier.NotifyMentions(userNick, post, mentionedUsers, postSavedOnDB.Mentions)
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
+// GetDrafts returns the drafts and scheduled posts of the user
+func (controller *PostsController) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	posts, err := controller.PostRepository.GetDrafts(userNick, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+// PublishPost publishes a draft or scheduled post of the user right away
+func (controller *PostsController) PublishPost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	postSavedOnDB, err := controller.PostRepository.GetPostWithId(postID)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+	if postSavedOnDB.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post was deleted"))
+		return
+	}
+	if postSavedOnDB.AuthorNick != userNick {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible to publish other's posts"))
+		return
+	}
+	if postSavedOnDB.Published() {
+		responses.Error(w, http.StatusConflict, errors.New("This post is already published"))
+		return
+	}
+	if postSavedOnDB.Poll != nil && !postSavedOnDB.Poll.CanOpen(time.Now()) {
+		responses.Error(w, http.StatusBadRequest, errors.New("The poll of this post must close at least 5 minutes after it's published"))
+		return
+	}
+
+	// the scheduler may publish it meanwhile, in which case it's no longer found as unpublished
+	post, err := controller.PostRepository.PublishPost(postID)
+	if err != nil {
+		responses.Error(w, http.StatusConflict, errors.New("This post is already published"))
+		return
+	}
+
+	mentionedUsers := controller.resolveMentions(&post)
+	parent, quoted := controller.repliedAndQuoted(post)
+
+	controller.Hub.PublishPost(post, stream.EventPost, post)
+	controller.Notifier.NotifyPublished(userNick, post, parent, quoted, mentionedUsers)
+
+	responses.JSON(w, http.StatusOK, post)
+}
+
 func (controller *PostsController) DeletePost(w http.ResponseWriter, r *http.Request) {
 	userNick, err := auth.GetUserNick(r)
 	if err != nil {
@@ -131,6 +341,10 @@ func (controller *PostsController) DeletePost(w http.ResponseWriter, r *http.Req
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	if postSavedOnDB.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post was deleted"))
+		return
+	}
 	if postSavedOnDB.AuthorNick != userNick {
 		responses.Error(w, http.StatusForbidden, errors.New("It's not possible deleting other's post"))
 		return
@@ -144,21 +358,122 @@ func (controller *PostsController) DeletePost(w http.ResponseWriter, r *http.Req
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
+// GetTrash returns the posts the user deleted that can still be restored
+func (controller *PostsController) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	posts, err := controller.PostRepository.GetTrash(userNick, time.Now().Add(-config.TrashRetention), page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+// RestorePost takes a post of the user out of the trash, while the trash retention didn't pass
+func (controller *PostsController) RestorePost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	if err = controller.PostRepository.RestorePost(postID, userNick, time.Now().Add(-config.TrashRetention)); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
 func (controller *PostsController) GetPost(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	postID := params["postID"]
 
-	post, err := controller.PostRepository.GetPostWithId(postID)
+	viewerNick, _ := auth.GetUserNick(r)
+
+	post, err := controller.PostRepository.GetVisiblePost(postID, viewerNick)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	if !post.Deleted {
+		users, err := controller.UserRepository.GetUsersByNicks([]string{viewerNick, post.AuthorNick})
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		usersByNick := map[string]entities.User{}
+		for _, user := range users {
+			usersByNick[user.Nick] = user
+		}
+
+		if !visibility.CanSee(usersByNick[viewerNick], usersByNick[post.AuthorNick]) {
+			responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+			return
+		}
+	}
+
+	posts, err := controller.resolveReferences(viewerNick, []entities.Post{post})
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responses.JSON(w, http.StatusOK, post)
+	if len(posts) == 0 {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, posts[0])
+}
+
+// GetRevisions lists the previous versions of the post, for the ones that can read it
+func (controller *PostsController) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	viewerNick, _ := auth.GetUserNick(r)
+
+	post, err := controller.PostRepository.GetVisiblePost(postID, viewerNick)
+	if err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	revisions, err := controller.PostRepository.GetRevisions(postID, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, revisions)
 }
 
 func (controller *PostsController) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	posts, err := controller.PostRepository.GetAllPosts()
+	viewerNick, _ := auth.GetUserNick(r)
+
+	posts, err := controller.PostRepository.GetAllPosts(viewerNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts, err = controller.resolveReferences(viewerNick, posts)
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
@@ -168,27 +483,712 @@ func (controller *PostsController) GetAllPosts(w http.ResponseWriter, r *http.Re
 }
 
 func (controller *PostsController) LikePost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
 	postID := params["postID"]
 
-	err := controller.PostRepository.Like(postID)
+	if post, err := controller.PostRepository.GetVisiblePost(postID, userNick); err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	err = controller.PostRepository.Like(postID, userNick)
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if post, err := controller.PostRepository.GetPostWithId(postID); err == nil && post.Published() {
+		controller.Hub.PublishPost(post, stream.EventLikes, stream.LikesChange{PostID: postID, Likes: post.Likes})
+		controller.Notifier.Notify(post.AuthorNick, entities.NotificationLike, userNick, postID)
+	}
+
 	responses.JSON(w, http.StatusOK, nil)
 }
 
 func (controller *PostsController) DislikePost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
 	postID := params["postID"]
 
-	err := controller.PostRepository.Dislike(postID)
+	if post, err := controller.PostRepository.GetVisiblePost(postID, userNick); err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	err = controller.PostRepository.Dislike(postID, userNick)
 	if err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if post, err := controller.PostRepository.GetPostWithId(postID); err == nil {
+		controller.Hub.PublishPost(post, stream.EventLikes, stream.LikesChange{PostID: postID, Likes: post.Likes})
+	}
+
 	responses.JSON(w, http.StatusOK, nil)
 }
+
+// ReactToPost leaves one of the available reactions on the post, each type counts once for a user
+func (controller *PostsController) ReactToPost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var reaction entities.PostReaction
+	if err = json.Unmarshal(reqbody, &reaction); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !isReaction(reaction.Type) {
+		responses.Error(w, http.StatusBadRequest, errors.New("This reaction isn't available"))
+		return
+	}
+
+	post, err := controller.PostRepository.GetVisiblePost(postID, userNick)
+	if err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	if err = controller.PostRepository.React(postID, userNick, reaction.Type); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if post, err := controller.PostRepository.GetPostWithId(postID); err == nil && post.Published() {
+		controller.Hub.PublishPost(post, stream.EventReactions, stream.ReactionsChange{PostID: postID, Reactions: post.Reactions})
+		controller.Notifier.Notify(post.AuthorNick, entities.NotificationReaction, userNick, postID)
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoveReaction takes back the reaction of the given type the user left on the post
+func (controller *PostsController) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+	reaction := r.URL.Query().Get("type")
+
+	if !isReaction(reaction) {
+		responses.Error(w, http.StatusBadRequest, errors.New("This reaction isn't available"))
+		return
+	}
+
+	if err = controller.PostRepository.Unreact(postID, userNick, reaction); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	if post, err := controller.PostRepository.GetPostWithId(postID); err == nil {
+		controller.Hub.PublishPost(post, stream.EventReactions, stream.ReactionsChange{PostID: postID, Reactions: post.Reactions})
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// GetReactions lists who reacted to the post, only with the given type when there's one
+func (controller *PostsController) GetReactions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID := params["postID"]
+	reaction := r.URL.Query().Get("type")
+
+	if reaction != "" && !isReaction(reaction) {
+		responses.Error(w, http.StatusBadRequest, errors.New("This reaction isn't available"))
+		return
+	}
+
+	viewerNick, _ := auth.GetUserNick(r)
+
+	post, err := controller.PostRepository.GetVisiblePost(postID, viewerNick)
+	if err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	reactions, err := controller.PostRepository.GetReactions(postID, reaction, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	nicks := []string{viewerNick}
+	for _, reaction := range reactions {
+		nicks = append(nicks, reaction.Nick)
+	}
+
+	users, err := controller.UserRepository.GetUsersByNicks(nicks)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	usersByNick := map[string]entities.User{}
+	for _, user := range users {
+		usersByNick[user.Nick] = user
+	}
+
+	// the ones reacting are left out when the viewer couldn't see their profile, so a page may come shorter
+	visibleReactions := []entities.PostReaction{}
+	for _, reaction := range reactions {
+		if visibility.CanSee(usersByNick[viewerNick], usersByNick[reaction.Nick]) {
+			visibleReactions = append(visibleReactions, reaction)
+		}
+	}
+
+	responses.JSON(w, http.StatusOK, visibleReactions)
+}
+
+// isReaction tells if the reaction is one of the configured ones
+func isReaction(reaction string) bool {
+	for _, available := range config.Reactions {
+		if reaction == available {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (controller *PostsController) GetReplies(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	page, limit := getPagination(r)
+
+	viewerNick, _ := auth.GetUserNick(r)
+
+	replies, err := controller.PostRepository.GetReplies(postID, viewerNick, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// replies can't quote, so there's nothing to resolve besides what's revealed to the viewer
+	replies, err = revealToViewer(controller.PostRepository, viewerNick, replies)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, replies)
+}
+
+func (controller *PostsController) GetThread(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	viewerNick, _ := auth.GetUserNick(r)
+
+	post, err := controller.PostRepository.GetVisiblePost(postID, viewerNick)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	rootID := post.RootID
+	if rootID == "" {
+		rootID = post.ID
+	}
+
+	conversation, err := controller.PostRepository.GetConversation(rootID, viewerNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	conversation, err = revealToViewer(controller.PostRepository, viewerNick, conversation)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	thread, err := threads.Build(post.ID, conversation)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, thread)
+}
+
+func (controller *PostsController) Repost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	original, err := controller.PostRepository.GetVisiblePost(postID, userNick)
+	if err != nil {
+		responses.Error(w, http.StatusNotFound, errors.New("It's not possible to repost a post that doesn't exists"))
+		return
+	}
+
+	if statusCode, err := controller.checkShareable(userNick, original); err != nil {
+		responses.Error(w, statusCode, err)
+		return
+	}
+
+	repost, err := controller.PostRepository.Repost(postID, userNick)
+	if err != nil {
+		responses.Error(w, http.StatusConflict, err)
+		return
+	}
+
+	repost.Original = &original
+	controller.Hub.PublishFrom(userNick, stream.EventPost, repost)
+
+	controller.Notifier.Notify(original.AuthorNick, entities.NotificationRepost, userNick, postID)
+
+	responses.JSON(w, http.StatusCreated, repost)
+}
+
+func (controller *PostsController) Unrepost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	if err = controller.PostRepository.Unrepost(postID, userNick); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// VotePoll registers the user's vote on the poll of a post, voting again with the same options changes nothing.
+// The post is returned with the poll results
+func (controller *PostsController) VotePoll(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	post, err := controller.PostRepository.GetVisiblePost(postID, userNick)
+	if err != nil || post.Deleted {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	if post.Poll == nil {
+		responses.Error(w, http.StatusBadRequest, errors.New("This post doesn't have a poll"))
+		return
+	}
+
+	if post.Poll.IsClosed(time.Now()) {
+		responses.Error(w, http.StatusConflict, errors.New("This poll is closed"))
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var vote entities.PollVote
+	if err = json.Unmarshal(reqbody, &vote); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	options, err := post.Poll.CheckVote(vote.Options)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = controller.PostRepository.Vote(postID, userNick, options); err != nil {
+		responses.Error(w, http.StatusConflict, err)
+		return
+	}
+
+	post, err = controller.PostRepository.GetPostWithId(postID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	post.Poll.Reveal(options, time.Now())
+
+	responses.JSON(w, http.StatusOK, post)
+}
+
+// PinPost pins one of the user's published posts to the top of the profile
+func (controller *PostsController) PinPost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	post, err := controller.PostRepository.GetPostWithId(postID)
+	if err != nil || post.Deleted || !post.Published() {
+		responses.Error(w, http.StatusNotFound, errors.New("This post doens't exists"))
+		return
+	}
+
+	if post.AuthorNick != userNick {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible to pin other's posts"))
+		return
+	}
+
+	if post.RepostOf != "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to pin a repost"))
+		return
+	}
+
+	if err = controller.UserRepository.PinPost(userNick, postID, config.MaxPinnedPosts); err != nil {
+		responses.Error(w, http.StatusConflict, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *PostsController) UnpinPost(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	postID := params["postID"]
+
+	if err = controller.UserRepository.UnpinPost(userNick, postID); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *PostsController) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	user, err := controller.UserRepository.GetUserByNick(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	muted := map[string]bool{}
+	for _, nick := range user.Muted {
+		muted[nick] = true
+	}
+
+	nicks := []string{userNick}
+	for _, nick := range user.Following {
+		if !muted[nick] {
+			nicks = append(nicks, nick)
+		}
+	}
+
+	page, limit := getPagination(r)
+
+	posts, err := controller.PostRepository.GetFeed(nicks, userNick, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts, err = controller.resolveReferences(userNick, posts)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+func (controller *PostsController) GetMentions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userNick := params["userID"]
+
+	page, limit := getPagination(r)
+
+	viewerNick, _ := auth.GetUserNick(r)
+
+	posts, err := controller.PostRepository.GetMentions(userNick, viewerNick, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts, err = controller.resolveReferences(viewerNick, posts)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+// resolveMentions keeps only the mentions of users that exist, returning them by nick
+func (controller *PostsController) resolveMentions(post *entities.Post) map[string]entities.User {
+	users := map[string]entities.User{}
+	mentions := []entities.Mention{}
+	for _, mention := range post.Mentions {
+		user, found := users[mention.Nick]
+		if !found {
+			var err error
+			if user, err = controller.UserRepository.GetUserByNick(mention.Nick); err != nil {
+				continue
+			}
+			users[mention.Nick] = user
+		}
+
+		mention.Nick = user.Nick
+		mentions = append(mentions, mention)
+	}
+
+	post.Mentions = mentions
+	return users
+}
+
+// repliedAndQuoted loads the posts a draft or scheduled post replies and quotes once it's published, the ones
+// no longer found are left empty
+func (controller *PostsController) repliedAndQuoted(post entities.Post) (entities.Post, entities.Post) {
+	var parent, quoted entities.Post
+	if post.ParentID != "" {
+		parent, _ = controller.PostRepository.GetPostWithId(post.ParentID)
+	}
+	if post.QuoteOf != "" {
+		quoted, _ = controller.PostRepository.GetPostWithId(post.QuoteOf)
+	}
+
+	return parent, quoted
+}
+
+// checkShareable tells if the user can repost or quote the post, returning the status code to be used when not
+func (controller *PostsController) checkShareable(userNick string, post entities.Post) (int, error) {
+	if post.Deleted {
+		return http.StatusBadRequest, errors.New("It's not possible to share a deleted post")
+	}
+
+	if post.RepostOf != "" {
+		return http.StatusBadRequest, errors.New("It's not possible to share a repost, share the original post instead")
+	}
+
+	// restricted posts can't be spread beyond the ones chosen by the author, not even by the author
+	if !post.ReadableByAnyone() {
+		return http.StatusForbidden, errors.New("It's not possible to share a post that isn't public")
+	}
+
+	if post.AuthorNick == userNick {
+		return 0, nil
+	}
+
+	users, err := controller.UserRepository.GetUsersByNicks([]string{userNick, post.AuthorNick})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	viewer, author := entities.User{}, entities.User{}
+	for _, user := range users {
+		if user.Nick == userNick {
+			viewer = user
+		}
+		if user.Nick == post.AuthorNick {
+			author = user
+		}
+	}
+
+	if !visibility.CanSee(viewer, author) {
+		return http.StatusNotFound, errors.New("This post doens't exists")
+	}
+
+	// posts from private accounts can't be spread beyond the followers
+	if author.Private {
+		return http.StatusForbidden, errors.New("It's not possible to share posts from a private account")
+	}
+
+	return 0, nil
+}
+
+// resolveReferences fills the original post of reposts and quotes. Reposts of posts the viewer can't see, or
+// that were deleted, are removed, while quotes keep their commentary with the original marked as deleted.
+// The polls and the reactions of the posts and their originals are revealed to the viewer
+func (controller *PostsController) resolveReferences(viewerNick string, posts []entities.Post) ([]entities.Post, error) {
+	return resolvePostReferences(controller.PostRepository, controller.UserRepository, viewerNick, posts)
+}
+
+func resolvePostReferences(postRepository repositories.PostsRepository, userRepository repositories.UsersRepository, viewerNick string, posts []entities.Post) ([]entities.Post, error) {
+	ids := []string{}
+	for _, post := range posts {
+		if post.RepostOf != "" {
+			ids = append(ids, post.RepostOf)
+		}
+		if post.QuoteOf != "" {
+			ids = append(ids, post.QuoteOf)
+		}
+	}
+
+	if len(ids) == 0 {
+		return revealToViewer(postRepository, viewerNick, posts)
+	}
+
+	originals, err := postRepository.GetPostsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	nicks := []string{viewerNick}
+	originalsByID := map[string]entities.Post{}
+	for _, original := range originals {
+		originalsByID[original.ID] = original
+		if original.AuthorNick != "" {
+			nicks = append(nicks, original.AuthorNick)
+		}
+	}
+
+	users, err := userRepository.GetUsersByNicks(nicks)
+	if err != nil {
+		return nil, err
+	}
+
+	usersByNick := map[string]entities.User{}
+	for _, user := range users {
+		usersByNick[user.Nick] = user
+	}
+	viewer := usersByNick[viewerNick]
+
+	resolved := []entities.Post{}
+	for _, post := range posts {
+		originalID := post.RepostOf
+		if originalID == "" {
+			originalID = post.QuoteOf
+		}
+
+		if originalID == "" {
+			resolved = append(resolved, post)
+			continue
+		}
+
+		original, found := originalsByID[originalID]
+		visible := found && canView(viewer, usersByNick[original.AuthorNick], original)
+
+		if post.RepostOf != "" && !visible {
+			continue
+		}
+
+		if !visible {
+			original = entities.Post{ID: originalID, Deleted: true}
+		}
+
+		post.Original = &original
+		resolved = append(resolved, post)
+	}
+
+	return revealToViewer(postRepository, viewerNick, resolved)
+}
+
+// revealToViewer fills the polls of the posts, and of their originals, with the viewer's votes and the results
+// the viewer is allowed to see, along with the reactions the viewer left on them
+func revealToViewer(postRepository repositories.PostsRepository, viewerNick string, posts []entities.Post) ([]entities.Post, error) {
+	pollIDs := []string{}
+	reactedIDs := []string{}
+	for i := range posts {
+		for _, post := range withOriginal(&posts[i]) {
+			if post.Poll != nil {
+				pollIDs = append(pollIDs, post.ID)
+			}
+			if len(post.Reactions) > 0 {
+				reactedIDs = append(reactedIDs, post.ID)
+			}
+		}
+	}
+
+	var err error
+	votes := map[string][]int{}
+	if viewerNick != "" && len(pollIDs) > 0 {
+		if votes, err = postRepository.GetPollVotes(viewerNick, pollIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	reactions := map[string][]string{}
+	if viewerNick != "" && len(reactedIDs) > 0 {
+		if reactions, err = postRepository.GetOwnReactions(viewerNick, reactedIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for i := range posts {
+		for _, post := range withOriginal(&posts[i]) {
+			if post.Poll != nil {
+				post.Poll.Reveal(votes[post.ID], now)
+			}
+			post.OwnReactions = reactions[post.ID]
+		}
+	}
+
+	return posts, nil
+}
+
+// withOriginal returns the post along with the post it reposts or quotes, when filled
+func withOriginal(post *entities.Post) []*entities.Post {
+	if post.Original == nil {
+		return []*entities.Post{post}
+	}
+
+	return []*entities.Post{post, post.Original}
+}
+
+// canView tells if the viewer can see the post, which has to be published and not deleted, besides readable
+// by the viewer on an account they can see
+func canView(viewer entities.User, author entities.User, post entities.Post) bool {
+	return !post.Deleted && post.Published() && visibility.CanSee(viewer, author) && visibility.CanRead(viewer, post)
+}
@@ -1,15 +1,20 @@
 package controllers
 
 import (
+	"api/internal/application/stream"
 	"api/internal/domain/entities"
 	"api/internal/domain/repositories/mocks"
+	"api/internal/infrastructure/config"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -81,7 +86,7 @@ func TestCreatePost(t *testing.T) {
 			repositoryMock := mocks.NewPostsRepositoryMock()
 			repositoryMock.On("CreatePost", mock.AnythingOfType("entities.Post")).Return(test.expectedCreatePostResult, test.expectedError)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req := httptest.NewRequest("POST", "/posts", test.input)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -132,9 +137,9 @@ func TestGetPosts(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewPostsRepositoryMock()
-			repositoryMock.On("GetPosts", test.userId).Return(test.expectedGetAllPostsResult, test.expectedError)
+			repositoryMock.On("GetPosts", test.userId, "1").Return(test.expectedGetAllPostsResult, test.expectedError)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/posts/", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -165,6 +170,7 @@ func TestUpdatePost(t *testing.T) {
 		expectedPostWithIdError  error
 		expectedStatusCode       int
 		expectedUpdatedResult    error
+		nothingChanged           bool
 	}{
 		{
 			name:                    "Success on UpdatePost",
@@ -176,6 +182,17 @@ func TestUpdatePost(t *testing.T) {
 			expectedPostWithIdError: nil,
 			expectedUpdatedResult:   nil,
 		},
+		{
+			name:                    "Success on UpdatePost, nothing changed",
+			input:                   `{"title": "Test Post", "content": "new post"}`,
+			urlId:                   "64a399cdb6a0487490ed730c",
+			validToken:              ValidToken,
+			userId:                  "1",
+			expectedStatusCode:      204,
+			expectedPostWithIdError: nil,
+			expectedUpdatedResult:   assert.AnError,
+			nothingChanged:          true,
+		},
 		{
 			name:                    "Error on UpdatePost, unexistent url ID",
 			input:                   `{"title": "Wow", "content": "updated post"}`,
@@ -225,7 +242,7 @@ func TestUpdatePost(t *testing.T) {
 			repositoryMock.On("GetPostWithId", test.urlId).Return(postMocked, test.expectedPostWithIdError)
 			repositoryMock.On("UpdatePost", test.urlId, mock.AnythingOfType("entities.Post")).Return(test.expectedUpdatedResult)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("PUT", "/posts/", strings.NewReader(test.input))
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -241,6 +258,9 @@ func TestUpdatePost(t *testing.T) {
 
 			assert.Equal(t, test.expectedStatusCode, rr.Code)
 
+			if test.nothingChanged {
+				repositoryMock.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything)
+			}
 		})
 	}
 }
@@ -311,7 +331,7 @@ func TestDeletePost(t *testing.T) {
 			repositoryMock.On("GetPostWithId", test.urlId).Return(postMocked, test.expectedPostWithIdError)
 			repositoryMock.On("DeletePost", test.urlId).Return(test.expectedDeleteResult)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("DELETE", "/posts/", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -349,12 +369,12 @@ func TestGetPost(t *testing.T) {
 			expectedStatusCode:    200,
 		},
 		{
-			name:                  "Error on GetPost",
+			name:                  "Error on GetPost, not visible to the user",
 			urlId:                 "64a399cdb6a0487490ed730c",
 			validToken:            ValidToken,
 			expectedGetPostResult: entities.Post{},
 			expectedError:         assert.AnError,
-			expectedStatusCode:    500,
+			expectedStatusCode:    404,
 		},
 		{
 			name:                  "Wrong postID",
@@ -362,16 +382,19 @@ func TestGetPost(t *testing.T) {
 			validToken:            ValidToken,
 			expectedGetPostResult: entities.Post{},
 			expectedError:         assert.AnError,
-			expectedStatusCode:    500,
+			expectedStatusCode:    404,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewPostsRepositoryMock()
-			repositoryMock.On("GetPostWithId", test.urlId).Return(postMocked, test.expectedError)
+			repositoryMock.On("GetVisiblePost", test.urlId, "1").Return(postMocked, test.expectedError)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUsersByNicks", []string{"1", "1"}).Return([]entities.User{{Nick: "1"}}, nil)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/posts/", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -390,6 +413,64 @@ func TestGetPost(t *testing.T) {
 	}
 }
 
+func TestGetPostHiddenAuthor(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		author             entities.User
+		viewer             entities.User
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetPost, private author followed by the viewer",
+			author:             entities.User{Nick: "2", Private: true, Followers: []string{"1"}},
+			viewer:             entities.User{Nick: "1", Following: []string{"2"}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetPost, private author not followed by the viewer",
+			author:             entities.User{Nick: "2", Private: true},
+			viewer:             entities.User{Nick: "1"},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on GetPost, viewer blocked by the author",
+			author:             entities.User{Nick: "2", Blocked: []string{"1"}},
+			viewer:             entities.User{Nick: "1"},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on GetPost, author blocked by the viewer",
+			author:             entities.User{Nick: "2"},
+			viewer:             entities.User{Nick: "1", Blocked: []string{"2"}},
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "2"}, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUsersByNicks", []string{"1", "2"}).Return([]entities.User{test.viewer, test.author}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/64a399cdb6a0487490ed730c", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
 func TestGetAllPosts(t *testing.T) {
 
 	tests := []struct {
@@ -418,9 +499,9 @@ func TestGetAllPosts(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewPostsRepositoryMock()
-			repositoryMock.On("GetAllPosts").Return(test.expectedGetAllPostsResult, test.expectedError)
+			repositoryMock.On("GetAllPosts", "").Return(test.expectedGetAllPostsResult, test.expectedError)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/posts/", nil)
 
@@ -441,6 +522,7 @@ func TestLikePost(t *testing.T) {
 		urlId              string
 		validToken         string
 		expectedError      error
+		postNotVisible     bool
 		expectedStatusCode int
 	}{
 		{
@@ -464,14 +546,40 @@ func TestLikePost(t *testing.T) {
 			expectedError:      assert.AnError,
 			expectedStatusCode: 500,
 		},
+		{
+			name:               "Error on LikePost, post not visible to the user",
+			urlId:              "64a399cdb6a0487490ed730c",
+			validToken:         ValidToken,
+			postNotVisible:     true,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on LikePost, invalid token",
+			urlId:              "64a399cdb6a0487490ed730c",
+			validToken:         ValidToken + "invalidate",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 401,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewPostsRepositoryMock()
-			repositoryMock.On("Like", test.urlId).Return(test.expectedError)
+			repositoryMock.On("Like", test.urlId, "1").Return(test.expectedError)
+			if test.postNotVisible {
+				repositoryMock.On("GetVisiblePost", test.urlId, "1").Return(entities.Post{}, assert.AnError)
+			} else {
+				repositoryMock.On("GetVisiblePost", test.urlId, "1").Return(entities.Post{ID: test.urlId, AuthorNick: "2"}, nil)
+			}
+			repositoryMock.On("GetPostWithId", test.urlId).Return(entities.Post{ID: test.urlId, AuthorNick: "2"}, nil)
 
-			postsController := NewPostsController(repositoryMock)
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "2").Return(entities.User{Nick: "2"}, nil)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("POST", "/posts/", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -486,6 +594,16 @@ func TestLikePost(t *testing.T) {
 			controller.ServeHTTP(rr, req)
 
 			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedError == nil && !test.postNotVisible {
+				notificationsRepositoryMock.AssertCalled(t, "AddNotification", entities.Notification{
+					Recipient: "2",
+					Type:      entities.NotificationLike,
+					Actor:     "1",
+					PostID:    test.urlId,
+				})
+			} else {
+				notificationsRepositoryMock.AssertNotCalled(t, "AddNotification", mock.Anything)
+			}
 		})
 	}
 }
@@ -497,6 +615,7 @@ func TestDislikePost(t *testing.T) {
 		urlId              string
 		validToken         string
 		expectedError      error
+		postNotVisible     bool
 		expectedStatusCode int
 	}{
 		{
@@ -520,14 +639,34 @@ func TestDislikePost(t *testing.T) {
 			expectedError:      assert.AnError,
 			expectedStatusCode: 500,
 		},
+		{
+			name:               "Error on DislikePost, post not visible to the user",
+			urlId:              "64a399cdb6a0487490ed730c",
+			validToken:         ValidToken,
+			postNotVisible:     true,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on DislikePost, invalid token",
+			urlId:              "64a399cdb6a0487490ed730c",
+			validToken:         ValidToken + "invalidate",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 401,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewPostsRepositoryMock()
-			repositoryMock.On("Dislike", test.urlId).Return(test.expectedError)
+			repositoryMock.On("Dislike", test.urlId, "1").Return(test.expectedError)
+			if test.postNotVisible {
+				repositoryMock.On("GetVisiblePost", test.urlId, "1").Return(entities.Post{}, assert.AnError)
+			} else {
+				repositoryMock.On("GetVisiblePost", test.urlId, "1").Return(entities.Post{ID: test.urlId, AuthorNick: "2"}, nil)
+			}
+			repositoryMock.On("GetPostWithId", test.urlId).Return(entities.Post{ID: test.urlId, AuthorNick: "2"}, nil)
 
-			postsController := NewPostsController(repositoryMock)
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("POST", "/posts/", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -545,3 +684,1791 @@ func TestDislikePost(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateReply(t *testing.T) {
+
+	tests := []struct {
+		name                 string
+		input                string
+		expectedParentResult entities.Post
+		expectedParentError  error
+		expectedRootID       string
+		expectedStatusCode   int
+	}{
+		{
+			name:                 "Success on CreatePost, reply to the first post",
+			input:                `{"content": "reply", "parentId": "64a399cdb6a0487490ed730c"}`,
+			expectedParentResult: entities.Post{ID: "64a399cdb6a0487490ed730c"},
+			expectedRootID:       "64a399cdb6a0487490ed730c",
+			expectedStatusCode:   201,
+		},
+		{
+			name:                 "Success on CreatePost, reply to a reply",
+			input:                `{"content": "reply", "parentId": "64a399cdb6a0487490ed730c"}`,
+			expectedParentResult: entities.Post{ID: "64a399cdb6a0487490ed730c", ParentID: "64a399cdb6a0487490ed730a", RootID: "64a399cdb6a0487490ed730a"},
+			expectedRootID:       "64a399cdb6a0487490ed730a",
+			expectedStatusCode:   201,
+		},
+		{
+			name:                "Error on CreatePost, unexistent parent",
+			input:               `{"content": "reply", "parentId": "64a399cdb6a0487490ed730c"}`,
+			expectedParentError: assert.AnError,
+			expectedStatusCode:  404,
+		},
+		{
+			name:                 "Error on CreatePost, deleted parent",
+			input:                `{"content": "reply", "parentId": "64a399cdb6a0487490ed730c"}`,
+			expectedParentResult: entities.Post{ID: "64a399cdb6a0487490ed730c", Deleted: true},
+			expectedStatusCode:   400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(test.expectedParentResult, test.expectedParentError)
+			repositoryMock.On("CreatePost", mock.MatchedBy(func(post entities.Post) bool {
+				return post.RootID == test.expectedRootID
+			})).Return(entities.Post{}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req := httptest.NewRequest("POST", "/posts", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.CreatePost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetReplies(t *testing.T) {
+
+	tests := []struct {
+		name                    string
+		query                   string
+		expectedPage            int
+		expectedLimit           int
+		expectedGetRepliesError error
+		expectedStatusCode      int
+	}{
+		{
+			name:               "Success on GetReplies",
+			query:              "",
+			expectedPage:       1,
+			expectedLimit:      20,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on GetReplies, second page",
+			query:              "?page=2&limit=5",
+			expectedPage:       2,
+			expectedLimit:      5,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on GetReplies, page out of range",
+			query:              "?page=9223372036854775807&limit=100",
+			expectedPage:       maxPage,
+			expectedLimit:      100,
+			expectedStatusCode: 200,
+		},
+		{
+			name:                    "Error on GetReplies",
+			query:                   "",
+			expectedPage:            1,
+			expectedLimit:           20,
+			expectedGetRepliesError: assert.AnError,
+			expectedStatusCode:      500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetReplies", "64a399cdb6a0487490ed730c", "1", test.expectedPage, test.expectedLimit).Return([]entities.Post{}, test.expectedGetRepliesError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/64a399cdb6a0487490ed730c/replies"+test.query, nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetReplies)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetThread(t *testing.T) {
+	root := entities.Post{ID: "a", Title: "root"}
+	tombstone := entities.Post{ID: "b", ParentID: "a", RootID: "a", Deleted: true, ReplyCount: 1}
+	reply := entities.Post{ID: "c", ParentID: "b", RootID: "a", Content: "reply"}
+
+	tests := []struct {
+		name                      string
+		postID                    string
+		expectedPostResult        entities.Post
+		expectedPostError         error
+		expectedConversationError error
+		expectedStatusCode        int
+		expectedBody              string
+	}{
+		{
+			name:               "Success on GetThread",
+			postID:             "b",
+			expectedPostResult: tombstone,
+			expectedStatusCode: 200,
+			expectedBody:       `"ancestors":[{"id":"a","title":"root","likes":0,"replyCount":0,`,
+		},
+		{
+			name:               "Error on GetThread, unexistent post",
+			postID:             "b",
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+		{
+			name:                      "Error on GetThread, GetConversation",
+			postID:                    "b",
+			expectedPostResult:        tombstone,
+			expectedConversationError: assert.AnError,
+			expectedStatusCode:        500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", test.postID, "1").Return(test.expectedPostResult, test.expectedPostError)
+			repositoryMock.On("GetConversation", "a", "1").Return([]entities.Post{root, tombstone, reply}, test.expectedConversationError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/"+test.postID+"/thread", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": test.postID})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetThread)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedBody != "" {
+				assert.Contains(t, rr.Body.String(), test.expectedBody)
+				assert.Contains(t, rr.Body.String(), `"replies":[{"id":"c"`)
+			}
+		})
+	}
+}
+
+func TestRepliesAndThreadPollResults(t *testing.T) {
+	root := entities.Post{ID: "a", Title: "root"}
+	reply := entities.Post{ID: "b", ParentID: "a", RootID: "a", Content: "reply", Poll: newTestPoll(false, time.Now().Add(time.Hour))}
+
+	tests := []struct {
+		name    string
+		url     string
+		handler func(*PostsController) http.HandlerFunc
+	}{
+		{
+			name:    "Poll results on GetReplies",
+			url:     "/posts/a/replies",
+			handler: func(controller *PostsController) http.HandlerFunc { return controller.GetReplies },
+		},
+		{
+			name:    "Poll results on GetThread",
+			url:     "/posts/a/thread",
+			handler: func(controller *PostsController) http.HandlerFunc { return controller.GetThread },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// every request gets its own copy of the poll, as revealing it fills the results in place
+			votedReply := reply
+			poll := *reply.Poll
+			poll.Options = append([]entities.PollOption{}, reply.Poll.Options...)
+			votedReply.Poll = &poll
+
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetReplies", "a", "1", 1, 20).Return([]entities.Post{votedReply}, nil)
+			repositoryMock.On("GetVisiblePost", "a", "1").Return(root, nil)
+			repositoryMock.On("GetConversation", "a", "1").Return([]entities.Post{root, votedReply}, nil)
+			repositoryMock.On("GetPollVotes", "1", []string{"b"}).Return(map[string][]int{"b": {0}}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", test.url, nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "a"})
+
+			rr := httptest.NewRecorder()
+
+			test.handler(postsController).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Body.String(), `"ownVotes":[0]`)
+			assert.Contains(t, rr.Body.String(), `"votes":3`)
+		})
+	}
+}
+
+func TestRepost(t *testing.T) {
+
+	tests := []struct {
+		name                string
+		expectedPostResult  entities.Post
+		expectedPostError   error
+		expectedUsersResult []entities.User
+		expectedRepostError error
+		expectedStatusCode  int
+	}{
+		{
+			name:                "Success on Repost",
+			expectedPostResult:  entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "3"},
+			expectedUsersResult: []entities.User{{Nick: "1"}, {Nick: "3"}},
+			expectedStatusCode:  201,
+		},
+		{
+			name:                "Error on Repost, already reposted",
+			expectedPostResult:  entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "3"},
+			expectedUsersResult: []entities.User{{Nick: "1"}, {Nick: "3"}},
+			expectedRepostError: assert.AnError,
+			expectedStatusCode:  409,
+		},
+		{
+			name:               "Error on Repost, unexistent post",
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on Repost, deleted post",
+			expectedPostResult: entities.Post{ID: "64a399cdb6a0487490ed730c", Deleted: true},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on Repost, repost of a repost",
+			expectedPostResult: entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "3", RepostOf: "64a399cdb6a0487490ed730a"},
+			expectedStatusCode: 400,
+		},
+		{
+			name:                "Error on Repost, private account followed by the user",
+			expectedPostResult:  entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "3"},
+			expectedUsersResult: []entities.User{{Nick: "1"}, {Nick: "3", Private: true, Followers: []string{"1"}}},
+			expectedStatusCode:  403,
+		},
+		{
+			name:               "Error on Repost, followers-only post",
+			expectedPostResult: entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "3", Visibility: entities.VisibilityFollowers},
+			expectedStatusCode: 403,
+		},
+		{
+			name:                "Error on Repost, blocked by the author",
+			expectedPostResult:  entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "3"},
+			expectedUsersResult: []entities.User{{Nick: "1"}, {Nick: "3", Blocked: []string{"1"}}},
+			expectedStatusCode:  404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(test.expectedPostResult, test.expectedPostError)
+			repositoryMock.On("Repost", "64a399cdb6a0487490ed730c", "1").Return(entities.Post{}, test.expectedRepostError)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUsersByNicks", []string{"1", "3"}).Return(test.expectedUsersResult, nil)
+			usersRepositoryMock.On("GetUserByNick", "3").Return(entities.User{Nick: "3"}, nil)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/repost", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.Repost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestUnrepost(t *testing.T) {
+
+	tests := []struct {
+		name                  string
+		expectedUnrepostError error
+		expectedStatusCode    int
+	}{
+		{
+			name:               "Success on Unrepost",
+			expectedStatusCode: 204,
+		},
+		{
+			name:                  "Error on Unrepost, not reposted",
+			expectedUnrepostError: assert.AnError,
+			expectedStatusCode:    400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("Unrepost", "64a399cdb6a0487490ed730c", "1").Return(test.expectedUnrepostError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("DELETE", "/posts/64a399cdb6a0487490ed730c/repost", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.Unrepost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetFeed(t *testing.T) {
+	feed := []entities.Post{
+		{ID: "r1", AuthorNick: "2", RepostOf: "public"},
+		{ID: "r2", AuthorNick: "2", RepostOf: "private"},
+		{ID: "q1", AuthorNick: "2", Content: "look", QuoteOf: "removed"},
+		{ID: "q2", AuthorNick: "2", Content: "look again", QuoteOf: "restricted"},
+		{ID: "p1", AuthorNick: "1", Title: "mine"},
+	}
+	originals := []entities.Post{
+		{ID: "public", AuthorNick: "3", Title: "public post"},
+		{ID: "private", AuthorNick: "4", Title: "private post"},
+		// the quote was made before the author restricted the post to the followers
+		{ID: "restricted", AuthorNick: "3", Title: "restricted post", Visibility: entities.VisibilityFollowers},
+	}
+	users := []entities.User{{Nick: "1", Following: []string{"2"}}, {Nick: "3"}, {Nick: "4", Private: true}}
+
+	tests := []struct {
+		name               string
+		expectedUserError  error
+		expectedFeedError  error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetFeed",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetFeed, GetUserByNick",
+			expectedUserError:  assert.AnError,
+			expectedStatusCode: 500,
+		},
+		{
+			name:               "Error on GetFeed",
+			expectedFeedError:  assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetFeed", []string{"1", "2"}, "1", 1, 20).Return(feed, test.expectedFeedError)
+			repositoryMock.On("GetPostsByIDs", []string{"public", "private", "removed", "restricted"}).Return(originals, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1", Following: []string{"2"}}, test.expectedUserError)
+			usersRepositoryMock.On("GetUsersByNicks", []string{"1", "3", "4", "3"}).Return(users, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/feed", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetFeed)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode == 200 {
+				body := rr.Body.String()
+				assert.Contains(t, body, `"id":"r1"`)
+				assert.NotContains(t, body, `"id":"r2"`)
+				assert.Contains(t, body, `"original":{"id":"removed","likes":0,"replyCount":0,"repostCount":0,"quoteCount":0,"deleted":true`)
+				assert.Contains(t, body, `"original":{"id":"restricted","likes":0,"replyCount":0,"repostCount":0,"quoteCount":0,"deleted":true`)
+				assert.Contains(t, body, `"id":"p1"`)
+			}
+		})
+	}
+}
+
+func TestCreatePostHashtags(t *testing.T) {
+	repositoryMock := mocks.NewPostsRepositoryMock()
+	repositoryMock.On("CreatePost", mock.AnythingOfType("entities.Post")).Return(entities.Post{}, nil)
+
+	postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(`{"title": "Go", "content": "Learning #Go and #golang, #go again #1 a#b"}`))
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(postsController.CreatePost)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	createdPost := repositoryMock.Calls[0].Arguments.Get(0).(entities.Post)
+	assert.Equal(t, []string{"go", "golang"}, createdPost.Tags)
+}
+
+func TestCreatePostMentions(t *testing.T) {
+	repositoryMock := mocks.NewPostsRepositoryMock()
+	repositoryMock.On("CreatePost", mock.AnythingOfType("entities.Post")).Return(entities.Post{ID: "64a399cdb6a0487490ed730c"}, nil)
+
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "alice").Return(entities.User{Nick: "alice"}, nil)
+	usersRepositoryMock.On("GetUserByNick", "bob").Return(entities.User{Nick: "bob", Muted: []string{"1"}}, nil)
+	usersRepositoryMock.On("GetUserByNick", "ghost").Return(entities.User{}, assert.AnError)
+
+	notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+	notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+	postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, stream.NewHub(10, 10))
+
+	input := `{"title": "Hi", "content": "olá @alice, @bob and @ghost! mail me at me@alice.com @alice"}`
+	req := httptest.NewRequest("POST", "/posts", strings.NewReader(input))
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(postsController.CreatePost)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	createdPost := repositoryMock.Calls[0].Arguments.Get(0).(entities.Post)
+	assert.Equal(t, []entities.Mention{
+		{Nick: "alice", Start: 4, End: 10},
+		{Nick: "bob", Start: 12, End: 16},
+		{Nick: "alice", Start: 53, End: 59},
+	}, createdPost.Mentions)
+
+	// bob muted the author, so only alice is notified, once
+	notificationsRepositoryMock.AssertNumberOfCalls(t, "AddNotification", 1)
+	notificationsRepositoryMock.AssertCalled(t, "AddNotification", entities.Notification{
+		Recipient: "alice",
+		Type:      entities.NotificationMention,
+		Actor:     "1",
+		PostID:    "64a399cdb6a0487490ed730c",
+	})
+}
+
+func TestUpdatePostMentions(t *testing.T) {
+	savedPost := postMocked
+	savedPost.Mentions = []entities.Mention{{Nick: "alice", Start: 0, End: 6}}
+
+	repositoryMock := mocks.NewPostsRepositoryMock()
+	repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(savedPost, nil)
+	repositoryMock.On("UpdatePost", "64a399cdb6a0487490ed730c", mock.AnythingOfType("entities.Post")).Return(nil)
+
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "alice").Return(entities.User{Nick: "alice"}, nil)
+	usersRepositoryMock.On("GetUserByNick", "carol").Return(entities.User{Nick: "carol"}, nil)
+
+	notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+	notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+	postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("PUT", "/posts/", strings.NewReader(`{"title": "Hi", "content": "@alice and now @carol"}`))
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(postsController.UpdatePost)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// alice was already mentioned before the update
+	notificationsRepositoryMock.AssertNumberOfCalls(t, "AddNotification", 1)
+	notificationsRepositoryMock.AssertCalled(t, "AddNotification", entities.Notification{
+		Recipient: "carol",
+		Type:      entities.NotificationMention,
+		Actor:     "1",
+		PostID:    "64a399cdb6a0487490ed730c",
+	})
+}
+
+func TestGetMentions(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetMentions",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetMentions",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetMentions", "alice", "1", 1, 20).Return([]entities.Post{}, test.expectedError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/users/alice/mentions", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"userID": "alice"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetMentions)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestCreatePostVisibility(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		input              string
+		expectedVisibility string
+		expectedNotified   []string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on CreatePost, public by default",
+			input:              `{"title": "Hi", "content": "hi @alice and @bob"}`,
+			expectedVisibility: entities.VisibilityPublic,
+			expectedNotified:   []string{"alice", "bob"},
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Success on CreatePost, followers-only",
+			input:              `{"title": "Hi", "content": "hi @alice and @bob", "visibility": "followers"}`,
+			expectedVisibility: entities.VisibilityFollowers,
+			expectedNotified:   []string{"bob"},
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Success on CreatePost, mentioned-only",
+			input:              `{"title": "Hi", "content": "hi @alice and @bob", "visibility": "mentioned"}`,
+			expectedVisibility: entities.VisibilityMentioned,
+			expectedNotified:   []string{"alice", "bob"},
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Error on CreatePost, invalid visibility",
+			input:              `{"title": "Hi", "content": "hi", "visibility": "friends"}`,
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("CreatePost", mock.AnythingOfType("entities.Post")).Return(entities.Post{
+				ID:         "64a399cdb6a0487490ed730c",
+				AuthorNick: "1",
+				Visibility: test.expectedVisibility,
+				Mentions:   []entities.Mention{{Nick: "alice"}, {Nick: "bob"}},
+			}, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "alice").Return(entities.User{Nick: "alice"}, nil)
+			usersRepositoryMock.On("GetUserByNick", "bob").Return(entities.User{Nick: "bob", Following: []string{"1"}}, nil)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+			hub := stream.NewHub(10, 10)
+			follower, _ := hub.Subscribe("3", []string{"1"}, nil, 0)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, hub)
+
+			req := httptest.NewRequest("POST", "/posts", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.CreatePost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode != 201 {
+				repositoryMock.AssertNotCalled(t, "CreatePost", mock.Anything)
+				return
+			}
+
+			createdPost := repositoryMock.Calls[0].Arguments.Get(0).(entities.Post)
+			assert.Equal(t, test.expectedVisibility, createdPost.Visibility)
+
+			notified := []string{}
+			for _, call := range notificationsRepositoryMock.Calls {
+				notified = append(notified, call.Arguments.Get(0).(entities.Notification).Recipient)
+			}
+			assert.ElementsMatch(t, test.expectedNotified, notified)
+
+			// a follower that isn't mentioned doesn't get mentioned-only posts
+			assert.Equal(t, test.expectedVisibility != entities.VisibilityMentioned, len(follower.Events) == 1)
+		})
+	}
+}
+
+func TestUpdatePostEditWindow(t *testing.T) {
+	editWindow := config.PostEditWindow
+	config.PostEditWindow = time.Hour
+	defer func() { config.PostEditWindow = editWindow }()
+
+	tests := []struct {
+		name               string
+		createdAt          time.Time
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on UpdatePost, inside the edit window",
+			createdAt:          time.Now().Add(-10 * time.Minute),
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on UpdatePost, after the edit window",
+			createdAt:          time.Now().Add(-2 * time.Hour),
+			expectedStatusCode: 403,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1", CreatedAt: test.createdAt}, nil)
+			repositoryMock.On("UpdatePost", "64a399cdb6a0487490ed730c", mock.AnythingOfType("entities.Post")).Return(nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("PUT", "/posts/64a399cdb6a0487490ed730c", strings.NewReader(`{"title": "Wow", "content": "updated post"}`))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.UpdatePost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode != 204 {
+				repositoryMock.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGetRevisions(t *testing.T) {
+	revisions := []entities.PostRevision{
+		{PostID: "64a399cdb6a0487490ed730c", Title: "Second", Content: "second version"},
+		{PostID: "64a399cdb6a0487490ed730c", Title: "First", Content: "first version"},
+	}
+
+	tests := []struct {
+		name                   string
+		expectedPostResult     entities.Post
+		expectedPostError      error
+		expectedRevisionsError error
+		expectedStatusCode     int
+	}{
+		{
+			name:               "Success on GetRevisions",
+			expectedPostResult: entities.Post{ID: "64a399cdb6a0487490ed730c", Edited: true, EditCount: 2},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetRevisions, not visible to the user",
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on GetRevisions, deleted post",
+			expectedPostResult: entities.Post{ID: "64a399cdb6a0487490ed730c", Deleted: true},
+			expectedStatusCode: 404,
+		},
+		{
+			name:                   "Error on GetRevisions",
+			expectedPostResult:     entities.Post{ID: "64a399cdb6a0487490ed730c"},
+			expectedRevisionsError: assert.AnError,
+			expectedStatusCode:     500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(test.expectedPostResult, test.expectedPostError)
+			repositoryMock.On("GetRevisions", "64a399cdb6a0487490ed730c", 1, 20).Return(revisions, test.expectedRevisionsError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/64a399cdb6a0487490ed730c/revisions", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetRevisions)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode == 200 {
+				var result []entities.PostRevision
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+				assert.Equal(t, revisions, result)
+			}
+		})
+	}
+}
+
+func TestCreateDraft(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name               string
+		input              string
+		expectedStatus     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on CreatePost, draft",
+			input:              `{"title": "Soon", "content": "hi @alice", "status": "draft"}`,
+			expectedStatus:     entities.PostStatusDraft,
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Success on CreatePost, scheduled",
+			input:              `{"title": "Soon", "content": "hi @alice", "status": "scheduled", "publishAt": "` + publishAt + `"}`,
+			expectedStatus:     entities.PostStatusScheduled,
+			expectedStatusCode: 201,
+		},
+		{
+			name:               "Error on CreatePost, scheduled without publishAt",
+			input:              `{"title": "Soon", "content": "hi", "status": "scheduled"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on CreatePost, scheduled in the past",
+			input:              `{"title": "Soon", "content": "hi", "status": "scheduled", "publishAt": "2020-01-01T00:00:00Z"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on CreatePost, publishAt on a draft",
+			input:              `{"title": "Soon", "content": "hi", "status": "draft", "publishAt": "` + publishAt + `"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on CreatePost, reply as draft",
+			input:              `{"content": "hi", "parentId": "64a399cdb6a0487490ed730c", "status": "draft"}`,
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("CreatePost", mock.AnythingOfType("entities.Post")).Return(entities.Post{
+				ID:         "64a399cdb6a0487490ed730c",
+				AuthorNick: "1",
+				Status:     test.expectedStatus,
+				Mentions:   []entities.Mention{{Nick: "alice"}},
+			}, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "alice").Return(entities.User{Nick: "alice"}, nil)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+
+			hub := stream.NewHub(10, 10)
+			follower, _ := hub.Subscribe("3", []string{"1"}, nil, 0)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, hub)
+
+			req := httptest.NewRequest("POST", "/posts", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.CreatePost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode != 201 {
+				repositoryMock.AssertNotCalled(t, "CreatePost", mock.Anything)
+				return
+			}
+
+			createdPost := repositoryMock.Calls[0].Arguments.Get(0).(entities.Post)
+			assert.Equal(t, test.expectedStatus, createdPost.Status)
+
+			// nothing is announced before the post is published
+			notificationsRepositoryMock.AssertNotCalled(t, "AddNotification", mock.Anything)
+			assert.Len(t, follower.Events, 0)
+		})
+	}
+}
+
+func TestGetDrafts(t *testing.T) {
+	drafts := []entities.Post{
+		{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1", Status: entities.PostStatusDraft},
+	}
+
+	tests := []struct {
+		name               string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetDrafts",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetDrafts",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetDrafts", "1", 1, 20).Return(drafts, test.expectedError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/drafts", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetDrafts)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode == 200 {
+				var result []entities.Post
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+				assert.Equal(t, drafts, result)
+			}
+		})
+	}
+}
+
+func TestUpdateDraft(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name               string
+		savedPost          entities.Post
+		input              string
+		expectedStatus     string
+		expectedCall       string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on UpdatePost, draft keeps its status",
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft},
+			input:              `{"title": "Soon", "content": "new content"}`,
+			expectedStatus:     entities.PostStatusDraft,
+			expectedCall:       "UpdateDraft",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Success on UpdatePost, scheduled keeps its time",
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusScheduled, PublishAt: &publishAt},
+			input:              `{"title": "Soon", "content": "new content"}`,
+			expectedStatus:     entities.PostStatusScheduled,
+			expectedCall:       "UpdateDraft",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Success on UpdatePost, draft outside the edit window",
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft, CreatedAt: time.Now().Add(-time.Hour)},
+			input:              `{"title": "Soon", "content": "new content"}`,
+			expectedStatus:     entities.PostStatusDraft,
+			expectedCall:       "UpdateDraft",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on UpdatePost, published post back to draft",
+			savedPost:          entities.Post{AuthorNick: "1", CreatedAt: time.Now()},
+			input:              `{"title": "Soon", "content": "new content", "status": "draft"}`,
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previousEditWindow := config.PostEditWindow
+			config.PostEditWindow = time.Minute
+			defer func() { config.PostEditWindow = previousEditWindow }()
+
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(test.savedPost, nil)
+			repositoryMock.On("UpdateDraft", "64a399cdb6a0487490ed730c", mock.AnythingOfType("entities.Post")).Return(nil)
+			repositoryMock.On("UpdatePost", "64a399cdb6a0487490ed730c", mock.AnythingOfType("entities.Post")).Return(nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("PUT", "/posts/64a399cdb6a0487490ed730c", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.UpdatePost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			repositoryMock.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything)
+			if test.expectedCall == "" {
+				repositoryMock.AssertNotCalled(t, "UpdateDraft", mock.Anything, mock.Anything)
+				return
+			}
+
+			updatedPost := repositoryMock.Calls[1].Arguments.Get(1).(entities.Post)
+			assert.Equal(t, test.expectedStatus, updatedPost.Status)
+			assert.Equal(t, test.savedPost.PublishAt, updatedPost.PublishAt)
+		})
+	}
+}
+
+func TestPublishPost(t *testing.T) {
+	publishedPost := entities.Post{
+		ID:         "64a399cdb6a0487490ed730c",
+		AuthorNick: "1",
+		Visibility: entities.VisibilityPublic,
+		Mentions:   []entities.Mention{{Nick: "alice"}},
+	}
+
+	tests := []struct {
+		name               string
+		validToken         string
+		savedPost          entities.Post
+		expectedPostError  error
+		expectedPublishErr error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on PublishPost",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on PublishPost, not the author",
+			validToken:         DiffToken,
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft},
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Success on PublishPost, with a poll",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft, Poll: newTestPoll(false, time.Now().Add(time.Hour))},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on PublishPost, poll closing too soon",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft, Poll: newTestPoll(false, time.Now().Add(2*time.Minute))},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on PublishPost, poll already closed",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusDraft, Poll: newTestPoll(false, time.Now().Add(-time.Hour))},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on PublishPost, already published",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{AuthorNick: "1"},
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Error on PublishPost, published by the scheduler meanwhile",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{AuthorNick: "1", Status: entities.PostStatusScheduled},
+			expectedPublishErr: assert.AnError,
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Error on PublishPost, post not found",
+			validToken:         ValidToken,
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(test.savedPost, test.expectedPostError)
+			repositoryMock.On("PublishPost", "64a399cdb6a0487490ed730c").Return(publishedPost, test.expectedPublishErr)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "alice").Return(entities.User{Nick: "alice"}, nil)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+			hub := stream.NewHub(10, 10)
+			follower, _ := hub.Subscribe("3", []string{"1"}, nil, 0)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, hub)
+
+			req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/publish", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.PublishPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode != 200 {
+				notificationsRepositoryMock.AssertNotCalled(t, "AddNotification", mock.Anything)
+				assert.Len(t, follower.Events, 0)
+				return
+			}
+
+			notificationsRepositoryMock.AssertNumberOfCalls(t, "AddNotification", 1)
+			assert.Len(t, follower.Events, 1)
+		})
+	}
+}
+
+func TestPublishPostReply(t *testing.T) {
+	reply := entities.Post{
+		ID:         "64a399cdb6a0487490ed730c",
+		AuthorNick: "1",
+		ParentID:   "64a399cdb6a0487490ed730a",
+		RootID:     "64a399cdb6a0487490ed730a",
+		Visibility: entities.VisibilityPublic,
+	}
+
+	repositoryMock := mocks.NewPostsRepositoryMock()
+	repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(entities.Post{AuthorNick: "1", ParentID: reply.ParentID, Status: entities.PostStatusDraft}, nil)
+	repositoryMock.On("PublishPost", "64a399cdb6a0487490ed730c").Return(reply, nil)
+	repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730a").Return(entities.Post{ID: "64a399cdb6a0487490ed730a", AuthorNick: "2"}, nil)
+
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "2").Return(entities.User{Nick: "2"}, nil)
+
+	notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+	notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+	postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/publish", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(postsController.PublishPost)
+	controller.ServeHTTP(rr, req)
+
+	// the author of the parent hears about the reply once it's published
+	assert.Equal(t, http.StatusOK, rr.Code)
+	notificationsRepositoryMock.AssertCalled(t, "AddNotification", entities.Notification{
+		Recipient: "2",
+		Type:      entities.NotificationReply,
+		Actor:     "1",
+		PostID:    "64a399cdb6a0487490ed730a",
+	})
+}
+
+func TestGetTrash(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour).UTC()
+	trash := []entities.Post{
+		{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1", DeletedAt: &deletedAt},
+	}
+
+	tests := []struct {
+		name               string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetTrash",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetTrash",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetTrash", "1", mock.AnythingOfType("time.Time"), 1, 20).Return(trash, test.expectedError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/trash", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetTrash)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode == 200 {
+				var result []entities.Post
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+				assert.Equal(t, trash, result)
+
+				// only what was deleted within the retention is listed
+				since := repositoryMock.Calls[0].Arguments.Get(1).(time.Time)
+				assert.WithinDuration(t, time.Now().Add(-config.TrashRetention), since, time.Minute)
+			}
+		})
+	}
+}
+
+func TestRestorePost(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		validToken         string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on RestorePost",
+			validToken:         ValidToken,
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on RestorePost, not on the trash",
+			validToken:         ValidToken,
+			expectedError:      assert.AnError,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on RestorePost, invalid token",
+			validToken:         ValidToken + "invalidate",
+			expectedStatusCode: 401,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("RestorePost", "64a399cdb6a0487490ed730c", "1", mock.AnythingOfType("time.Time")).Return(test.expectedError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/restore", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.RestorePost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestPinPost(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		validToken         string
+		savedPost          entities.Post
+		expectedPostError  error
+		expectedPinError   error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on PinPost",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1"},
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on PinPost, other's post",
+			validToken:         DiffToken,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1"},
+			expectedStatusCode: 403,
+		},
+		{
+			name:               "Error on PinPost, draft",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1", Status: entities.PostStatusDraft},
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on PinPost, repost",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1", RepostOf: "64a399cdb6a0487490ed730d"},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on PinPost, too many pinned posts",
+			validToken:         ValidToken,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "1"},
+			expectedPinError:   errors.New("It's not possible to pin more than 3 posts"),
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Error on PinPost, post not found",
+			validToken:         ValidToken,
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(test.savedPost, test.expectedPostError)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("PinPost", "1", "64a399cdb6a0487490ed730c", config.MaxPinnedPosts).Return(test.expectedPinError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/pin", nil)
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.PinPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestUnpinPost(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		expectedUnpinError error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on UnpinPost",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on UnpinPost, post not pinned",
+			expectedUnpinError: errors.New("This post isn't pinned"),
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("UnpinPost", "1", "64a399cdb6a0487490ed730c").Return(test.expectedUnpinError)
+
+			postsController := NewPostsController(mocks.NewPostsRepositoryMock(), mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("DELETE", "/posts/64a399cdb6a0487490ed730c/pin", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.UnpinPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func newTestPoll(multiple bool, expiresAt time.Time) *entities.Poll {
+	return &entities.Poll{
+		Options:    []entities.PollOption{{Text: "yes", Votes: 3}, {Text: "no", Votes: 1}, {Text: "maybe"}},
+		Multiple:   multiple,
+		ExpiresAt:  expiresAt,
+		VoterCount: 4,
+	}
+}
+
+func TestVotePoll(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		input              string
+		savedPost          entities.Post
+		expectedPostError  error
+		expectedOptions    []int
+		expectedVoteError  error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on VotePoll",
+			input:              `{"options": [1]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", Poll: newTestPoll(false, time.Now().Add(time.Hour))},
+			expectedOptions:    []int{1},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on VotePoll, multiple choice",
+			input:              `{"options": [2, 0, 2]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", Poll: newTestPoll(true, time.Now().Add(time.Hour))},
+			expectedOptions:    []int{0, 2},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on VotePoll, many options on a single choice poll",
+			input:              `{"options": [0, 1]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", Poll: newTestPoll(false, time.Now().Add(time.Hour))},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on VotePoll, unknown option",
+			input:              `{"options": [3]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", Poll: newTestPoll(false, time.Now().Add(time.Hour))},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on VotePoll, post without poll",
+			input:              `{"options": [0]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c"},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on VotePoll, poll closed",
+			input:              `{"options": [0]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", Poll: newTestPoll(false, time.Now().Add(-time.Hour))},
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Error on VotePoll, already voted on another option",
+			input:              `{"options": [0]}`,
+			savedPost:          entities.Post{ID: "64a399cdb6a0487490ed730c", Poll: newTestPoll(false, time.Now().Add(time.Hour))},
+			expectedOptions:    []int{0},
+			expectedVoteError:  errors.New("You already voted on this poll"),
+			expectedStatusCode: 409,
+		},
+		{
+			name:               "Error on VotePoll, post not found",
+			input:              `{"options": [0]}`,
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(test.savedPost, test.expectedPostError)
+			repositoryMock.On("Vote", "64a399cdb6a0487490ed730c", "1", test.expectedOptions).Return(test.expectedVoteError)
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(test.savedPost, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/vote", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.VotePoll)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				var post entities.Post
+				json.Unmarshal(rr.Body.Bytes(), &post)
+				assert.Equal(t, test.expectedOptions, post.Poll.OwnVotes)
+				assert.NotNil(t, post.Poll.Options[0].Results)
+			}
+		})
+	}
+}
+
+func TestGetPostPollResults(t *testing.T) {
+
+	tests := []struct {
+		name            string
+		poll            *entities.Poll
+		ownVotes        map[string][]int
+		expectedResults bool
+	}{
+		{
+			name:            "Results hidden before voting",
+			poll:            newTestPoll(false, time.Now().Add(time.Hour)),
+			ownVotes:        map[string][]int{},
+			expectedResults: false,
+		},
+		{
+			name:            "Results shown after voting",
+			poll:            newTestPoll(false, time.Now().Add(time.Hour)),
+			ownVotes:        map[string][]int{"64a399cdb6a0487490ed730c": {0}},
+			expectedResults: true,
+		},
+		{
+			name:            "Results shown once the poll is closed",
+			poll:            newTestPoll(false, time.Now().Add(-time.Hour)),
+			ownVotes:        map[string][]int{},
+			expectedResults: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			post := entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "2", Poll: test.poll}
+
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(post, nil)
+			repositoryMock.On("GetPollVotes", "1", []string{"64a399cdb6a0487490ed730c"}).Return(test.ownVotes, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUsersByNicks", []string{"1", "2"}).Return([]entities.User{{Nick: "1"}, {Nick: "2"}}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/64a399cdb6a0487490ed730c", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, test.expectedResults, strings.Contains(rr.Body.String(), `"votes":3`))
+		})
+	}
+}
+
+func TestReactToPost(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		input              string
+		expectedPostError  error
+		expectedReaction   string
+		expectedReactError error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on ReactToPost",
+			input:              `{"type": "🎉"}`,
+			expectedReaction:   "🎉",
+			expectedStatusCode: 204,
+		},
+		{
+			name:               "Error on ReactToPost, reaction not available",
+			input:              `{"type": "💩"}`,
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on ReactToPost, post not found",
+			input:              `{"type": "🎉"}`,
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "Error on ReactToPost",
+			input:              `{"type": "👀"}`,
+			expectedReaction:   "👀",
+			expectedReactError: assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			post := entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "2", Reactions: map[string]int{"🎉": 1}}
+
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(post, test.expectedPostError)
+			repositoryMock.On("React", "64a399cdb6a0487490ed730c", "1", test.expectedReaction).Return(test.expectedReactError)
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(post, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "2").Return(entities.User{Nick: "2"}, nil)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, notificationsRepositoryMock, stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/posts/64a399cdb6a0487490ed730c/reactions", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.ReactToPost)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestRemoveReaction(t *testing.T) {
+
+	tests := []struct {
+		name                 string
+		reaction             string
+		expectedUnreactError error
+		expectedStatusCode   int
+	}{
+		{
+			name:               "Success on RemoveReaction",
+			reaction:           "🚀",
+			expectedStatusCode: 204,
+		},
+		{
+			name:                 "Error on RemoveReaction, no reaction of this type",
+			reaction:             "🚀",
+			expectedUnreactError: errors.New("You didn't react with 🚀 to this post"),
+			expectedStatusCode:   404,
+		},
+		{
+			name:               "Error on RemoveReaction, reaction not available",
+			reaction:           "",
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("Unreact", "64a399cdb6a0487490ed730c", "1", test.reaction).Return(test.expectedUnreactError)
+			repositoryMock.On("GetPostWithId", "64a399cdb6a0487490ed730c").Return(entities.Post{ID: "64a399cdb6a0487490ed730c"}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("DELETE", "/posts/64a399cdb6a0487490ed730c/reactions?type="+url.QueryEscape(test.reaction), nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.RemoveReaction)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetReactions(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		reaction           string
+		expectedPostError  error
+		savedReactions     []entities.PostReaction
+		expectedResult     []entities.PostReaction
+		expectedStatusCode int
+	}{
+		{
+			name:     "Success on GetReactions",
+			reaction: "",
+			expectedResult: []entities.PostReaction{
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "2", Type: "👍"},
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "3", Type: "🤔"},
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on GetReactions, by type",
+			reaction:           "🤔",
+			expectedResult:     []entities.PostReaction{{PostID: "64a399cdb6a0487490ed730c", Nick: "3", Type: "🤔"}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:     "Success on GetReactions, hiding users blocked either way",
+			reaction: "",
+			savedReactions: []entities.PostReaction{
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "7", Type: "👍"},
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "3", Type: "🤔"},
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "4", Type: "👍"},
+			},
+			expectedResult:     []entities.PostReaction{{PostID: "64a399cdb6a0487490ed730c", Nick: "3", Type: "🤔"}},
+			expectedStatusCode: 200,
+		},
+		{
+			name:     "Success on GetReactions, hiding private users not followed",
+			reaction: "",
+			savedReactions: []entities.PostReaction{
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "3", Type: "🤔"},
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "5", Type: "👍"},
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "6", Type: "👍"},
+			},
+			expectedResult: []entities.PostReaction{
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "3", Type: "🤔"},
+				{PostID: "64a399cdb6a0487490ed730c", Nick: "6", Type: "👍"},
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetReactions, reaction not available",
+			reaction:           "thinking",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on GetReactions, post not found",
+			reaction:           "",
+			expectedPostError:  assert.AnError,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(entities.Post{ID: "64a399cdb6a0487490ed730c"}, test.expectedPostError)
+			savedReactions := test.savedReactions
+			if savedReactions == nil {
+				savedReactions = test.expectedResult
+			}
+			repositoryMock.On("GetReactions", "64a399cdb6a0487490ed730c", test.reaction, 1, 20).Return(savedReactions, nil)
+
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUsersByNicks", mock.Anything).Return([]entities.User{
+				{Nick: "1", Blocked: []string{"7"}},
+				{Nick: "2"},
+				{Nick: "3"},
+				{Nick: "4", Blocked: []string{"1"}},
+				{Nick: "5", Private: true},
+				{Nick: "6", Private: true, Followers: []string{"1"}},
+				{Nick: "7"},
+			}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/posts/64a399cdb6a0487490ed730c/reactions?type="+url.QueryEscape(test.reaction), nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetReactions)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if rr.Code == http.StatusOK {
+				var reactions []entities.PostReaction
+				json.Unmarshal(rr.Body.Bytes(), &reactions)
+				assert.Equal(t, test.expectedResult, reactions)
+			}
+		})
+	}
+}
+
+func TestGetPostOwnReactions(t *testing.T) {
+	post := entities.Post{ID: "64a399cdb6a0487490ed730c", AuthorNick: "2", Reactions: map[string]int{"👍": 2, "❤️": 1}}
+
+	repositoryMock := mocks.NewPostsRepositoryMock()
+	repositoryMock.On("GetVisiblePost", "64a399cdb6a0487490ed730c", "1").Return(post, nil)
+	repositoryMock.On("GetOwnReactions", "1", []string{"64a399cdb6a0487490ed730c"}).Return(map[string][]string{"64a399cdb6a0487490ed730c": {"👍"}}, nil)
+
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUsersByNicks", []string{"1", "2"}).Return([]entities.User{{Nick: "1"}, {Nick: "2"}}, nil)
+
+	postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), usersRepositoryMock, mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("GET", "/posts/64a399cdb6a0487490ed730c", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"postID": "64a399cdb6a0487490ed730c"})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(postsController.GetPost)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var result entities.Post
+	json.Unmarshal(rr.Body.Bytes(), &result)
+	assert.Equal(t, map[string]int{"👍": 2, "❤️": 1}, result.Reactions)
+	assert.Equal(t, []string{"👍"}, result.OwnReactions)
+}
+
+func TestRepliesAndThreadOwnReactions(t *testing.T) {
+	root := entities.Post{ID: "a", Title: "root"}
+	reply := entities.Post{ID: "b", ParentID: "a", RootID: "a", Content: "reply", Reactions: map[string]int{"👍": 1}}
+
+	tests := []struct {
+		name    string
+		url     string
+		handler func(*PostsController) http.HandlerFunc
+	}{
+		{
+			name:    "Own reactions on GetReplies",
+			url:     "/posts/a/replies",
+			handler: func(controller *PostsController) http.HandlerFunc { return controller.GetReplies },
+		},
+		{
+			name:    "Own reactions on GetThread",
+			url:     "/posts/a/thread",
+			handler: func(controller *PostsController) http.HandlerFunc { return controller.GetThread },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetReplies", "a", "1", 1, 20).Return([]entities.Post{reply}, nil)
+			repositoryMock.On("GetVisiblePost", "a", "1").Return(root, nil)
+			repositoryMock.On("GetConversation", "a", "1").Return([]entities.Post{root, reply}, nil)
+			repositoryMock.On("GetOwnReactions", "1", []string{"b"}).Return(map[string][]string{"b": {"👍"}}, nil)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", test.url, nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"postID": "a"})
+
+			rr := httptest.NewRecorder()
+
+			test.handler(postsController).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Body.String(), `"ownReactions":["👍"]`)
+		})
+	}
+}
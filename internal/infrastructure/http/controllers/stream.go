@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/application/stream"
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/config"
+	"api/internal/infrastructure/http/responses"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// streamWriteTimeout is how long a write can take before the connection is considered dead
+const streamWriteTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the stream is authenticated by the token and not by cookies, so any origin can connect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type StreamController struct {
+	userRepository repositories.UsersRepository
+	hub            *stream.Hub
+}
+
+func NewStreamController(userRepository repositories.UsersRepository, hub *stream.Hub) *StreamController {
+	return &StreamController{
+		userRepository,
+		hub,
+	}
+}
+
+// Stream pushes the events of the user over a WebSocket when the client asks for the upgrade, and over
+// Server-Sent Events otherwise
+func (controller *StreamController) Stream(w http.ResponseWriter, r *http.Request) {
+	// browsers can't set headers on WebSocket and EventSource connections, so the token can go on the query
+	if r.Header.Get("Authorization") == "" && r.URL.Query().Get("access_token") != "" {
+		r.Header.Set("Authorization", "Bearer "+r.URL.Query().Get("access_token"))
+	}
+
+	if err := auth.ValidateToken(r); err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	userNick, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// deleted accounts aren't found, the token is no longer valid for them
+	user, err := controller.userRepository.GetUserByNick(userNick)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		responses.Error(w, http.StatusUnauthorized, errors.New("This account doesn't exists anymore"))
+		return
+	}
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	if websocket.IsWebSocketUpgrade(r) {
+		controller.streamWebSocket(w, r, userNick, user.Following, user.Muted, lastID)
+		return
+	}
+
+	controller.streamSSE(w, r, userNick, user.Following, user.Muted, lastID)
+}
+
+func (controller *StreamController) streamSSE(w http.ResponseWriter, r *http.Request, userNick string, following []string, muted []string, lastID uint64) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		responses.Error(w, http.StatusInternalServerError, errors.New("It's not possible to stream on this connection"))
+		return
+	}
+
+	subscriber, missed := controller.hub.Subscribe(userNick, following, muted, lastID)
+	defer controller.hub.Unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range missed {
+		if err := writeSSE(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(config.StreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, open := <-subscriber.Events:
+			if !open {
+				return
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event stream.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func (controller *StreamController) streamWebSocket(w http.ResponseWriter, r *http.Request, userNick string, following []string, muted []string, lastID uint64) {
+	connection, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already answered the request
+		return
+	}
+	defer connection.Close()
+
+	subscriber, missed := controller.hub.Subscribe(userNick, following, muted, lastID)
+	defer controller.hub.Unsubscribe(subscriber)
+
+	// the client doesn't send anything, reading is only needed to handle the pongs and to know when it leaves
+	closed := make(chan struct{})
+	connection.SetReadDeadline(time.Now().Add(2 * config.StreamKeepAlive))
+	connection.SetPongHandler(func(string) error {
+		return connection.SetReadDeadline(time.Now().Add(2 * config.StreamKeepAlive))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := connection.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range missed {
+		if err := writeWebSocket(connection, event); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(config.StreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, open := <-subscriber.Events:
+			if !open {
+				connection.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, resume from the last event"),
+					time.Now().Add(streamWriteTimeout),
+				)
+				return
+			}
+			if err := writeWebSocket(connection, event); err != nil {
+				return
+			}
+
+		case <-keepAlive.C:
+			if err := connection.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+
+		case <-closed:
+			return
+		}
+	}
+}
+
+func writeWebSocket(connection *websocket.Conn, event stream.Event) error {
+	connection.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return connection.WriteJSON(event)
+}
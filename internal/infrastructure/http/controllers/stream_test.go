@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"api/internal/application/stream"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
+	"api/internal/domain/repositories/mocks"
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamUnauthorized(t *testing.T) {
+	controller := NewStreamController(mocks.NewUsersRepositoryMock(), stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(controller.Stream).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestStreamDeletedAccount(t *testing.T) {
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{}, repositories.ErrUserNotFound)
+	controller := NewStreamController(usersRepositoryMock, stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	rr := httptest.NewRecorder()
+
+	http.HandlerFunc(controller.Stream).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+// publishMissed publishes a few events for the user "1", following "2" and muting "3", and returns the id
+// of the event before them
+func publishMissed(hub *stream.Hub) uint64 {
+	probe, _ := hub.Subscribe("2", nil, nil, 0)
+	defer hub.Unsubscribe(probe)
+
+	hub.PublishFrom("2", stream.EventPost, entities.Post{Title: "Seen"})
+	seen := <-probe.Events
+
+	hub.PublishFrom("2", stream.EventPost, entities.Post{Title: "Missed"})
+	hub.PublishFrom("3", stream.EventPost, entities.Post{Title: "Muted"})
+	hub.PublishFrom("4", stream.EventPost, entities.Post{Title: "Not followed"})
+	hub.PublishTo("1", stream.EventNotification, entities.Notification{Type: entities.NotificationLike})
+
+	return seen.ID
+}
+
+func newStreamServer(hub *stream.Hub) *httptest.Server {
+	usersRepositoryMock := mocks.NewUsersRepositoryMock()
+	usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1", Following: []string{"2", "3"}, Muted: []string{"3"}}, nil)
+
+	controller := NewStreamController(usersRepositoryMock, hub)
+	return httptest.NewServer(http.HandlerFunc(controller.Stream))
+}
+
+func TestStreamSSE(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	server := newStreamServer(hub)
+	defer server.Close()
+
+	lastEventID := publishMissed(hub)
+
+	req, _ := http.NewRequest("GET", server.URL+"?access_token="+ValidToken, nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprint(lastEventID))
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, stream.EventPost, readSSEEvent(t, reader))
+	assert.Equal(t, stream.EventNotification, readSSEEvent(t, reader))
+
+	hub.PublishFrom("2", stream.EventLikes, stream.LikesChange{PostID: "1", Likes: 3})
+	assert.Equal(t, stream.EventLikes, readSSEEvent(t, reader))
+
+	// after unfollowing the open connection stops getting the events of the author
+	hub.SetFollowing("1", "2", false)
+	hub.PublishFrom("2", stream.EventPost, entities.Post{Title: "Unfollowed"})
+	hub.PublishTo("1", stream.EventNotification, entities.Notification{Type: entities.NotificationLike})
+	assert.Equal(t, stream.EventNotification, readSSEEvent(t, reader))
+}
+
+func TestStreamSSEReset(t *testing.T) {
+	hub := stream.NewHub(10, 2)
+	server := newStreamServer(hub)
+	defer server.Close()
+
+	// only the last two events are kept, so the missed post is gone
+	lastEventID := publishMissed(hub)
+
+	req, _ := http.NewRequest("GET", server.URL+"?access_token="+ValidToken+"&lastEventId="+fmt.Sprint(lastEventID), nil)
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, stream.EventReset, readSSEEvent(t, bufio.NewReader(resp.Body)))
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	server := newStreamServer(hub)
+	defer server.Close()
+
+	lastEventID := publishMissed(hub)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+ValidToken)
+	header.Set("Last-Event-ID", fmt.Sprint(lastEventID))
+
+	dialer := websocket.Dialer{HandshakeTimeout: time.Second}
+	connection, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer connection.Close()
+
+	connection.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var event stream.Event
+	for _, expected := range []string{stream.EventPost, stream.EventNotification} {
+		assert.Nil(t, connection.ReadJSON(&event))
+		assert.Equal(t, expected, event.Type)
+	}
+
+	hub.PublishFrom("1", stream.EventPost, entities.Post{Title: "Own post"})
+	assert.Nil(t, connection.ReadJSON(&event))
+	assert.Equal(t, stream.EventPost, event.Type)
+	assert.Greater(t, event.ID, lastEventID)
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) string {
+	eventType := ""
+	for {
+		line, err := reader.ReadString('\n')
+		if !assert.Nil(t, err) {
+			return ""
+		}
+
+		line = strings.TrimRight(line, "\n")
+		if line == "" && eventType != "" {
+			return eventType
+		}
+		if strings.HasPrefix(line, "event: ") {
+			eventType = strings.TrimPrefix(line, "event: ")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"api/internal/application/auth"
+	"api/internal/domain/entities"
+	"api/internal/infrastructure/http/responses"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// tagSearchLimit is how many tags are suggested on the autocomplete
+const tagSearchLimit = 10
+
+func (controller *PostsController) GetTagPosts(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tag := entities.NormalizeHashtag(params["tag"])
+
+	if tag == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("You must insert the tag"))
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	viewerNick, _ := auth.GetUserNick(r)
+
+	posts, err := controller.PostRepository.GetPostsByTag(tag, viewerNick, page, limit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	posts, err = controller.resolveReferences(viewerNick, posts)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, posts)
+}
+
+func (controller *PostsController) SearchTags(w http.ResponseWriter, r *http.Request) {
+	prefix := entities.NormalizeHashtag(r.URL.Query().Get("q"))
+
+	if prefix == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("The search query is required"))
+		return
+	}
+
+	tags, err := controller.PostRepository.SearchTags(prefix, tagSearchLimit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, tags)
+}
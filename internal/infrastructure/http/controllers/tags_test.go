@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"api/internal/application/stream"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTagPosts(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		tag                string
+		expectedTag        string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetTagPosts",
+			tag:                "GoLang",
+			expectedTag:        "golang",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetTagPosts, empty tag",
+			tag:                "#",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on GetTagPosts",
+			tag:                "golang",
+			expectedTag:        "golang",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("GetPostsByTag", test.expectedTag, "1", 1, 20).Return([]entities.Post{{ID: "1", Tags: []string{"golang"}}}, test.expectedError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/tags/"+test.tag+"/posts", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"tag": test.tag})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.GetTagPosts)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestSearchTags(t *testing.T) {
+
+	tests := []struct {
+		name               string
+		query              string
+		expectedPrefix     string
+		expectedError      error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on SearchTags",
+			query:              "?q=%23Go",
+			expectedPrefix:     "go",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on SearchTags, empty query",
+			query:              "",
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on SearchTags",
+			query:              "?q=go",
+			expectedPrefix:     "go",
+			expectedError:      assert.AnError,
+			expectedStatusCode: 500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewPostsRepositoryMock()
+			repositoryMock.On("SearchTags", test.expectedPrefix, 10).Return([]entities.Hashtag{{Tag: "golang", Count: 3}}, test.expectedError)
+
+			postsController := NewPostsController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewUsersRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/tags/search"+test.query, nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(postsController.SearchTags)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
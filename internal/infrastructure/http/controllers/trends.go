@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"api/internal/application/trends"
+	"api/internal/domain/entities"
+	"api/internal/infrastructure/http/responses"
+	"errors"
+	"net/http"
+)
+
+const defaultTrendsWindow = "24h"
+
+type TrendsController struct {
+	cache *trends.Cache
+}
+
+func NewTrendsController(cache *trends.Cache) *TrendsController {
+	return &TrendsController{
+		cache,
+	}
+}
+
+func (controller *TrendsController) GetTrends(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = defaultTrendsWindow
+	}
+
+	if _, found := trends.Windows[window]; !found {
+		responses.Error(w, http.StatusBadRequest, errors.New("The window must be 1h, 24h or 7d"))
+		return
+	}
+
+	// the trends are computed in the background, until the first refresh there's nothing to show
+	trending, found := controller.cache.Get(window)
+	if !found {
+		trending = []entities.Trend{}
+	}
+
+	responses.JSON(w, http.StatusOK, trending)
+}
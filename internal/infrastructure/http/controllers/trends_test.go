@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"api/internal/application/trends"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTrends(t *testing.T) {
+	now := time.Now()
+	stats := []entities.TagStats{
+		// a lot of activity every day, it doesn't grow over the day before
+		{Tag: "golang", Bucket: entities.TagBucket(now.Add(-30 * time.Hour)), Posts: 20, Likes: 40},
+		{Tag: "golang", Bucket: entities.TagBucket(now.Add(-20 * time.Hour)), Posts: 20, Likes: 40},
+		// a smaller, recent burst of a tag nobody used before
+		{Tag: "rust", Bucket: entities.TagBucket(now.Add(-10 * time.Minute)), Posts: 8, Likes: 10},
+		// only one post, not enough to trend
+		{Tag: "zig", Bucket: entities.TagBucket(now), Posts: 1, Likes: 100},
+	}
+
+	trendsRepositoryMock := mocks.NewTrendsRepositoryMock()
+	trendsRepositoryMock.On("GetTagStats", mock.AnythingOfType("time.Time")).Return(stats, nil)
+
+	cache := trends.NewCache()
+	assert.NoError(t, trends.Refresh(trendsRepositoryMock, cache, now))
+
+	tests := []struct {
+		name               string
+		query              string
+		expectedTags       []string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on GetTrends, default window",
+			query:              "",
+			expectedTags:       []string{"rust", "golang"},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on GetTrends, 7 days window",
+			query:              "?window=7d",
+			expectedTags:       []string{"golang", "rust"},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Success on GetTrends, 1 hour window",
+			query:              "?window=1h",
+			expectedTags:       []string{"rust"},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on GetTrends, invalid window",
+			query:              "?window=2d",
+			expectedStatusCode: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trendsController := NewTrendsController(cache)
+
+			req, _ := http.NewRequest("GET", "/trends"+test.query, nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(trendsController.GetTrends)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedTags != nil {
+				var trending []entities.Trend
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &trending))
+
+				tags := []string{}
+				for _, trend := range trending {
+					tags = append(tags, trend.Tag)
+				}
+				assert.Equal(t, test.expectedTags, tags)
+			}
+		})
+	}
+}
@@ -2,26 +2,55 @@ package controllers
 
 import (
 	"api/internal/application/auth"
+	"api/internal/application/notifications"
+	"api/internal/application/search"
+	"api/internal/application/stream"
+	"api/internal/application/suggestions"
 	"api/internal/domain/entities"
 	"api/internal/domain/repositories"
 	"api/internal/domain/security"
+	"api/internal/infrastructure/config"
 	"api/internal/infrastructure/http/responses"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	// searchCandidatesLimit is how many users are fetched to be ranked on a search
+	searchCandidatesLimit = 500
+	// suggestionCandidatesLimit is how many friends of friends are fetched to be ranked as suggestions
+	suggestionCandidatesLimit = 200
+	// maxRelationshipsBatch is how many nicks can be checked on a single relationships request
+	maxRelationshipsBatch = 100
+)
+
+// maxSuggestions is how many ranked suggestions are kept on the cache for each user
+const maxSuggestions = 50
+
 type UsersController struct {
-	userRepository repositories.UsersRepository
+	userRepository   repositories.UsersRepository
+	mediaRepository  repositories.MediaRepository
+	suggestionsCache *suggestions.Cache
+	notifier         *notifications.Notifier
+	hub              *stream.Hub
 }
 
-func NewUsersController(userRepository repositories.UsersRepository) *UsersController {
+func NewUsersController(userRepository repositories.UsersRepository, mediaRepository repositories.MediaRepository, notificationRepository repositories.NotificationsRepository, hub *stream.Hub) *UsersController {
 	return &UsersController{
 		userRepository,
+		mediaRepository,
+		suggestions.NewCache(config.SuggestionsRefreshInterval),
+		notifications.NewNotifier(notificationRepository, userRepository, hub),
+		hub,
 	}
 }
 
@@ -68,7 +97,14 @@ func (controller *UsersController) GetUser(w http.ResponseWriter, r *http.Reques
 
 	user, err := controller.userRepository.GetUserByNick(nick)
 	if err != nil {
+		// the user may have changed the nick recently
+		if currentNick, redirectErr := controller.userRepository.GetNickRedirect(nick); redirectErr == nil {
+			http.Redirect(w, r, "/users/"+url.PathEscape(currentNick), http.StatusTemporaryRedirect)
+			return
+		}
+
 		responses.Error(w, http.StatusInternalServerError, err)
+		return
 	}
 
 	responses.JSON(w, http.StatusOK, user)
@@ -97,18 +133,42 @@ func (controller *UsersController) UpdateUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	var user entities.User
-	if err = json.Unmarshal(reqbody, &user); err != nil {
+	// partial update, unknown fields are rejected so typos aren't silently ignored
+	var update entities.UserUpdate
+	decoder := json.NewDecoder(bytes.NewReader(reqbody))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&update); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// sending the current nick back isn't a nick change
+	if update.Nick != nil {
+		if entities.NormalizeNick(*update.Nick) != nick {
+			responses.Error(w, http.StatusBadRequest, errors.New("The nick must be changed through the nick change endpoint"))
+			return
+		}
+		update.Nick = nil
+	}
+
+	if err = update.Prepare(); err != nil {
 		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = user.Prepare("edicao"); err != nil {
+	mediaIDs := []string{}
+	for _, mediaID := range []*string{update.AvatarID, update.BannerID} {
+		if mediaID != nil {
+			mediaIDs = append(mediaIDs, *mediaID)
+		}
+	}
+
+	if err = checkMediaOwnership(controller.mediaRepository, nick, mediaIDs...); err != nil {
 		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = controller.userRepository.UpdateUser(nick, user); err != nil {
+	if err = controller.userRepository.UpdateUser(nick, update); err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -139,6 +199,51 @@ func (controller *UsersController) DeleteUser(w http.ResponseWriter, r *http.Req
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
+// RestoreUser brings back a deleted account while the trash retention didn't pass, authenticating it the way
+// the login does, as the account can't log in while deleted
+func (controller *UsersController) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var user entities.User
+	if err = json.Unmarshal(reqbody, &user); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userSavedOnDB, err := controller.userRepository.SearchByEmail(user.Email)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = security.VerifyPassword(userSavedOnDB.Password, user.Password); err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userSavedOnDB.DeletedAt == nil {
+		responses.Error(w, http.StatusBadRequest, errors.New("This account isn't deleted"))
+		return
+	}
+
+	if err = controller.userRepository.RestoreUser(userSavedOnDB.Nick, time.Now().Add(-config.TrashRetention)); err != nil {
+		responses.Error(w, http.StatusNotFound, err)
+		return
+	}
+
+	token, err := auth.CreateTokenWithNick(userSavedOnDB.Nick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Write([]byte(token))
+}
+
 func (controller *UsersController) FollowUser(w http.ResponseWriter, r *http.Request) {
 	followerID, err := auth.GetUserNick(r)
 	if err != nil {
@@ -154,10 +259,42 @@ func (controller *UsersController) FollowUser(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	blocked, err := controller.userRepository.IsBlocked(followerID, followedID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if blocked {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't follow this user"))
+		return
+	}
+
+	followed, err := controller.userRepository.GetUserByNick(followedID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// private accounts have to accept the follower first
+	if followed.Private {
+		if err = controller.userRepository.RequestFollow(followerID, followedID); err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		controller.suggestionsCache.Invalidate(followerID)
+		controller.notifier.Notify(followedID, entities.NotificationFollowRequest, followerID, "")
+
+		responses.JSON(w, http.StatusAccepted, nil)
+		return
+	}
+
 	if err = controller.userRepository.Follow(followerID, followedID); err != nil {
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	controller.suggestionsCache.Invalidate(followerID)
+	controller.hub.SetFollowing(followerID, followedID, true)
+	controller.notifier.Notify(followedID, entities.NotificationFollow, followerID, "")
 
 	responses.JSON(w, http.StatusNoContent, nil)
 }
@@ -181,6 +318,8 @@ func (controller *UsersController) UnfollowUser(w http.ResponseWriter, r *http.R
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	controller.suggestionsCache.Invalidate(unfollowerID)
+	controller.hub.SetFollowing(unfollowerID, unfollowedID, false)
 
 	responses.JSON(w, http.StatusNoContent, nil)
 }
@@ -272,3 +411,471 @@ func (controller *UsersController) UpdatePassword(w http.ResponseWriter, r *http
 
 	responses.JSON(w, http.StatusNoContent, nil)
 }
+
+func (controller *UsersController) ChangeNick(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userNick := params["userID"]
+
+	if userNickOnToken != userNick {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible to change the nick of another user"))
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var body struct {
+		Nick string `json:"nick"`
+	}
+	if err = json.Unmarshal(reqbody, &body); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	newNick := entities.NormalizeNick(body.Nick)
+	if err = entities.ValidateNick(newNick); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if newNick == userNick {
+		responses.Error(w, http.StatusBadRequest, errors.New("The new nick is the same as the current one"))
+		return
+	}
+
+	if err = controller.userRepository.ChangeNick(userNick, newNick); err != nil {
+		if errors.Is(err, repositories.ErrNickTaken) || errors.Is(err, repositories.ErrNickReserved) || errors.Is(err, repositories.ErrNickChangePending) {
+			responses.Error(w, http.StatusConflict, err)
+			return
+		}
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the nick is the token subject, so the old token doesn't identify the user anymore
+	token, err := auth.CreateTokenWithNick(newNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, struct {
+		Nick  string `json:"nick"`
+		Token string `json:"token"`
+	}{
+		Nick:  newNick,
+		Token: token,
+	})
+}
+
+func (controller *UsersController) GetNickHistory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userNick := params["userID"]
+
+	history, err := controller.userRepository.GetNickHistory(userNick)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, history)
+}
+
+func (controller *UsersController) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("The q parameter is required"))
+		return
+	}
+
+	candidates, err := controller.userRepository.SearchUsers(viewerID, query, searchCandidatesLimit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+	responses.JSON(w, http.StatusOK, paginate(search.RankUsers(query, candidates), page, limit))
+}
+
+func (controller *UsersController) BlockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	blockedID := params["userID"]
+
+	if blockerID == blockedID {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't block yourself"))
+		return
+	}
+
+	if err = controller.userRepository.Block(blockerID, blockedID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	controller.suggestionsCache.Invalidate(blockerID)
+	// the block breaks the follow on both directions
+	controller.hub.SetFollowing(blockerID, blockedID, false)
+	controller.hub.SetFollowing(blockedID, blockerID, false)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *UsersController) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	unblockerID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	unblockedID := params["userID"]
+
+	if err = controller.userRepository.Unblock(unblockerID, unblockedID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	controller.suggestionsCache.Invalidate(unblockerID)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *UsersController) GetBlocked(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't see the users blocked by another user"))
+		return
+	}
+
+	blocked, err := controller.userRepository.GetBlocked(userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, blocked)
+}
+
+func (controller *UsersController) UpdatePrivacy(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("It's not possible update another user"))
+		return
+	}
+
+	reqbody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var settings entities.PrivacySettings
+	if err = json.Unmarshal(reqbody, &settings); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = controller.userRepository.UpdatePrivacy(userID, settings); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *UsersController) GetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't see the follow requests of another user"))
+		return
+	}
+
+	requests, err := controller.userRepository.GetFollowRequests(userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, requests)
+}
+
+func (controller *UsersController) AcceptFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+	requesterID := params["requesterID"]
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't accept follow requests of another user"))
+		return
+	}
+
+	if err = controller.userRepository.AcceptFollowRequest(userID, requesterID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	controller.hub.SetFollowing(requesterID, userID, true)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RejectFollowRequest is used by the requested user to reject and by the requester to cancel
+func (controller *UsersController) RejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+	requesterID := params["requesterID"]
+
+	if userID != userNickOnToken && requesterID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't remove follow requests of another user"))
+		return
+	}
+
+	if err = controller.userRepository.RemoveFollowRequest(userID, requesterID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *UsersController) MuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	mutedID := params["userID"]
+
+	if muterID == mutedID {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't mute yourself"))
+		return
+	}
+
+	if err = controller.userRepository.Mute(muterID, mutedID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	controller.suggestionsCache.Invalidate(muterID)
+	controller.hub.SetMuted(muterID, mutedID, true)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *UsersController) UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	unmuterID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	unmutedID := params["userID"]
+
+	if err = controller.userRepository.Unmute(unmuterID, unmutedID); err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	controller.suggestionsCache.Invalidate(unmuterID)
+	controller.hub.SetMuted(unmuterID, unmutedID, false)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+func (controller *UsersController) GetMuted(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't see the users muted by another user"))
+		return
+	}
+
+	muted, err := controller.userRepository.GetMuted(userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, muted)
+}
+
+func (controller *UsersController) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	userNickOnToken, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	if userID != userNickOnToken {
+		responses.Error(w, http.StatusForbidden, errors.New("You can't see the suggestions of another user"))
+		return
+	}
+
+	page, limit := getPagination(r)
+
+	if cached, found := controller.suggestionsCache.Get(userID); found {
+		responses.JSON(w, http.StatusOK, paginate(cached, page, limit))
+		return
+	}
+
+	user, err := controller.userRepository.GetUserByNick(userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	candidates, err := controller.userRepository.GetSuggestionCandidates(userID, suggestionCandidatesLimit)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ranked := suggestions.Rank(user.TechStack, candidates, time.Now())
+	if len(ranked) > maxSuggestions {
+		ranked = ranked[:maxSuggestions]
+	}
+	controller.suggestionsCache.Set(userID, ranked)
+
+	responses.JSON(w, http.StatusOK, paginate(ranked, page, limit))
+}
+
+func (controller *UsersController) GetRelationship(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	relationships, err := controller.userRepository.GetRelationships(viewerID, []string{userID})
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(relationships) == 0 {
+		responses.Error(w, http.StatusNotFound, errors.New("This user doesn't exists"))
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, relationships[0])
+}
+
+func (controller *UsersController) GetRelationships(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := auth.GetUserNick(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	nicks := []string{}
+	for _, nick := range strings.Split(r.URL.Query().Get("nicks"), ",") {
+		if nick = strings.TrimSpace(nick); nick != "" {
+			nicks = append(nicks, nick)
+		}
+	}
+
+	if len(nicks) == 0 {
+		responses.Error(w, http.StatusBadRequest, errors.New("The nicks parameter is required"))
+		return
+	}
+
+	if len(nicks) > maxRelationshipsBatch {
+		responses.Error(w, http.StatusBadRequest, errors.New("It's not possible to check more than 100 users at once"))
+		return
+	}
+
+	relationships, err := controller.userRepository.GetRelationships(viewerID, nicks)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, relationships)
+}
+
+func (controller *UsersController) GetMutuals(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	userID := params["userID"]
+
+	if userID == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("You must insert the user ID"))
+		return
+	}
+
+	mutuals, err := controller.userRepository.GetMutuals(userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	page, limit := getPagination(r)
+	responses.JSON(w, http.StatusOK, paginate(mutuals, page, limit))
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"api/internal/application/stream"
 	"api/internal/domain/entities"
+	"api/internal/domain/repositories"
 	"api/internal/domain/repositories/mocks"
+	"api/internal/domain/security"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -11,6 +14,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -60,6 +64,30 @@ func TestCreateUser(t *testing.T) {
 			expectedErrorMessage: "{\"error\":\"unexpected end of JSON input\"}",
 			expectedError:        assert.AnError,
 		},
+		{
+			name:                 "Error on CreateUser, reserved nick",
+			input:                bytes.NewBufferString(`{"name":"test", "nick":"Admin", "email":"test@gmail.com", "password":"test"}`),
+			expectedStatusCode:   http.StatusBadRequest,
+			responseIsAnError:    true,
+			expectedErrorMessage: "{\"error\":\"This nick is reserved\"}",
+			expectedError:        assert.AnError,
+		},
+		{
+			name:                 "Error on CreateUser, nick of a route",
+			input:                bytes.NewBufferString(`{"name":"test", "nick":"drafts", "email":"test@gmail.com", "password":"test"}`),
+			expectedStatusCode:   http.StatusBadRequest,
+			responseIsAnError:    true,
+			expectedErrorMessage: "{\"error\":\"This nick is reserved\"}",
+			expectedError:        assert.AnError,
+		},
+		{
+			name:                 "Error on CreateUser, confusable characters on nick",
+			input:                bytes.NewBufferString(`{"name":"test", "nick":"pаypal", "email":"test@gmail.com", "password":"test"}`),
+			expectedStatusCode:   http.StatusBadRequest,
+			responseIsAnError:    true,
+			expectedErrorMessage: "{\"error\":\"The nick mixes characters that look alike from different alphabets\"}",
+			expectedError:        assert.AnError,
+		},
 		{
 			name:                 "Error on CreateUser, invalid user data",
 			input:                bytes.NewBuffer(invalidUserSerialized),
@@ -75,7 +103,7 @@ func TestCreateUser(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("Create", mock.AnythingOfType("entities.User")).Return(test.expectedCreateUserResult, test.expectedError)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req := httptest.NewRequest("POST", "/users", test.input)
 			rr := httptest.NewRecorder()
@@ -120,7 +148,7 @@ func TestGetAllUsers(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("GetAllUsers").Return(test.expectedGetAllUsersReturn, test.expectedGetAllUsersError)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/users/", nil)
 
@@ -147,6 +175,8 @@ func TestGetUser(t *testing.T) {
 		input                 string
 		expectedGetUserReturn entities.User
 		expectedGetUserError  error
+		expectedRedirectNick  string
+		expectedRedirectError error
 	}{
 		{
 			name:                  "Success on GetUser",
@@ -163,6 +193,17 @@ func TestGetUser(t *testing.T) {
 			input:                 "1",
 			expectedGetUserReturn: entities.User{},
 			expectedGetUserError:  assert.AnError,
+			expectedRedirectError: assert.AnError,
+		},
+		{
+			name:                  "Redirect on GetUser, nick recently changed",
+			requestID:             "1",
+			expectedStatusCode:    307,
+			input:                 "1",
+			expectedGetUserReturn: entities.User{},
+			expectedGetUserError:  assert.AnError,
+			expectedRedirectNick:  "newnick",
+			expectedRedirectError: nil,
 		},
 	}
 
@@ -170,8 +211,9 @@ func TestGetUser(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("GetUserByNick", test.input).Return(test.expectedGetUserReturn, test.expectedGetUserError)
+			repositoryMock.On("GetNickRedirect", test.input).Return(test.expectedRedirectNick, test.expectedRedirectError)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/users/", nil)
 			params := map[string]string{
@@ -202,13 +244,22 @@ func TestUpdateUser(t *testing.T) {
 	}{
 		{
 			name:                  "Success on UpdateUser",
-			input:                 `{"name":"updated", "nick":"testupdated", "email":"user1@email.com"}`,
+			input:                 `{"name":"updated", "nick":"1", "email":"user1@email.com"}`,
 			urlId:                 "1",
 			validToken:            ValidToken,
 			userId:                "1",
 			expectedStatusCode:    204,
 			expectedUpdatedResult: nil,
 		},
+		{
+			name:                  "Error on UpdateUser, nick changed",
+			input:                 `{"name":"updated", "nick":"testupdated", "email":"user1@email.com"}`,
+			urlId:                 "1",
+			validToken:            ValidToken,
+			userId:                "1",
+			expectedStatusCode:    400,
+			expectedUpdatedResult: nil,
+		},
 		{
 			name:                  "Error on UpdateUser, unexistent url ID",
 			input:                 `{"username":"updated", "nick":"testupdated", "email":"user1@email.com"}`,
@@ -263,6 +314,42 @@ func TestUpdateUser(t *testing.T) {
 			expectedStatusCode:    400,
 			expectedUpdatedResult: assert.AnError,
 		},
+		{
+			name:                  "Success on UpdateUser, partial update",
+			input:                 `{"bio":"Gopher", "techStack":["Go", "MongoDB"]}`,
+			urlId:                 "1",
+			validToken:            ValidToken,
+			userId:                "1",
+			expectedStatusCode:    204,
+			expectedUpdatedResult: nil,
+		},
+		{
+			name:                  "Success on UpdateUser, clearing fields",
+			input:                 `{"bio":"", "website":"", "techStack":[]}`,
+			urlId:                 "1",
+			validToken:            ValidToken,
+			userId:                "1",
+			expectedStatusCode:    204,
+			expectedUpdatedResult: nil,
+		},
+		{
+			name:                  "Error on UpdateUser, empty name",
+			input:                 `{"name":" "}`,
+			urlId:                 "1",
+			validToken:            ValidToken,
+			userId:                "1",
+			expectedStatusCode:    400,
+			expectedUpdatedResult: nil,
+		},
+		{
+			name:                  "Error on UpdateUser, invalid website",
+			input:                 `{"website":"not a url"}`,
+			urlId:                 "1",
+			validToken:            ValidToken,
+			userId:                "1",
+			expectedStatusCode:    400,
+			expectedUpdatedResult: nil,
+		},
 		{
 			name:                  "Error on call UpdateUser",
 			input:                 `{"invalidField":"updated", "nick":"testupdated", "email":"user1@email.com"}`,
@@ -277,9 +364,9 @@ func TestUpdateUser(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
-			repositoryMock.On("UpdateUser", test.userId, mock.AnythingOfType("entities.User")).Return(test.expectedUpdatedResult)
+			repositoryMock.On("UpdateUser", test.userId, mock.AnythingOfType("entities.UserUpdate")).Return(test.expectedUpdatedResult)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("PUT", "/users/", strings.NewReader(test.input))
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -299,6 +386,31 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestUpdateUserClearsFields(t *testing.T) {
+	repositoryMock := mocks.NewUsersRepositoryMock()
+	repositoryMock.On("UpdateUser", "1", mock.AnythingOfType("entities.UserUpdate")).Return(nil)
+
+	usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+	req, _ := http.NewRequest("PUT", "/users/1", strings.NewReader(`{"bio":"", "pronouns":" they/them "}`))
+	req.Header.Add("Authorization", "Bearer "+ValidToken)
+	req = mux.SetURLVars(req, map[string]string{"userID": "1"})
+
+	rr := httptest.NewRecorder()
+
+	controller := http.HandlerFunc(usersController.UpdateUser)
+	controller.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	update := repositoryMock.Calls[0].Arguments.Get(1).(entities.UserUpdate)
+	assert.Equal(t, "", *update.Bio)
+	assert.Equal(t, "they/them", *update.Pronouns)
+	assert.Nil(t, update.Name)
+	assert.Nil(t, update.Location)
+	assert.Nil(t, update.Website)
+}
+
 func TestDeleteUser(t *testing.T) {
 
 	tests := []struct {
@@ -350,7 +462,7 @@ func TestDeleteUser(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("DeleteUser", mock.AnythingOfType("string")).Return(test.expectedDeleteUserResult)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("DELETE", "/users/", nil)
 			parameters := map[string]string{
@@ -378,13 +490,43 @@ func TestFollowUser(t *testing.T) {
 		followedId           string
 		validToken           string
 		expectedFollowResult error
+		isBlocked            bool
+		isPrivate            bool
+		disabled             []string
+		expectedNotification string
 	}{
+		{
+			name:                 "Success on FollowUser, private account",
+			expectedStatusCode:   202,
+			followedId:           "test",
+			validToken:           ValidToken,
+			expectedFollowResult: nil,
+			isPrivate:            true,
+			expectedNotification: entities.NotificationFollowRequest,
+		},
+		{
+			name:                 "Error on FollowUser, blocked",
+			expectedStatusCode:   403,
+			followedId:           "test",
+			validToken:           ValidToken,
+			expectedFollowResult: nil,
+			isBlocked:            true,
+		},
 		{
 			name:                 "Succcess on FollowUser",
 			expectedStatusCode:   204,
 			followedId:           "test",
 			validToken:           ValidToken,
 			expectedFollowResult: nil,
+			expectedNotification: entities.NotificationFollow,
+		},
+		{
+			name:                 "Succcess on FollowUser, follow notifications turned off",
+			expectedStatusCode:   204,
+			followedId:           "test",
+			validToken:           ValidToken,
+			expectedFollowResult: nil,
+			disabled:             []string{entities.NotificationFollow},
 		},
 		{
 			name:                 "Error on FollowUser",
@@ -420,8 +562,14 @@ func TestFollowUser(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("Follow", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(test.expectedFollowResult)
+			repositoryMock.On("IsBlocked", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(test.isBlocked, nil)
+			repositoryMock.On("GetUserByNick", test.followedId).Return(entities.User{Nick: test.followedId, Private: test.isPrivate, DisabledNotifications: test.disabled}, nil)
+			repositoryMock.On("RequestFollow", "1", test.followedId).Return(test.expectedFollowResult)
+
+			notificationsRepositoryMock := mocks.NewNotificationsRepositoryMock()
+			notificationsRepositoryMock.On("AddNotification", mock.AnythingOfType("entities.Notification")).Return(nil)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), notificationsRepositoryMock, stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("POST", "/users/test/follow", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -436,7 +584,15 @@ func TestFollowUser(t *testing.T) {
 			controller.ServeHTTP(rr, req)
 
 			assert.Equal(t, test.expectedStatusCode, rr.Code)
-
+			if test.expectedNotification != "" {
+				notificationsRepositoryMock.AssertCalled(t, "AddNotification", entities.Notification{
+					Recipient: test.followedId,
+					Type:      test.expectedNotification,
+					Actor:     "1",
+				})
+			} else {
+				notificationsRepositoryMock.AssertNotCalled(t, "AddNotification", mock.Anything)
+			}
 		})
 	}
 }
@@ -491,7 +647,7 @@ func TestUnfollowUser(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("Unfollow", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(test.expectedUnfollowResult)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("POST", "/users/test/unfollow", nil)
 			req.Header.Add("Authorization", "Bearer "+test.validToken)
@@ -546,7 +702,7 @@ func TestGetFollowers(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("GetFollowers", mock.AnythingOfType("string")).Return(test.expectedGetFollowersResult, test.expectedGetFollowersError)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/users/test/followers", nil)
 			parameters := map[string]string{
@@ -600,7 +756,7 @@ func TestGetFollowing(t *testing.T) {
 			repositoryMock := mocks.NewUsersRepositoryMock()
 			repositoryMock.On("GetFollowing", mock.AnythingOfType("string")).Return(test.expectedGetFollowingResult, test.expectedGetFollowingError)
 
-			usersController := NewUsersController(repositoryMock)
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 			req, _ := http.NewRequest("GET", "/users/test/following", nil)
 			parameters := map[string]string{
@@ -657,12 +813,12 @@ func TestGetFollowing(t *testing.T) {
 // 			repositoryMock.On("GetPassword", mock.AnythingOfType("string")).Return(test.expectedGetPasswordResult, test.expectedGetPasswordError)
 // 			repositoryMock.On("UpdatePassword", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(test.expectedUpdatePasswordError)
 
-// 			usersController := NewUsersController(repositoryMock)
+// 			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
 
 // 			securityMock := mocks.NewUsersRepositoryMock()
 // 			securityMock.On("VerifyPassword", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
 
-// 			usersController := NewUsersController(repositoryMock, securityMock)
+// 			usersController := NewUsersController(repositoryMock, securityMock, stream.NewHub(10, 10))
 
 // 			req, _ := http.NewRequest("POST", "/users/test/update-password", test.input)
 // 			parameters := map[string]string{
@@ -680,3 +836,398 @@ func TestGetFollowing(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestChangeNick(t *testing.T) {
+
+	tests := []struct {
+		name                     string
+		input                    string
+		urlId                    string
+		validToken               string
+		expectedChangeNickResult error
+		expectedStatusCode       int
+	}{
+		{
+			name:                     "Success on ChangeNick",
+			input:                    `{"nick":"newnick"}`,
+			urlId:                    "1",
+			validToken:               ValidToken,
+			expectedChangeNickResult: nil,
+			expectedStatusCode:       200,
+		},
+		{
+			name:                     "Error on ChangeNick, nick taken",
+			input:                    `{"nick":"newnick"}`,
+			urlId:                    "1",
+			validToken:               ValidToken,
+			expectedChangeNickResult: repositories.ErrNickTaken,
+			expectedStatusCode:       409,
+		},
+		{
+			name:                     "Error on ChangeNick, nick reserved",
+			input:                    `{"nick":"newnick"}`,
+			urlId:                    "1",
+			validToken:               ValidToken,
+			expectedChangeNickResult: repositories.ErrNickReserved,
+			expectedStatusCode:       409,
+		},
+		{
+			name:                     "Error on ChangeNick, previous change pending",
+			input:                    `{"nick":"newnick"}`,
+			urlId:                    "1",
+			validToken:               ValidToken,
+			expectedChangeNickResult: repositories.ErrNickChangePending,
+			expectedStatusCode:       409,
+		},
+		{
+			name:                     "Error on ChangeNick, repository failure",
+			input:                    `{"nick":"newnick"}`,
+			urlId:                    "1",
+			validToken:               ValidToken,
+			expectedChangeNickResult: assert.AnError,
+			expectedStatusCode:       500,
+		},
+		{
+			name:                     "Error on ChangeNick, tokenId != requestId",
+			input:                    `{"nick":"newnick"}`,
+			urlId:                    "1",
+			validToken:               DiffToken,
+			expectedChangeNickResult: nil,
+			expectedStatusCode:       403,
+		},
+		{
+			name:                     "Error on ChangeNick, empty nick",
+			input:                    `{"nick":" "}`,
+			urlId:                    "1",
+			validToken:               ValidToken,
+			expectedChangeNickResult: nil,
+			expectedStatusCode:       400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewUsersRepositoryMock()
+			repositoryMock.On("ChangeNick", test.urlId, "newnick").Return(test.expectedChangeNickResult)
+
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/users/", strings.NewReader(test.input))
+			req.Header.Add("Authorization", "Bearer "+test.validToken)
+			req = mux.SetURLVars(req, map[string]string{"userID": test.urlId})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(usersController.ChangeNick)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	candidates := []entities.UserSummary{
+		{Nick: "johnsmith", Name: "John Smith", FollowersCount: 3},
+		{Nick: "johanna", Name: "Johanna", FollowersCount: 1000},
+		{Nick: "jon", Name: "Jon", FollowedByViewer: true},
+		{Nick: "julia", Name: "Julia Jhon"},
+		{Nick: "jackson", Name: "Jackson"},
+	}
+
+	tests := []struct {
+		name                string
+		query               string
+		expectedSearchError error
+		expectedStatusCode  int
+		expectedNicks       []string
+	}{
+		{
+			name:                "Success on SearchUsers, prefix and typo",
+			query:               "jhon",
+			expectedSearchError: nil,
+			expectedStatusCode:  200,
+			expectedNicks:       []string{"jon", "julia", "johnsmith"},
+		},
+		{
+			name:                "Success on SearchUsers, followed accounts first",
+			query:               "jo",
+			expectedSearchError: nil,
+			expectedStatusCode:  200,
+			expectedNicks:       []string{"jon", "johanna", "johnsmith"},
+		},
+		{
+			name:                "Error on SearchUsers, empty query",
+			query:               "",
+			expectedSearchError: nil,
+			expectedStatusCode:  400,
+		},
+		{
+			name:                "Error on SearchUsers",
+			query:               "jo",
+			expectedSearchError: assert.AnError,
+			expectedStatusCode:  500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewUsersRepositoryMock()
+			repositoryMock.On("SearchUsers", "1", test.query, mock.AnythingOfType("int")).Return(candidates, test.expectedSearchError)
+
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/users/search?q="+test.query, nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(usersController.SearchUsers)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+			if test.expectedNicks != nil {
+				var results []entities.UserSummary
+				json.Unmarshal(rr.Body.Bytes(), &results)
+
+				nicks := []string{}
+				for _, result := range results {
+					nicks = append(nicks, result.Nick)
+				}
+				assert.Equal(t, test.expectedNicks, nicks)
+			}
+		})
+	}
+}
+
+func TestGetSuggestions(t *testing.T) {
+	candidates := []entities.UserSummary{
+		{Nick: "stranger", TechStack: []string{"go"}},
+		{Nick: "friendoffriends", MutualCount: 3},
+		{Nick: "active", MutualCount: 1, LastPostAt: time.Now()},
+	}
+
+	tests := []struct {
+		name                    string
+		urlId                   string
+		expectedCandidatesError error
+		expectedStatusCode      int
+		expectedNicks           []string
+	}{
+		{
+			name:                    "Success on GetSuggestions",
+			urlId:                   "1",
+			expectedCandidatesError: nil,
+			expectedStatusCode:      200,
+			expectedNicks:           []string{"friendoffriends", "active", "stranger"},
+		},
+		{
+			name:                    "Error on GetSuggestions, another user",
+			urlId:                   "2",
+			expectedCandidatesError: nil,
+			expectedStatusCode:      403,
+		},
+		{
+			name:                    "Error on GetSuggestions",
+			urlId:                   "1",
+			expectedCandidatesError: assert.AnError,
+			expectedStatusCode:      500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewUsersRepositoryMock()
+			repositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1", TechStack: []string{"go"}}, nil)
+			repositoryMock.On("GetSuggestionCandidates", "1", mock.AnythingOfType("int")).Return(candidates, test.expectedCandidatesError)
+
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			// the second request is answered by the cache
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest("GET", "/users/", nil)
+				req.Header.Add("Authorization", "Bearer "+ValidToken)
+				req = mux.SetURLVars(req, map[string]string{"userID": test.urlId})
+
+				rr := httptest.NewRecorder()
+
+				controller := http.HandlerFunc(usersController.GetSuggestions)
+				controller.ServeHTTP(rr, req)
+
+				assert.Equal(t, test.expectedStatusCode, rr.Code)
+
+				if test.expectedNicks != nil {
+					var results []entities.UserSummary
+					json.Unmarshal(rr.Body.Bytes(), &results)
+
+					nicks := []string{}
+					for _, result := range results {
+						nicks = append(nicks, result.Nick)
+					}
+					assert.Equal(t, test.expectedNicks, nicks)
+				}
+			}
+
+			if test.expectedStatusCode == 200 {
+				repositoryMock.AssertNumberOfCalls(t, "GetSuggestionCandidates", 1)
+			}
+		})
+	}
+}
+
+func TestGetRelationships(t *testing.T) {
+
+	tests := []struct {
+		name                  string
+		query                 string
+		expectedNicks         []string
+		expectedRelationships []entities.Relationship
+		expectedError         error
+		expectedStatusCode    int
+	}{
+		{
+			name:                  "Success on GetRelationships",
+			query:                 "?nicks=alice, bob",
+			expectedNicks:         []string{"alice", "bob"},
+			expectedRelationships: []entities.Relationship{{Nick: "alice", Following: true}, {Nick: "bob", BlockedBy: true}},
+			expectedError:         nil,
+			expectedStatusCode:    200,
+		},
+		{
+			name:               "Error on GetRelationships, missing nicks",
+			query:              "",
+			expectedStatusCode: 400,
+		},
+		{
+			name:                  "Error on GetRelationships",
+			query:                 "?nicks=alice",
+			expectedNicks:         []string{"alice"},
+			expectedRelationships: []entities.Relationship{},
+			expectedError:         assert.AnError,
+			expectedStatusCode:    500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewUsersRepositoryMock()
+			repositoryMock.On("GetRelationships", "1", test.expectedNicks).Return(test.expectedRelationships, test.expectedError)
+
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/users/relationships"+strings.ReplaceAll(test.query, " ", "%20"), nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(usersController.GetRelationships)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestGetRelationship(t *testing.T) {
+
+	tests := []struct {
+		name                  string
+		expectedRelationships []entities.Relationship
+		expectedStatusCode    int
+	}{
+		{
+			name:                  "Success on GetRelationship",
+			expectedRelationships: []entities.Relationship{{Nick: "alice", Following: true, FollowedBy: true}},
+			expectedStatusCode:    200,
+		},
+		{
+			name:                  "Error on GetRelationship, unexistent user",
+			expectedRelationships: []entities.Relationship{},
+			expectedStatusCode:    404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewUsersRepositoryMock()
+			repositoryMock.On("GetRelationships", "1", []string{"alice"}).Return(test.expectedRelationships, nil)
+
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("GET", "/users/alice/relationship", nil)
+			req.Header.Add("Authorization", "Bearer "+ValidToken)
+			req = mux.SetURLVars(req, map[string]string{"userID": "alice"})
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(usersController.GetRelationship)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	password, _ := security.Hash("123456")
+	deletedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name               string
+		input              string
+		savedUser          entities.User
+		expectedRestoreErr error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on RestoreUser",
+			input:              `{"email": "user@email.com", "password": "123456"}`,
+			savedUser:          entities.User{Nick: "1", Password: string(password), DeletedAt: &deletedAt},
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on RestoreUser, wrong password",
+			input:              `{"email": "user@email.com", "password": "654321"}`,
+			savedUser:          entities.User{Nick: "1", Password: string(password), DeletedAt: &deletedAt},
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Error on RestoreUser, account not deleted",
+			input:              `{"email": "user@email.com", "password": "123456"}`,
+			savedUser:          entities.User{Nick: "1", Password: string(password)},
+			expectedStatusCode: 400,
+		},
+		{
+			name:               "Error on RestoreUser, retention passed",
+			input:              `{"email": "user@email.com", "password": "123456"}`,
+			savedUser:          entities.User{Nick: "1", Password: string(password), DeletedAt: &deletedAt},
+			expectedRestoreErr: assert.AnError,
+			expectedStatusCode: 404,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repositoryMock := mocks.NewUsersRepositoryMock()
+			repositoryMock.On("SearchByEmail", "user@email.com").Return(test.savedUser, nil)
+			repositoryMock.On("RestoreUser", "1", mock.AnythingOfType("time.Time")).Return(test.expectedRestoreErr)
+
+			usersController := NewUsersController(repositoryMock, mocks.NewMediaRepositoryMock(), mocks.NewNotificationsRepositoryMock(), stream.NewHub(10, 10))
+
+			req, _ := http.NewRequest("POST", "/users/restore", strings.NewReader(test.input))
+
+			rr := httptest.NewRecorder()
+
+			controller := http.HandlerFunc(usersController.RestoreUser)
+			controller.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+			if test.expectedStatusCode == 200 {
+				assert.NotEmpty(t, rr.Body.String())
+			} else if test.expectedStatusCode != 404 {
+				repositoryMock.AssertNotCalled(t, "RestoreUser", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
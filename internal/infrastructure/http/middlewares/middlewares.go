@@ -2,7 +2,9 @@ package middlewares
 
 import (
 	"api/internal/application/auth"
+	"api/internal/domain/repositories"
 	"api/internal/infrastructure/http/responses"
+	"errors"
 	"log"
 	"net/http"
 )
@@ -14,12 +16,27 @@ func Logger(nextFunction http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func Authenticate(nextFunction http.HandlerFunc) http.HandlerFunc {
+// Authenticate lets through the requests with a valid token of an active account. Tokens are stateless, so
+// the account is looked up to stop the ones issued before it was deleted or its nick changed
+func Authenticate(userRepository repositories.UsersRepository, nextFunction http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := auth.ValidateToken(r); err != nil {
 			responses.Error(w, http.StatusUnauthorized, err)
 			return
 		}
+
+		nick, err := auth.GetUserNick(r)
+		if err != nil {
+			responses.Error(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		// deleted accounts aren't found, even while they can still be restored
+		if _, err = userRepository.GetUserByNick(nick); err != nil {
+			responses.Error(w, http.StatusUnauthorized, errors.New("This account doesn't exists anymore"))
+			return
+		}
+
 		nextFunction(w, r)
 	}
 }
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"api/internal/application/auth"
+	"api/internal/domain/entities"
+	"api/internal/domain/repositories/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	validToken, _ := auth.CreateTokenWithNick("1")
+
+	tests := []struct {
+		name               string
+		token              string
+		expectedUserError  error
+		expectedStatusCode int
+	}{
+		{
+			name:               "Success on Authenticate",
+			token:              validToken,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Error on Authenticate, deleted account",
+			token:              validToken,
+			expectedUserError:  assert.AnError,
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Error on Authenticate, invalid token",
+			token:              validToken + "invalidate",
+			expectedStatusCode: 401,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usersRepositoryMock := mocks.NewUsersRepositoryMock()
+			usersRepositoryMock.On("GetUserByNick", "1").Return(entities.User{Nick: "1"}, test.expectedUserError)
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}
+
+			req, _ := http.NewRequest("POST", "/posts", nil)
+			req.Header.Add("Authorization", "Bearer "+test.token)
+
+			rr := httptest.NewRecorder()
+
+			Authenticate(usersRepositoryMock, next).ServeHTTP(rr, req)
+
+			assert.Equal(t, test.expectedStatusCode, rr.Code)
+		})
+	}
+}
@@ -20,7 +20,7 @@ func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
 
 func Error(w http.ResponseWriter, statusCode int, err error) {
 	JSON(w, statusCode, struct {
-		Error string `json: "error"`
+		Error string `json:"error"`
 	}{
 		Error: err.Error(),
 	})
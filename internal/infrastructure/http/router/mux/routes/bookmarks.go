@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigBookmarksRoutes(db *mongo.Database) []Route {
+
+	repository := repositories.NewBookmarksRepository(db)
+
+	postsRepository := repositories.NewPostsRepository(db)
+
+	usersRepository := repositories.NewUsersRepository(db)
+
+	controllers := controllers.NewBookmarksController(repository, postsRepository, usersRepository)
+
+	var bookmarksRoutes = []Route{
+		{
+			URI:          "/posts/{postID}/bookmark",
+			Method:       http.MethodPost,
+			Controller:   controllers.BookmarkPost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/bookmark",
+			Method:       http.MethodDelete,
+			Controller:   controllers.RemoveBookmark,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/bookmarks",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetBookmarks,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/bookmarks/collections",
+			Method:       http.MethodPost,
+			Controller:   controllers.CreateCollection,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/bookmarks/collections",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetCollections,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/bookmarks/collections/{collectionID}",
+			Method:       http.MethodPut,
+			Controller:   controllers.RenameCollection,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/bookmarks/collections/{collectionID}",
+			Method:       http.MethodDelete,
+			Controller:   controllers.DeleteCollection,
+			RequiresAuth: true,
+		},
+	}
+
+	return bookmarksRoutes
+}
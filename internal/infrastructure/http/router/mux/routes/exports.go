@@ -0,0 +1,46 @@
+package routes
+
+import (
+	domain "api/internal/domain/repositories"
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigExportsRoutes(db *mongo.Database, blobStore domain.BlobStore) []Route {
+
+	repository := repositories.NewExportsRepository(db)
+
+	controllers := controllers.NewExportsController(repository, blobStore)
+
+	var exportsRoutes = []Route{
+		{
+			URI:          "/users/{userID}/export",
+			Method:       http.MethodPost,
+			Controller:   controllers.CreateExport,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/exports",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetExports,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/exports/{exportID}",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetExport,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/exports/{exportID}/download",
+			Method:       http.MethodGet,
+			Controller:   controllers.DownloadExport,
+			RequiresAuth: true,
+		},
+	}
+
+	return exportsRoutes
+}
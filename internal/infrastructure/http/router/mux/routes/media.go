@@ -0,0 +1,46 @@
+package routes
+
+import (
+	domain "api/internal/domain/repositories"
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigMediaRoutes(db *mongo.Database, blobStore domain.BlobStore) []Route {
+
+	repository := repositories.NewMediaRepository(db)
+
+	controllers := controllers.NewMediaController(repository, blobStore)
+
+	var mediaRoutes = []Route{
+		{
+			URI:          "/media",
+			Method:       http.MethodPost,
+			Controller:   controllers.UploadMedia,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/media/{mediaID}",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetMedia,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/media/{mediaID}/file",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetMediaFile,
+			RequiresAuth: false,
+		},
+		{
+			URI:          "/media/{mediaID}",
+			Method:       http.MethodDelete,
+			Controller:   controllers.DeleteMedia,
+			RequiresAuth: true,
+		},
+	}
+
+	return mediaRoutes
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"api/internal/application/stream"
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigMessagesRoutes(db *mongo.Database, hub *stream.Hub) []Route {
+
+	repository := repositories.NewMessagesRepository(db)
+
+	usersRepository := repositories.NewUsersRepository(db)
+
+	controllers := controllers.NewMessagesController(repository, usersRepository, hub)
+
+	var messagesRoutes = []Route{
+		{
+			URI:          "/conversations",
+			Method:       http.MethodPost,
+			Controller:   controllers.CreateConversation,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/conversations",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetConversations,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/conversations/{conversationID}",
+			Method:       http.MethodDelete,
+			Controller:   controllers.DeleteConversation,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/conversations/{conversationID}/messages",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetMessages,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/conversations/{conversationID}/messages",
+			Method:       http.MethodPost,
+			Controller:   controllers.SendMessage,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/conversations/{conversationID}/read",
+			Method:       http.MethodPost,
+			Controller:   controllers.MarkRead,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/conversations/{conversationID}/messages/{messageID}",
+			Method:       http.MethodDelete,
+			Controller:   controllers.DeleteMessage,
+			RequiresAuth: true,
+		},
+	}
+	return messagesRoutes
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigNotificationsRoutes(db *mongo.Database) []Route {
+
+	repository := repositories.NewNotificationsRepository(db)
+
+	usersRepository := repositories.NewUsersRepository(db)
+
+	controllers := controllers.NewNotificationsController(repository, usersRepository)
+
+	var notificationsRoutes = []Route{
+		{
+			URI:          "/notifications",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetNotifications,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/notifications/read",
+			Method:       http.MethodPost,
+			Controller:   controllers.MarkRead,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/notifications/preferences",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetPreferences,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/notifications/preferences",
+			Method:       http.MethodPut,
+			Controller:   controllers.UpdatePreferences,
+			RequiresAuth: true,
+		},
+	}
+	return notificationsRoutes
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"api/internal/application/stream"
 	"api/internal/infrastructure/database/repositories"
 	"api/internal/infrastructure/http/controllers"
 	"net/http"
@@ -8,11 +9,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func ConfigPostsRoutes(db *mongo.Database) []Route {
+func ConfigPostsRoutes(db *mongo.Database, hub *stream.Hub) []Route {
 
 	repository := repositories.NewPostsRepository(db)
 
-	controllers := controllers.NewPostsController(*repository)
+	mediaRepository := repositories.NewMediaRepository(db)
+
+	usersRepository := repositories.NewUsersRepository(db)
+
+	notificationsRepository := repositories.NewNotificationsRepository(db)
+
+	controllers := controllers.NewPostsController(repository, mediaRepository, usersRepository, notificationsRepository, hub)
 
 	var PostsRoutes = []Route{
 		{
@@ -21,6 +28,24 @@ func ConfigPostsRoutes(db *mongo.Database) []Route {
 			Controller:   controllers.CreatePost,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/posts/feed",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetFeed,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/drafts",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetDrafts,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/trash",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetTrash,
+			RequiresAuth: true,
+		},
 		{
 			URI:          "/posts/{userID}",
 			Method:       http.MethodGet,
@@ -63,6 +88,90 @@ func ConfigPostsRoutes(db *mongo.Database) []Route {
 			Controller:   controllers.DislikePost,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/posts/{postID}/reactions",
+			Method:       http.MethodPost,
+			Controller:   controllers.ReactToPost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/reactions",
+			Method:       http.MethodDelete,
+			Controller:   controllers.RemoveReaction,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/reactions",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetReactions,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/replies",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetReplies,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/thread",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetThread,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/revisions",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetRevisions,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/publish",
+			Method:       http.MethodPost,
+			Controller:   controllers.PublishPost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/restore",
+			Method:       http.MethodPost,
+			Controller:   controllers.RestorePost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/repost",
+			Method:       http.MethodPost,
+			Controller:   controllers.Repost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/repost",
+			Method:       http.MethodDelete,
+			Controller:   controllers.Unrepost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/vote",
+			Method:       http.MethodPost,
+			Controller:   controllers.VotePoll,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/pin",
+			Method:       http.MethodPost,
+			Controller:   controllers.PinPost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/posts/{postID}/pin",
+			Method:       http.MethodDelete,
+			Controller:   controllers.UnpinPost,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/mentions",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetMentions,
+			RequiresAuth: true,
+		},
 	}
 	return PostsRoutes
 }
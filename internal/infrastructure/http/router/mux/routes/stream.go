@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"api/internal/application/stream"
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigStreamRoutes(db *mongo.Database, hub *stream.Hub) []Route {
+
+	usersRepository := repositories.NewUsersRepository(db)
+
+	controllers := controllers.NewStreamController(usersRepository, hub)
+
+	var streamRoutes = []Route{
+		{
+			URI:        "/stream",
+			Method:     http.MethodGet,
+			Controller: controllers.Stream,
+			// the controller authenticates by itself, as browsers can't send headers when opening the stream
+			RequiresAuth: false,
+		},
+	}
+	return streamRoutes
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"api/internal/application/stream"
+	"api/internal/infrastructure/database/repositories"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ConfigTagsRoutes(db *mongo.Database, hub *stream.Hub) []Route {
+
+	repository := repositories.NewPostsRepository(db)
+
+	mediaRepository := repositories.NewMediaRepository(db)
+
+	usersRepository := repositories.NewUsersRepository(db)
+
+	notificationsRepository := repositories.NewNotificationsRepository(db)
+
+	controllers := controllers.NewPostsController(repository, mediaRepository, usersRepository, notificationsRepository, hub)
+
+	var tagsRoutes = []Route{
+		{
+			URI:          "/tags/search",
+			Method:       http.MethodGet,
+			Controller:   controllers.SearchTags,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/tags/{tag}/posts",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetTagPosts,
+			RequiresAuth: true,
+		},
+	}
+	return tagsRoutes
+}
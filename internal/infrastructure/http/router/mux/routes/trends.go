@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"api/internal/application/trends"
+	"api/internal/infrastructure/http/controllers"
+	"net/http"
+)
+
+func ConfigTrendsRoutes(cache *trends.Cache) []Route {
+
+	controllers := controllers.NewTrendsController(cache)
+
+	var trendsRoutes = []Route{
+		{
+			URI:          "/trends",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetTrends,
+			RequiresAuth: true,
+		},
+	}
+	return trendsRoutes
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"api/internal/application/stream"
 	"api/internal/infrastructure/database/repositories"
 	"api/internal/infrastructure/http/controllers"
 	"net/http"
@@ -8,11 +9,15 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func ConfigUsersRoutes(db *mongo.Database) []Route {
+func ConfigUsersRoutes(db *mongo.Database, hub *stream.Hub) []Route {
 
 	repository := repositories.NewUsersRepository(db)
 
-	controllers := controllers.NewUsersController(repository)
+	mediaRepository := repositories.NewMediaRepository(db)
+
+	notificationsRepository := repositories.NewNotificationsRepository(db)
+
+	controllers := controllers.NewUsersController(repository, mediaRepository, notificationsRepository, hub)
 
 	var userRoutes = []Route{
 		{
@@ -27,6 +32,24 @@ func ConfigUsersRoutes(db *mongo.Database) []Route {
 			Controller:   controllers.GetAllUsers,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/users/restore",
+			Method:       http.MethodPost,
+			Controller:   controllers.RestoreUser,
+			RequiresAuth: false,
+		},
+		{
+			URI:          "/users/relationships",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetRelationships,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/search",
+			Method:       http.MethodGet,
+			Controller:   controllers.SearchUsers,
+			RequiresAuth: true,
+		},
 		{
 			URI:          "/users/{userID}",
 			Method:       http.MethodGet,
@@ -39,6 +62,12 @@ func ConfigUsersRoutes(db *mongo.Database) []Route {
 			Controller:   controllers.UpdateUser,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/users/{userID}",
+			Method:       http.MethodPatch,
+			Controller:   controllers.UpdateUser,
+			RequiresAuth: true,
+		},
 		{
 			URI:          "/users/{userID}",
 			Method:       http.MethodDelete,
@@ -75,6 +104,96 @@ func ConfigUsersRoutes(db *mongo.Database) []Route {
 			Controller:   controllers.UpdatePassword,
 			RequiresAuth: true,
 		},
+		{
+			URI:          "/users/{userID}/nick",
+			Method:       http.MethodPost,
+			Controller:   controllers.ChangeNick,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/nick-history",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetNickHistory,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/block",
+			Method:       http.MethodPost,
+			Controller:   controllers.BlockUser,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/unblock",
+			Method:       http.MethodDelete,
+			Controller:   controllers.UnblockUser,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/blocked",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetBlocked,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/privacy",
+			Method:       http.MethodPut,
+			Controller:   controllers.UpdatePrivacy,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/follow-requests",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetFollowRequests,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/follow-requests/{requesterID}/accept",
+			Method:       http.MethodPost,
+			Controller:   controllers.AcceptFollowRequest,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/follow-requests/{requesterID}",
+			Method:       http.MethodDelete,
+			Controller:   controllers.RejectFollowRequest,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/mute",
+			Method:       http.MethodPost,
+			Controller:   controllers.MuteUser,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/unmute",
+			Method:       http.MethodDelete,
+			Controller:   controllers.UnmuteUser,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/muted",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetMuted,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/suggestions",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetSuggestions,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/relationship",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetRelationship,
+			RequiresAuth: true,
+		},
+		{
+			URI:          "/users/{userID}/mutuals",
+			Method:       http.MethodGet,
+			Controller:   controllers.GetMutuals,
+			RequiresAuth: true,
+		},
 	}
 
 	return userRoutes
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalBlobStore struct {
+	root string
+}
+
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalBlobStore{
+		root,
+	}, nil
+}
+
+func (store *LocalBlobStore) Put(key string, contentType string, data []byte) error {
+	return store.PutStream(key, contentType, bytes.NewReader(data), int64(len(data)))
+}
+
+func (store *LocalBlobStore) PutStream(key string, contentType string, data io.ReadSeeker, size int64) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// write on a temporary file first so a reader never sees a half written blob
+	temporaryPath := path + ".tmp"
+	file, err := os.OpenFile(temporaryPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.CopyN(file, data, size); err != nil {
+		file.Close()
+		os.Remove(temporaryPath)
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		os.Remove(temporaryPath)
+		return err
+	}
+
+	return os.Rename(temporaryPath, path)
+}
+
+func (store *LocalBlobStore) Get(key string) ([]byte, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+
+	return data, err
+}
+
+func (store *LocalBlobStore) GetStream(key string) (io.ReadCloser, int64, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	return file, info.Size(), nil
+}
+
+func (store *LocalBlobStore) Delete(key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (store *LocalBlobStore) path(key string) (string, error) {
+	cleanKey := filepath.Clean("/" + key)
+	if key == "" || strings.Contains(key, "..") {
+		return "", errors.New("Invalid blob key")
+	}
+
+	return filepath.Join(store.root, cleanKey), nil
+}
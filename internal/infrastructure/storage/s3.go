@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3BlobStore talks to any S3 compatible API (AWS, MinIO, ...) using path style URLs
+type S3BlobStore struct {
+	endpoint  string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+func NewS3BlobStore(endpoint, bucket, region, accessKey, secretKey string) *S3BlobStore {
+	return &S3BlobStore{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (store *S3BlobStore) Put(key string, contentType string, data []byte) error {
+	return store.PutStream(key, contentType, bytes.NewReader(data), int64(len(data)))
+}
+
+// PutStream reads data twice, once to sign its hash and once more to send it
+func (store *S3BlobStore) PutStream(key string, contentType string, data io.ReadSeeker, size int64) error {
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, data, size); err != nil {
+		return err
+	}
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, store.objectURL(key), io.LimitReader(data, size))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	signHashedRequest(req, hex.EncodeToString(hash.Sum(nil)), store.accessKey, store.secretKey, store.region, time.Now())
+	res, err := store.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+func (store *S3BlobStore) Get(key string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, store.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := store.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+func (store *S3BlobStore) GetStream(key string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequest(http.MethodGet, store.objectURL(key), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, err := store.do(req, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, 0, ErrBlobNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, 0, responseError(res)
+	}
+
+	// the body is left open, the caller reads the object from it
+	return res.Body, res.ContentLength, nil
+}
+
+func (store *S3BlobStore) Delete(key string) error {
+	req, err := http.NewRequest(http.MethodDelete, store.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := store.do(req, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+func (store *S3BlobStore) objectURL(key string) string {
+	return store.endpoint + "/" + store.bucket + "/" + uriEncode(strings.TrimLeft(key, "/"), false)
+}
+
+func (store *S3BlobStore) do(req *http.Request, payload []byte) (*http.Response, error) {
+	signRequest(req, payload, store.accessKey, store.secretKey, store.region, time.Now())
+	return store.client.Do(req)
+}
+
+func responseError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("Storage request failed with status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// signRequest adds the AWS signature version 4 headers to the request
+func signRequest(req *http.Request, payload []byte, accessKey, secretKey, region string, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	signHashedRequest(req, hex.EncodeToString(payloadHash[:]), accessKey, secretKey, region, now)
+}
+
+// signHashedRequest signs the request given the hex encoded SHA-256 of its payload
+func signHashedRequest(req *http.Request, payloadHash string, accessKey, secretKey, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || name == "content-md5" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		isUnreserved := (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '.' || b == '_' || b == '~'
+
+		if isUnreserved || (b == '/' && !encodeSlash) {
+			encoded.WriteByte(b)
+		} else {
+			encoded.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+
+	return encoded.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(data))
+	return hash.Sum(nil)
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"api/internal/domain/repositories"
+	"api/internal/infrastructure/config"
+	"errors"
+	"fmt"
+)
+
+var ErrBlobNotFound = repositories.ErrBlobNotFound
+
+// New builds the blob store configured by MEDIA_STORAGE
+func New() (repositories.BlobStore, error) {
+	switch config.MediaStorage {
+	case "", "local":
+		return NewLocalBlobStore(config.MediaLocalPath)
+	case "s3":
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for the s3 media storage")
+		}
+		return NewS3BlobStore(config.S3Endpoint, config.S3Bucket, config.S3Region, config.S3AccessKey, config.S3SecretKey), nil
+	default:
+		return nil, fmt.Errorf("Unknown media storage %s", config.MediaStorage)
+	}
+}